@@ -15,7 +15,9 @@ import (
 	"github.com/cesareyeserrano/ultron-ap/internal/database"
 	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
 	"github.com/cesareyeserrano/ultron-ap/internal/server"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
 )
 
@@ -42,7 +44,38 @@ func main() {
 
 	// Start metrics collector
 	reader := metrics.NewSystemReader()
+	reader.SetPrimaryTempSensor(cfg.TempSensor)
+	reader.SetReaderTimeout(cfg.ReaderTimeout)
+	diskFilter := metrics.DefaultDiskFilter()
+	diskFilter.IncludeFSTypes = cfg.DiskIncludeFSTypes
+	diskFilter.IncludeMounts = cfg.DiskIncludeMounts
+	diskFilter.ExcludeMounts = cfg.DiskExcludeMounts
+	if cfg.DiskExcludeFSTypes != nil {
+		diskFilter.ExcludeFSTypes = cfg.DiskExcludeFSTypes
+	}
+	reader.SetDiskFilter(diskFilter)
+	netFilter := metrics.DefaultNetFilter()
+	netFilter.Include = cfg.NetInclude
+	if cfg.NetExclude != nil {
+		netFilter.Exclude = cfg.NetExclude
+	}
+	reader.SetNetFilter(netFilter)
+	if len(cfg.Collectors) > 0 {
+		custom := metrics.NewCustomCollectors(customCollectorConfigs(cfg.Collectors))
+		custom.Start(context.Background())
+		defer custom.Stop()
+		reader.SetCustomCollectors(custom)
+	}
+	if cfg.IngestAddr != "" {
+		ingest := metrics.NewIngest()
+		if err := ingest.Listen(cfg.IngestAddr); err != nil {
+			log.Fatalf("Failed to start metrics ingest: %v", err)
+		}
+		defer ingest.Close()
+		reader.SetIngest(ingest)
+	}
 	collector := metrics.NewCollector(reader, cfg.MetricsInterval, 24*time.Hour)
+	collector.EnablePersistence(db, metrics.DefaultTiers)
 	collector.Start(context.Background())
 	defer collector.Stop()
 
@@ -56,6 +89,16 @@ func main() {
 	systemdMon.Start(context.Background())
 	defer systemdMon.Stop()
 
+	// Start synthetic probes
+	probeMon := probes.NewMonitor(db)
+	probeMon.Start(context.Background())
+	defer probeMon.Stop()
+
+	// Start SMART monitor
+	smartMon := smart.NewMonitor()
+	smartMon.Start(context.Background())
+	defer smartMon.Stop()
+
 	// Seed default alert rules
 	if err := db.SeedDefaultAlertConfigs(); err != nil {
 		log.Fatalf("Failed to seed default alert configs: %v", err)
@@ -63,11 +106,16 @@ func main() {
 
 	// Start alert engine
 	alertEng := alerts.NewEngine(db, collector, dockerMon, systemdMon, cfg.MetricsInterval)
+	alertEng.SetProbes(probeMon)
+	alertEng.SetSmart(smartMon)
+	alertEng.SetCrashLoop(cfg.CrashLoopRestarts, cfg.CrashLoopWindow)
 	alertEng.Start(context.Background())
 	defer alertEng.Stop()
 
 	// Create server
 	srv := server.New(cfg, db, collector, dockerMon, systemdMon, alertEng)
+	srv.SetProbes(probeMon)
+	srv.SetSmart(smartMon)
 
 	// Start server in goroutine
 	errCh := make(chan error, 1)
@@ -124,3 +172,18 @@ func bootstrapAdmin(cfg *config.Config, db *database.DB) error {
 	log.Printf("Admin user %q created", cfg.AdminUser)
 	return nil
 }
+
+func customCollectorConfigs(collectors []config.CustomCollector) []metrics.CustomCollectorConfig {
+	configs := make([]metrics.CustomCollectorConfig, len(collectors))
+	for i, c := range collectors {
+		configs[i] = metrics.CustomCollectorConfig{
+			Name:     c.Name,
+			Command:  c.Command,
+			Args:     c.Args,
+			Interval: c.Interval,
+			Timeout:  c.Timeout,
+			Format:   c.Format,
+		}
+	}
+	return configs
+}
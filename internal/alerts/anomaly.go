@@ -0,0 +1,213 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+const (
+	// baselineTau is the time constant of the baseline averages: older samples
+	// fade out with weight e^(-age/tau). For hourly baselines the age counts
+	// only time spent in that hour, so 6 hours there covers about 6 days.
+	baselineTau = 6 * time.Hour
+	// baselineMaxStep caps the weight of a single sample, so gaps such as a
+	// restart or the other 23 hours of an hourly baseline don't wipe it.
+	baselineMaxStep = 5 * time.Minute
+	// baselineWarmup is how much time a baseline must have learned before
+	// anomalies are reported.
+	baselineWarmup = time.Hour
+	// baselineHistory and baselineStep select the collector history a new
+	// baseline is seeded from.
+	baselineHistory = 7 * 24 * time.Hour
+	baselineStep    = 5 * time.Minute
+	// minStdDevRatio and minStdDev floor the standard deviation, so a metric
+	// that has been flat for hours doesn't alert on the smallest change.
+	minStdDevRatio = 0.05
+	minStdDev      = 0.01
+)
+
+// baseline is an exponentially weighted mean and variance of a metric.
+type baseline struct {
+	mean, variance float64
+	learned        time.Duration // total sample weight, see baselineMaxStep
+	last           time.Time
+}
+
+// add folds the value x observed at t into the baseline.
+func (b *baseline) add(t time.Time, x float64) {
+	if b.last.IsZero() {
+		b.mean, b.last = x, t
+		return
+	}
+	dt := t.Sub(b.last)
+	if dt <= 0 {
+		return
+	}
+	dt = min(dt, baselineMaxStep)
+
+	alpha := 1 - math.Exp(-float64(dt)/float64(baselineTau))
+	diff := x - b.mean
+	incr := alpha * diff
+	b.mean += incr
+	b.variance = (1 - alpha) * (b.variance + diff*incr)
+	b.learned += dt
+	b.last = t
+}
+
+// stdDev returns the floored standard deviation of the baseline. The variance
+// starts at zero, so it is divided by the weight learned so far to correct
+// the bias towards zero of a young baseline.
+func (b *baseline) stdDev() float64 {
+	variance := b.variance
+	if weight := 1 - math.Exp(-float64(b.learned)/float64(baselineTau)); weight > 0 {
+		variance /= weight
+	}
+	return max(math.Sqrt(variance), minStdDevRatio*math.Abs(b.mean), minStdDev)
+}
+
+// deviates reports whether x lies more than k standard deviations above
+// (operator > or >=) or below (< or <=) the baseline, once it has warmed up.
+func (b *baseline) deviates(x float64, operator string, k float64) bool {
+	if b.learned < baselineWarmup {
+		return false
+	}
+	limit := k * b.stdDev()
+	switch operator {
+	case ">", ">=":
+		return compareValue(x-b.mean, operator, limit)
+	case "<", "<=":
+		return compareValue(b.mean-x, reverseOperator(operator), limit)
+	default:
+		return false
+	}
+}
+
+func reverseOperator(op string) string {
+	if op == "<" {
+		return ">"
+	}
+	return ">="
+}
+
+// anomalyModel holds the baseline of one metric, or one per hour of the day.
+type anomalyModel struct {
+	buckets []baseline
+	// The latest sample, learned only once a newer one is observed.
+	pendingAt    time.Time
+	pendingValue float64
+}
+
+func newAnomalyModel(hourly bool) *anomalyModel {
+	if hourly {
+		return &anomalyModel{buckets: make([]baseline, 24)}
+	}
+	return &anomalyModel{buckets: make([]baseline, 1)}
+}
+
+// at returns the baseline that applies at t.
+func (m *anomalyModel) at(t time.Time) *baseline {
+	if len(m.buckets) == 24 {
+		return &m.buckets[t.Hour()]
+	}
+	return &m.buckets[0]
+}
+
+// observe returns the baseline that applies at t, first learning the held
+// back sample if t is newer, and holds back x. Every rule that shares the
+// model then checks the sample at t against the same baseline, one that
+// doesn't contain it yet, and the sample is learned once.
+func (m *anomalyModel) observe(t time.Time, x float64) *baseline {
+	if t.After(m.pendingAt) {
+		if !m.pendingAt.IsZero() {
+			m.at(m.pendingAt).add(m.pendingAt, m.pendingValue)
+		}
+		m.pendingAt, m.pendingValue = t, x
+	}
+	return m.at(t)
+}
+
+// anomalyKey identifies the model of a rule. Rules on the same metric share it.
+func anomalyKey(cfg database.AlertConfig) string {
+	if cfg.Hourly {
+		return ruleSource(cfg) + "@hourly"
+	}
+	return ruleSource(cfg)
+}
+
+// historySeries returns the collector series a rule's metric is recorded
+// under, or "" if it is derived from several series or not recorded.
+func historySeries(cfg database.AlertConfig) string {
+	if cfg.Target != "" {
+		switch cfg.Metric {
+		case "temp", "disk", "custom":
+			return cfg.Metric + ":" + cfg.Target
+		}
+		return ""
+	}
+	switch cfg.Metric {
+	case "cpu", "ram", "swap", "mem_pressure", "temp", "load1", "load5", "load15", "iowait", "steal":
+		return cfg.Metric
+	}
+	return ""
+}
+
+// anomalyModel returns the model for cfg, seeding a new one from the
+// collector's history when the metric is recorded there. Otherwise the model
+// learns from evaluations only and stays quiet until it has warmed up.
+func (e *Engine) anomalyModel(cfg database.AlertConfig) *anomalyModel {
+	key := anomalyKey(cfg)
+	if m, ok := e.baselines[key]; ok {
+		return m
+	}
+
+	m := newAnomalyModel(cfg.Hourly)
+	if series := historySeries(cfg); series != "" && e.collector != nil {
+		now := time.Now()
+		for _, s := range e.collector.Query(now.Add(-baselineHistory), now, baselineStep, []string{series}) {
+			if s.Name != series {
+				continue // a prefix match such as temp:cpu_thermal for temp
+			}
+			for _, p := range s.Points {
+				m.at(p.Timestamp).add(p.Timestamp, p.Avg)
+			}
+		}
+	}
+	e.baselines[key] = m
+	return m
+}
+
+// checkAnomaly compares value, observed at t, with the baseline of cfg, which
+// learns it once the next value is observed. It returns the alert message if
+// value is anomalous.
+func (e *Engine) checkAnomaly(cfg database.AlertConfig, t time.Time, value float64) (string, bool) {
+	b := e.anomalyModel(cfg).observe(t, value)
+	mean, sd := b.mean, b.stdDev()
+	if !b.deviates(value, cfg.Operator, cfg.Threshold) {
+		return "", false
+	}
+
+	direction := "above"
+	if cfg.Operator == "<" || cfg.Operator == "<=" {
+		direction = "below"
+	}
+	return fmt.Sprintf("%s: %.1f is %.1fσ %s its baseline of %.1f ± %.1f",
+		cfg.Name, value, math.Abs(value-mean)/sd, direction, mean, sd), true
+}
+
+// pruneBaselines drops the models no enabled anomaly rule uses any more.
+func (e *Engine) pruneBaselines(configs []database.AlertConfig) {
+	used := make(map[string]bool)
+	for _, cfg := range configs {
+		if cfg.Mode == database.AlertModeAnomaly {
+			used[anomalyKey(cfg)] = true
+		}
+	}
+	for key := range e.baselines {
+		if !used[key] {
+			delete(e.baselines, key)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+)
+
+// learn adds values alternating between lo and hi, one per step, for d.
+func learn(b *baseline, start time.Time, step, d time.Duration, lo, hi float64) time.Time {
+	t := start
+	for i := 0; t.Before(start.Add(d)); i++ {
+		if i%2 == 0 {
+			b.add(t, lo)
+		} else {
+			b.add(t, hi)
+		}
+		t = t.Add(step)
+	}
+	return t
+}
+
+func TestBaseline_LearnsMeanAndVariance(t *testing.T) {
+	var b baseline
+	learn(&b, time.Unix(1700000000, 0), time.Minute, 24*time.Hour, 40, 60)
+
+	assert.InDelta(t, 50, b.mean, 0.5)
+	assert.InDelta(t, 10, b.stdDev(), 0.5)
+	assert.Equal(t, 24*time.Hour-time.Minute, b.learned)
+}
+
+func TestBaseline_Deviates(t *testing.T) {
+	var b baseline
+	now := learn(&b, time.Unix(1700000000, 0), time.Minute, 30*time.Minute, 40, 60)
+	assert.False(t, b.deviates(95, ">", 3), "still warming up")
+
+	learn(&b, now, time.Minute, 24*time.Hour, 40, 60)
+	assert.True(t, b.deviates(95, ">", 3))
+	assert.False(t, b.deviates(75, ">", 3))
+	assert.False(t, b.deviates(5, ">", 3), "> only looks above the baseline")
+	assert.True(t, b.deviates(5, "<", 3))
+	assert.True(t, b.deviates(5, "<=", 3))
+	assert.False(t, b.deviates(5, "==", 3))
+}
+
+func TestBaseline_FlatMetricHasFloor(t *testing.T) {
+	var b baseline
+	learn(&b, time.Unix(1700000000, 0), time.Minute, 2*time.Hour, 20, 20)
+
+	assert.InDelta(t, 1, b.stdDev(), 0.001, "5% of the mean")
+	assert.False(t, b.deviates(22, ">", 3))
+	assert.True(t, b.deviates(24, ">", 3))
+}
+
+func TestBaseline_GapsDoNotResetIt(t *testing.T) {
+	var b baseline
+	now := learn(&b, time.Unix(1700000000, 0), time.Minute, 2*time.Hour, 40, 60)
+	b.add(now.Add(23*time.Hour), 500)
+	assert.Less(t, b.mean, 60.0, "one sample after a long gap weighs like one step")
+}
+
+func TestAnomalyModel_Hourly(t *testing.T) {
+	m := newAnomalyModel(true)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	for d := 0; d < 3; d++ {
+		// Busy at 14:00, idle at 03:00.
+		learn(m.at(day.Add(14*time.Hour)), day.Add(14*time.Hour), time.Minute, time.Hour, 70, 80)
+		learn(m.at(day.Add(3*time.Hour)), day.Add(3*time.Hour), time.Minute, time.Hour, 2, 4)
+		day = day.Add(24 * time.Hour)
+	}
+
+	assert.False(t, m.at(day.Add(14*time.Hour)).deviates(78, ">", 3), "normal for the afternoon")
+	assert.True(t, m.at(day.Add(3*time.Hour)).deviates(78, ">", 3), "anomalous at night")
+	assert.False(t, m.at(day.Add(9*time.Hour)).deviates(78, ">", 3), "nothing learned for 09:00")
+
+	assert.Len(t, newAnomalyModel(false).buckets, 1)
+}
+
+func TestHistorySeries(t *testing.T) {
+	assert.Equal(t, "cpu", historySeries(database.AlertConfig{Metric: "cpu"}))
+	assert.Equal(t, "temp", historySeries(database.AlertConfig{Metric: "temp"}))
+	assert.Equal(t, "temp:nvme_composite", historySeries(database.AlertConfig{Metric: "temp", Target: "nvme_composite"}))
+	assert.Equal(t, "disk:/data", historySeries(database.AlertConfig{Metric: "disk", Target: "/data"}))
+	assert.Empty(t, historySeries(database.AlertConfig{Metric: "disk"}), "the fullest disk is not a series")
+	assert.Empty(t, historySeries(database.AlertConfig{Metric: "net_errors", Target: "eth0"}))
+}
+
+func TestEvaluateMetricRule_AnomalySeededFromHistory(t *testing.T) {
+	db := setupTestDB(t)
+	now := time.Now()
+	for ts, i := now.Add(-6*time.Hour), 0; ts.Before(now); ts, i = ts.Add(time.Minute), i+1 {
+		cpu := 20.0
+		if i%2 == 1 {
+			cpu = 30
+		}
+		require.NoError(t, db.InsertMetricSamples(ts, map[string]float64{"cpu": cpu, "temp:cpu_thermal": 90}))
+	}
+	collector := metrics.NewCollector(nil, time.Minute, time.Hour)
+	collector.EnablePersistence(db, nil)
+
+	ac := &database.AlertConfig{Name: "CPU unusual", Metric: "cpu", Mode: database.AlertModeAnomaly, Operator: ">", Threshold: 3, Severity: "warning", Enabled: true}
+	require.NoError(t, db.CreateAlertConfig(ac))
+	eng := NewEngine(db, collector, nil, nil, time.Minute)
+
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Timestamp: now, CPU: metrics.CPUMetrics{TotalPercent: 28}})
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Timestamp: now.Add(time.Minute), CPU: metrics.CPUMetrics{TotalPercent: 85}})
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Contains(t, alerts[0].Message, "CPU unusual: 85.0 is")
+	assert.Contains(t, alerts[0].Message, "above its baseline of")
+	assert.Equal(t, "cpu", alerts[0].Source)
+	assert.Equal(t, 85.0, *alerts[0].Value)
+
+	// temp has no history of its own, only temp:cpu_thermal: it must warm up first.
+	temp := 90.0
+	tc := &database.AlertConfig{Name: "Temp unusual", Metric: "temp", Mode: database.AlertModeAnomaly, Operator: ">", Threshold: 3, Severity: "warning", Enabled: true}
+	require.NoError(t, db.CreateAlertConfig(tc))
+	eng.evaluateMetricRule(*tc, &metrics.Snapshot{Timestamp: now, Temperature: &temp})
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+}
+
+func TestEvaluateMetricRule_AnomalyWarmsUpWithoutHistory(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "Load unusual", Metric: "load5_per_core", Mode: database.AlertModeAnomaly, Operator: ">", Threshold: 3, Severity: "warning", Enabled: true}
+	require.NoError(t, db.CreateAlertConfig(ac))
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+
+	start := time.Unix(1700000000, 0)
+	snap := func(i int, load float64) *metrics.Snapshot {
+		return &metrics.Snapshot{Timestamp: start.Add(time.Duration(i) * time.Minute), CPU: metrics.CPUMetrics{Load5: load, PerCore: []float64{0, 0, 0, 0}}}
+	}
+	eng.evaluateMetricRule(*ac, snap(0, 0.5))
+	eng.evaluateMetricRule(*ac, snap(1, 12))
+	for i := 2; i < 90; i++ {
+		eng.evaluateMetricRule(*ac, snap(i, 0.4+0.4*float64(i%2)))
+	}
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts, "no alerts while warming up")
+
+	eng.evaluateMetricRule(*ac, snap(90, 12))
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Contains(t, alerts[0].Message, "Load unusual: 3.0 is")
+}
+
+func TestEvaluateMetricRule_AnomalyRulesShareOneSample(t *testing.T) {
+	db := setupTestDB(t)
+	high := database.AlertConfig{ID: 1, Name: "CPU high", Metric: "cpu", Mode: database.AlertModeAnomaly, Operator: ">", Threshold: 3, Severity: "warning", Enabled: true}
+	low := database.AlertConfig{ID: 2, Name: "CPU low", Metric: "cpu", Mode: database.AlertModeAnomaly, Operator: "<", Threshold: 2, Severity: "warning", Enabled: true}
+	single := NewEngine(db, nil, nil, nil, time.Minute)
+	shared := NewEngine(db, nil, nil, nil, time.Minute)
+
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 120; i++ {
+		snap := &metrics.Snapshot{Timestamp: start.Add(time.Duration(i) * time.Minute), CPU: metrics.CPUMetrics{TotalPercent: 20 + float64(i%5)}}
+		single.evaluateMetricRule(high, snap)
+		shared.evaluateMetricRule(high, snap)
+		shared.evaluateMetricRule(low, snap)
+	}
+
+	assert.Equal(t, single.baselines["cpu"], shared.baselines["cpu"], "a sample is learned once per model")
+
+	// The second rule checks against the baseline the first one saw.
+	b := shared.baselines["cpu"].observe(start.Add(120*time.Minute), 90)
+	mean := b.mean
+	shared.baselines["cpu"].observe(start.Add(120*time.Minute), 90)
+	assert.Equal(t, mean, b.mean)
+	assert.Less(t, mean, 30.0)
+}
+
+func TestPruneBaselines(t *testing.T) {
+	eng := NewEngine(setupTestDB(t), nil, nil, nil, time.Minute)
+	cpu := database.AlertConfig{Metric: "cpu", Mode: database.AlertModeAnomaly}
+	temp := database.AlertConfig{Metric: "temp", Target: "cpu_thermal", Mode: database.AlertModeAnomaly, Hourly: true}
+	eng.anomalyModel(cpu)
+	eng.anomalyModel(temp)
+
+	eng.pruneBaselines([]database.AlertConfig{temp, {Metric: "cpu", Operator: ">", Threshold: 90}})
+	assert.NotContains(t, eng.baselines, "cpu", "only threshold rules use cpu now")
+	assert.Contains(t, eng.baselines, anomalyKey(temp))
+}
@@ -4,27 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cesareyeserrano/ultron-ap/internal/database"
 	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
 )
 
+// maxOffenders is the number of top processes listed in cpu/ram alert messages.
+const maxOffenders = 3
+
+// By default a container restarted by its restart policy 3 times within 10
+// minutes is crash looping; SetCrashLoop changes both.
+const (
+	defaultCrashLoopRestarts = 3
+	defaultCrashLoopWindow   = 10 * time.Minute
+)
+
 // Engine evaluates alert rules against current system state.
 type Engine struct {
 	db        *database.DB
 	collector *metrics.Collector
 	docker    *docker.Monitor
 	systemd   *systemd.Monitor
+	probes    *probes.Monitor
+	smart     *smart.Monitor
 	interval  time.Duration
 
+	crashLoopRestarts int
+	crashLoopWindow   time.Duration
+
 	mu           sync.Mutex
-	cooldowns    map[string]time.Time // ruleKey -> last triggered
-	prevDocker   map[string]string    // containerName -> state
-	prevSystemd  map[string]string    // serviceName -> activeState
+	cooldowns    map[string]time.Time            // ruleKey -> last triggered
+	prevDocker   map[string]docker.ContainerInfo // containerName -> last seen
+	restarts     map[string][]time.Time          // containerName -> restarts within crashLoopWindow
+	dockerSeq    uint64                          // last Docker event seen
+	dockerSynced bool                            // dockerSeq has been read once
+	prevSystemd  map[string]string               // serviceName -> activeState
+	prevProbes   map[int64]bool                  // probeID -> up
+	prevDrives   map[string]smart.Drive          // drive key -> last checked state
+	baselines    map[string]*anomalyModel        // anomalyKey -> model, evaluation goroutine only
 	recentAlerts []database.Alert
 	recentMu     sync.RWMutex
 
@@ -35,17 +60,45 @@ type Engine struct {
 // NewEngine creates an alert engine.
 func NewEngine(db *database.DB, collector *metrics.Collector, dockerMon *docker.Monitor, systemdMon *systemd.Monitor, interval time.Duration) *Engine {
 	return &Engine{
-		db:          db,
-		collector:   collector,
-		docker:      dockerMon,
-		systemd:     systemdMon,
-		interval:    interval,
+		db:        db,
+		collector: collector,
+		docker:    dockerMon,
+		systemd:   systemdMon,
+		interval:  interval,
+
+		crashLoopRestarts: defaultCrashLoopRestarts,
+		crashLoopWindow:   defaultCrashLoopWindow,
+
 		cooldowns:   make(map[string]time.Time),
-		prevDocker:  make(map[string]string),
+		prevDocker:  make(map[string]docker.ContainerInfo),
+		restarts:    make(map[string][]time.Time),
 		prevSystemd: make(map[string]string),
+		prevProbes:  make(map[int64]bool),
+		prevDrives:  make(map[string]smart.Drive),
+		baselines:   make(map[string]*anomalyModel),
 	}
 }
 
+// SetProbes makes the engine alert when synthetic probes go down or recover.
+// It must be called before Start.
+func (e *Engine) SetProbes(m *probes.Monitor) {
+	e.probes = m
+}
+
+// SetSmart makes the engine alert when drive health degrades.
+// It must be called before Start.
+func (e *Engine) SetSmart(m *smart.Monitor) {
+	e.smart = m
+}
+
+// SetCrashLoop makes the engine report a container as crash looping once it
+// has been restarted restarts times within window. It must be called before
+// Start.
+func (e *Engine) SetCrashLoop(restarts int, window time.Duration) {
+	e.crashLoopRestarts = restarts
+	e.crashLoopWindow = window
+}
+
 // Start begins the evaluation loop.
 func (e *Engine) Start(ctx context.Context) {
 	ctx, e.cancel = context.WithCancel(ctx)
@@ -114,10 +167,13 @@ func (e *Engine) evaluate() {
 			e.evaluateMetricRule(cfg, snapshot)
 		}
 	}
+	e.pruneBaselines(configs)
 
 	// Evaluate Docker state changes
 	if e.docker != nil && e.docker.Available() {
-		e.evaluateDockerChanges()
+		events, seq := e.docker.EventsSince(e.dockerSeq)
+		e.evaluateDockerEvents(events, seq)
+		e.evaluateDockerChanges(e.docker.Containers(), time.Now())
 	}
 
 	// Evaluate Systemd state changes
@@ -125,6 +181,18 @@ func (e *Engine) evaluate() {
 		e.evaluateSystemdChanges()
 	}
 
+	// Evaluate probe state changes
+	if e.probes != nil {
+		statuses := e.probes.Statuses()
+		e.evaluateProbeChanges(statuses)
+		e.evaluateCertExpiry(statuses, time.Now())
+	}
+
+	// Evaluate drive health
+	if e.smart != nil && e.smart.Available() {
+		e.evaluateDriveHealth(e.smart.Drives(), time.Now())
+	}
+
 	// Refresh recent alerts cache
 	alerts, err := e.db.ListAlerts(50)
 	if err != nil {
@@ -137,13 +205,27 @@ func (e *Engine) evaluate() {
 }
 
 func (e *Engine) evaluateMetricRule(cfg database.AlertConfig, snap *metrics.Snapshot) {
-	value, ok := extractMetricValue(cfg.Metric, snap)
+	value, ok := ruleValue(cfg, snap)
 	if !ok {
 		return
 	}
 
-	if !compareValue(value, cfg.Operator, cfg.Threshold) {
-		return
+	var message string
+	if cfg.Mode == database.AlertModeAnomaly {
+		t := snap.Timestamp
+		if t.IsZero() {
+			t = time.Now()
+		}
+		msg, anomalous := e.checkAnomaly(cfg, t, value)
+		if !anomalous {
+			return
+		}
+		message = msg
+	} else {
+		if !compareValue(value, cfg.Operator, cfg.Threshold) {
+			return
+		}
+		message = fmt.Sprintf("%s: %.1f %s %.1f", cfg.Name, value, cfg.Operator, cfg.Threshold)
 	}
 
 	// Check cooldown
@@ -161,8 +243,8 @@ func (e *Engine) evaluateMetricRule(cfg database.AlertConfig, snap *metrics.Snap
 	alert := &database.Alert{
 		ConfigID: &cfg.ID,
 		Severity: cfg.Severity,
-		Message:  fmt.Sprintf("%s: %.1f %s %.1f", cfg.Name, value, cfg.Operator, cfg.Threshold),
-		Source:   cfg.Metric,
+		Message:  message + topOffenders(cfg.Metric, snap),
+		Source:   ruleSource(cfg),
 		Value:    &value,
 	}
 	if err := e.db.CreateAlert(alert); err != nil {
@@ -170,46 +252,136 @@ func (e *Engine) evaluateMetricRule(cfg database.AlertConfig, snap *metrics.Snap
 	}
 }
 
-func (e *Engine) evaluateDockerChanges() {
-	containers := e.docker.Containers()
-	current := make(map[string]string, len(containers))
+// evaluateDockerEvents alerts on containers that died with a non-zero exit
+// code or were OOM killed since the last evaluation, including ones restarted
+// before a state change could be seen. Events from before the first
+// evaluation are skipped.
+func (e *Engine) evaluateDockerEvents(events []docker.Event, seq uint64) {
+	e.mu.Lock()
+	synced := e.dockerSynced
+	e.dockerSeq, e.dockerSynced = seq, true
+	e.mu.Unlock()
+	if !synced {
+		return
+	}
+
+	now := time.Now()
+	oomKilled := make(map[string]bool) // containerID -> oom event seen
+	for _, ev := range events {
+		switch {
+		case ev.Action == "oom":
+			oomKilled[ev.ContainerID] = true
+			e.dockerAlert("docker:"+ev.Name+":oom", "critical",
+				fmt.Sprintf("Container %s was killed for running out of memory", ev.Name), ev.Name, now)
+		case ev.Action == "die" && ev.ExitCode != nil && *ev.ExitCode != 0:
+			// The death that follows an OOM kill is part of its alert.
+			if oomKilled[ev.ContainerID] {
+				delete(oomKilled, ev.ContainerID)
+				continue
+			}
+			// Shares the state change cooldown, so a death seen both ways alerts once.
+			e.dockerAlert("docker:"+ev.Name, "warning",
+				fmt.Sprintf("Container %s died with exit code %d", ev.Name, *ev.ExitCode), ev.Name, now)
+		}
+	}
+}
+
+// evaluateDockerChanges alerts on containers that exit with an error, turn
+// unhealthy, are OOM killed, or crash loop: are restarted by their restart
+// policy crashLoopRestarts times within crashLoopWindow.
+func (e *Engine) evaluateDockerChanges(containers []docker.ContainerInfo, now time.Time) {
+	current := make(map[string]docker.ContainerInfo, len(containers))
 
 	for _, c := range containers {
-		current[c.Name] = c.State
+		current[c.Name] = c
 
+		e.mu.Lock()
 		prev, existed := e.prevDocker[c.Name]
+		e.mu.Unlock()
 		if !existed {
 			continue // First cycle for this container, skip
 		}
 
-		// Detect transition to bad state
-		if prev != c.State && (c.State == "exited" || c.Health == docker.HealthError) {
-			key := fmt.Sprintf("docker:%s", c.Name)
+		switch {
+		case c.Health == docker.HealthOOM && !prev.OOMKilled:
+			e.dockerAlert("docker:"+c.Name+":oom", "critical",
+				fmt.Sprintf("Container %s was killed for running out of memory", c.Name), c.Name, now)
+		case prev.State != c.State && (c.State == "exited" || c.Health == docker.HealthError):
+			e.dockerAlert("docker:"+c.Name, "warning",
+				fmt.Sprintf("Container %s changed to %s", c.Name, c.State), c.Name, now)
+		}
+
+		if c.Health == docker.HealthUnhealthy && prev.Health != docker.HealthUnhealthy {
+			e.dockerAlert("docker:"+c.Name+":unhealthy", "warning",
+				fmt.Sprintf("Container %s is unhealthy", c.Name), c.Name, now)
+		}
+
+		// A lower count means the container was recreated; start over.
+		if c.RestartCount < prev.RestartCount {
 			e.mu.Lock()
-			last, exists := e.cooldowns[key]
-			if exists && time.Since(last) < 15*time.Minute {
-				e.mu.Unlock()
-				continue
-			}
-			e.cooldowns[key] = time.Now()
+			delete(e.restarts, c.Name)
 			e.mu.Unlock()
-
-			alert := &database.Alert{
-				Severity: "warning",
-				Message:  fmt.Sprintf("Container %s changed to %s", c.Name, c.State),
-				Source:   "docker:" + c.Name,
-			}
-			if err := e.db.CreateAlert(alert); err != nil {
-				log.Printf("alerts: failed to create docker alert: %v", err)
-			}
+		} else if n := e.recordRestarts(c.Name, c.RestartCount-prev.RestartCount, now); n >= e.crashLoopRestarts {
+			e.dockerAlert("docker:"+c.Name+":crashloop", "critical",
+				fmt.Sprintf("Container %s is crash looping: %d restarts in %.0f minutes", c.Name, n, e.crashLoopWindow.Minutes()), c.Name, now)
 		}
 	}
 
 	e.mu.Lock()
 	e.prevDocker = current
+	for name := range e.restarts {
+		if _, ok := current[name]; !ok {
+			delete(e.restarts, name)
+		}
+	}
 	e.mu.Unlock()
 }
 
+// recordRestarts adds n restarts seen at now to a container's history and
+// returns the number of restarts within crashLoopWindow.
+func (e *Engine) recordRestarts(name string, n int, now time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var recent []time.Time
+	for _, t := range e.restarts[name] {
+		if now.Sub(t) < e.crashLoopWindow {
+			recent = append(recent, t)
+		}
+	}
+	for i := 0; i < n; i++ {
+		recent = append(recent, now)
+	}
+	if len(recent) == 0 {
+		delete(e.restarts, name)
+		return 0
+	}
+	e.restarts[name] = recent
+	return len(recent)
+}
+
+// dockerAlert creates a container alert unless key alerted in the last 15
+// minutes.
+func (e *Engine) dockerAlert(key, severity, message, name string, now time.Time) {
+	e.mu.Lock()
+	last, exists := e.cooldowns[key]
+	if exists && now.Sub(last) < 15*time.Minute {
+		e.mu.Unlock()
+		return
+	}
+	e.cooldowns[key] = now
+	e.mu.Unlock()
+
+	alert := &database.Alert{
+		Severity: severity,
+		Message:  message,
+		Source:   "docker:" + name,
+	}
+	if err := e.db.CreateAlert(alert); err != nil {
+		log.Printf("alerts: failed to create docker alert: %v", err)
+	}
+}
+
 func (e *Engine) evaluateSystemdChanges() {
 	services := e.systemd.Services()
 	current := make(map[string]string, len(services))
@@ -250,6 +422,198 @@ func (e *Engine) evaluateSystemdChanges() {
 	e.mu.Unlock()
 }
 
+// evaluateProbeChanges alerts when a probe goes down and when it recovers.
+func (e *Engine) evaluateProbeChanges(statuses []probes.Status) {
+	current := make(map[int64]bool, len(statuses))
+
+	for _, st := range statuses {
+		if !st.Checked {
+			continue
+		}
+		current[st.Probe.ID] = st.Up
+
+		prev, existed := e.prevProbes[st.Probe.ID]
+		if !existed || prev == st.Up {
+			continue
+		}
+
+		alert := &database.Alert{Source: "probe:" + st.Probe.Name}
+		if st.Up {
+			alert.Severity = "info"
+			alert.Message = fmt.Sprintf("Probe %s is up again (%.0f ms)", st.Probe.Name, st.LatencyMs)
+		} else {
+			// Flapping probes alert once per cooldown; recoveries always do.
+			key := fmt.Sprintf("probe:%d", st.Probe.ID)
+			e.mu.Lock()
+			last, exists := e.cooldowns[key]
+			if exists && time.Since(last) < 15*time.Minute {
+				e.mu.Unlock()
+				continue
+			}
+			e.cooldowns[key] = time.Now()
+			e.mu.Unlock()
+
+			alert.Severity = "critical"
+			alert.Message = fmt.Sprintf("Probe %s is down: %s", st.Probe.Name, st.Error)
+		}
+		if err := e.db.CreateAlert(alert); err != nil {
+			log.Printf("alerts: failed to create probe alert: %v", err)
+		}
+	}
+
+	e.mu.Lock()
+	e.prevProbes = current
+	e.mu.Unlock()
+}
+
+// evaluateCertExpiry alerts on certificates found by tls and cert probes that
+// expire within the probe's warning or critical number of days. Each
+// certificate alerts at most once a day per severity.
+func (e *Engine) evaluateCertExpiry(statuses []probes.Status, now time.Time) {
+	for _, st := range statuses {
+		for _, c := range st.Certs {
+			days := c.DaysLeft(now)
+			severity := ""
+			switch {
+			case days <= float64(st.Probe.CritDays):
+				severity = "critical"
+			case days <= float64(st.Probe.WarnDays):
+				severity = "warning"
+			default:
+				continue
+			}
+
+			key := fmt.Sprintf("cert:%d:%s:%s", st.Probe.ID, c.Source, severity)
+			e.mu.Lock()
+			last, exists := e.cooldowns[key]
+			if exists && now.Sub(last) < 24*time.Hour {
+				e.mu.Unlock()
+				continue
+			}
+			e.cooldowns[key] = now
+			e.mu.Unlock()
+
+			when := fmt.Sprintf("expires in %.0f days", math.Floor(days))
+			if days < 0 {
+				when = fmt.Sprintf("expired %.0f days ago", math.Ceil(-days))
+			}
+			alert := &database.Alert{
+				Severity: severity,
+				Message:  fmt.Sprintf("Certificate for %s (%s) %s, issued by %s", c.Name(), c.Source, when, c.Issuer),
+				Source:   "cert:" + c.Name(),
+				Value:    &days,
+			}
+			if err := e.db.CreateAlert(alert); err != nil {
+				log.Printf("alerts: failed to create certificate alert: %v", err)
+			}
+		}
+	}
+}
+
+// evaluateDriveHealth alerts on drives whose SMART health is warning or
+// failing. A degraded drive alerts again once a day per severity, and at once
+// when its bad sector or media error counts grow.
+func (e *Engine) evaluateDriveHealth(drives []smart.Drive, now time.Time) {
+	for _, d := range drives {
+		if d.Standby || d.Health == smart.HealthUnknown {
+			continue
+		}
+		e.mu.Lock()
+		prev, existed := e.prevDrives[d.Key()]
+		e.prevDrives[d.Key()] = d
+		e.mu.Unlock()
+
+		severity := ""
+		switch d.Health {
+		case smart.HealthFailing:
+			severity = "critical"
+		case smart.HealthWarning:
+			severity = "warning"
+		default:
+			continue
+		}
+		grew := existed && (d.Reallocated > prev.Reallocated || d.Pending > prev.Pending || d.Uncorrectable > prev.Uncorrectable)
+
+		key := fmt.Sprintf("smart:%s:%s", d.Key(), d.Health)
+		e.mu.Lock()
+		last, exists := e.cooldowns[key]
+		if !grew && exists && now.Sub(last) < 24*time.Hour {
+			e.mu.Unlock()
+			continue
+		}
+		e.cooldowns[key] = now
+		e.mu.Unlock()
+
+		alert := &database.Alert{
+			Severity: severity,
+			Message:  fmt.Sprintf("Drive %s (%s) health is %s: %s", d.Name(), d.Device, d.Health, strings.Join(d.Problems, ", ")),
+			Source:   "smart:" + d.Device,
+		}
+		if err := e.db.CreateAlert(alert); err != nil {
+			log.Printf("alerts: failed to create drive health alert: %v", err)
+		}
+	}
+}
+
+// ruleValue extracts the value a rule is compared against: the targeted
+// sensor, mountpoint, interface or custom metric when the rule has a target,
+// otherwise the metric's default value. custom rules need a target.
+func ruleValue(cfg database.AlertConfig, snap *metrics.Snapshot) (float64, bool) {
+	if cfg.Target == "" {
+		return extractMetricValue(cfg.Metric, snap)
+	}
+	switch cfg.Metric {
+	case "temp":
+		for _, t := range snap.Temperatures {
+			if t.Label == cfg.Target {
+				return t.Celsius, true
+			}
+		}
+		return 0, false
+	case "net_down", "net_errors", "net_drops":
+		for _, n := range snap.Networks {
+			if n.Name == cfg.Target {
+				return netValue(cfg.Metric, n), true
+			}
+		}
+		return 0, false
+	case "custom":
+		for _, m := range snap.Custom {
+			if m.Name == cfg.Target {
+				return m.Value, true
+			}
+		}
+		return 0, false
+	case "disk", "inodes":
+		for _, d := range snap.Disks {
+			if d.Path == cfg.Target {
+				if cfg.Metric == "inodes" {
+					return d.InodesPercent, true
+				}
+				return d.Percent, true
+			}
+		}
+		return 0, false
+	case "disk_full_eta_hours":
+		for _, d := range snap.Disks {
+			if d.Path == cfg.Target && d.FullInHours != nil {
+				return *d.FullInHours, true
+			}
+		}
+		return 0, false
+	default:
+		return extractMetricValue(cfg.Metric, snap)
+	}
+}
+
+// ruleSource is the alert source for a rule, e.g. "temp" or "temp:nvme_composite".
+func ruleSource(cfg database.AlertConfig) string {
+	if cfg.Target == "" {
+		return cfg.Metric
+	}
+	return cfg.Metric + ":" + cfg.Target
+}
+
 // extractMetricValue extracts the numeric value for a metric type from a snapshot.
 func extractMetricValue(metric string, snap *metrics.Snapshot) (float64, bool) {
 	switch metric {
@@ -257,6 +621,17 @@ func extractMetricValue(metric string, snap *metrics.Snapshot) (float64, bool) {
 		return snap.CPU.TotalPercent, true
 	case "ram":
 		return snap.RAM.Percent, true
+	case "swap":
+		if snap.RAM.SwapTotal > 0 {
+			return snap.RAM.SwapPercent, true
+		}
+		return 0, false
+	case "mem_pressure":
+		// Share of the last 10s in which some task stalled on memory.
+		if snap.RAM.Pressure != nil {
+			return snap.RAM.Pressure.SomeAvg10, true
+		}
+		return 0, false
 	case "disk":
 		if len(snap.Disks) > 0 {
 			// Use the highest disk usage
@@ -269,16 +644,194 @@ func extractMetricValue(metric string, snap *metrics.Snapshot) (float64, bool) {
 			return max, true
 		}
 		return 0, false
+	case "inodes":
+		if len(snap.Disks) > 0 {
+			max := 0.0
+			for _, d := range snap.Disks {
+				if d.InodesPercent > max {
+					max = d.InodesPercent
+				}
+			}
+			return max, true
+		}
+		return 0, false
+	case "disk_full_eta_hours":
+		// The soonest of the disks that are filling up.
+		eta, ok := 0.0, false
+		for _, d := range snap.Disks {
+			if d.FullInHours != nil && (!ok || *d.FullInHours < eta) {
+				eta, ok = *d.FullInHours, true
+			}
+		}
+		return eta, ok
 	case "temp":
 		if snap.Temperature != nil {
 			return *snap.Temperature, true
 		}
 		return 0, false
+	case "disk_read":
+		return maxDiskIO(snap, func(d metrics.DiskIO) float64 { return float64(d.ReadBytesPS) / bytesPerMB })
+	case "disk_write":
+		return maxDiskIO(snap, func(d metrics.DiskIO) float64 { return float64(d.WriteBytesPS) / bytesPerMB })
+	case "disk_iops":
+		return maxDiskIO(snap, func(d metrics.DiskIO) float64 { return d.ReadIOPS + d.WriteIOPS })
+	case "disk_busy":
+		return maxDiskIO(snap, func(d metrics.DiskIO) float64 { return d.BusyPercent })
+	case "disk_await":
+		return maxDiskIO(snap, func(d metrics.DiskIO) float64 { return d.AwaitMs })
+	case "undervoltage":
+		if snap.Pi != nil {
+			return boolValue(snap.Pi.UnderVoltage), true
+		}
+		return 0, false
+	case "throttled":
+		if snap.Pi != nil {
+			return boolValue(snap.Pi.FreqCapped || snap.Pi.Throttled || snap.Pi.SoftTempLimit), true
+		}
+		return 0, false
+	case "arm_clock":
+		if snap.Pi != nil && snap.Pi.ARMClockHz > 0 {
+			return float64(snap.Pi.ARMClockHz) / 1e6, true
+		}
+		return 0, false
+	case "net_down":
+		// Number of interfaces without a link; target a rule at one interface
+		// to ignore ports that are unused on purpose.
+		if len(snap.Networks) == 0 {
+			return 0, false
+		}
+		down := 0.0
+		for _, n := range snap.Networks {
+			down += netValue(metric, n)
+		}
+		return down, true
+	case "net_errors", "net_drops":
+		if len(snap.Networks) == 0 {
+			return 0, false
+		}
+		max := 0.0
+		for _, n := range snap.Networks {
+			if v := netValue(metric, n); v > max {
+				max = v
+			}
+		}
+		return max, true
+	case "load1":
+		return snap.CPU.Load1, true
+	case "load5":
+		return snap.CPU.Load5, true
+	case "load15":
+		return snap.CPU.Load15, true
+	case "load5_per_core":
+		// Lets a single rule express "load5 > cores*N" on any machine.
+		if cores := len(snap.CPU.PerCore); cores > 0 {
+			return snap.CPU.Load5 / float64(cores), true
+		}
+		return 0, false
+	case "cpu_user":
+		return snap.CPU.Times.User, true
+	case "cpu_system":
+		return snap.CPU.Times.System, true
+	case "iowait":
+		return snap.CPU.Times.IOWait, true
+	case "steal":
+		return snap.CPU.Times.Steal, true
+	case "softirq":
+		return snap.CPU.Times.SoftIRQ, true
+	case "ctx_switches":
+		return snap.System.ContextSwitchesPS, true
+	case "interrupts":
+		return snap.System.InterruptsPS, true
+	case "processes":
+		return float64(snap.System.Processes), true
+	case "threads":
+		return float64(snap.System.Threads), true
+	case "procs_blocked":
+		return float64(snap.System.ProcsBlocked), true
 	default:
 		return 0, false
 	}
 }
 
+// netValue is the value of a net_* metric for a single interface: 1 or 0 for
+// net_down, and errors or drops per second in both directions otherwise.
+func netValue(metric string, n metrics.NetworkIface) float64 {
+	switch metric {
+	case "net_down":
+		return boolValue(linkDown(n))
+	case "net_errors":
+		return n.ErrorsInPS + n.ErrorsOutPS
+	case "net_drops":
+		return n.DropsInPS + n.DropsOutPS
+	}
+	return 0
+}
+
+// linkDown reports whether an interface has lost its link. Interfaces whose
+// state is unknown (common for tunnels and some wireless drivers) count as up.
+func linkDown(n metrics.NetworkIface) bool {
+	switch n.OperState {
+	case "down", "lowerlayerdown", "notpresent":
+		return true
+	}
+	return false
+}
+
+// boolValue maps a flag to 1 or 0 so it can be compared against a threshold.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// bytesPerMB converts byte rates to the MB/s used by disk_read and disk_write rules.
+const bytesPerMB = 1024 * 1024
+
+// maxDiskIO returns the highest value of field across all block devices.
+func maxDiskIO(snap *metrics.Snapshot, field func(metrics.DiskIO) float64) (float64, bool) {
+	if len(snap.DiskIO) == 0 {
+		return 0, false
+	}
+	max := 0.0
+	for _, d := range snap.DiskIO {
+		if v := field(d); v > max {
+			max = v
+		}
+	}
+	return max, true
+}
+
+// topOffenders describes the processes using the most of the resource behind
+// a cpu or ram rule, for appending to the alert message. Other metrics get "".
+func topOffenders(metric string, snap *metrics.Snapshot) string {
+	var procs []metrics.ProcessInfo
+	switch metric {
+	case "cpu":
+		procs = snap.TopCPU
+	case "ram":
+		procs = snap.TopRAM
+	default:
+		return ""
+	}
+	if len(procs) > maxOffenders {
+		procs = procs[:maxOffenders]
+	}
+	if len(procs) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(procs))
+	for _, p := range procs {
+		usage := fmt.Sprintf("%.1f%%", p.CPUPercent)
+		if metric == "ram" {
+			usage = fmt.Sprintf("%d MiB", p.RSS/(1024*1024))
+		}
+		parts = append(parts, fmt.Sprintf("%s (pid %d) %s", p.Name, p.PID, usage))
+	}
+	return " — top: " + strings.Join(parts, ", ")
+}
+
 // compareValue evaluates value <operator> threshold.
 func compareValue(value float64, operator string, threshold float64) bool {
 	switch operator {
@@ -12,6 +12,8 @@ import (
 	"github.com/cesareyeserrano/ultron-ap/internal/database"
 	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
 )
 
@@ -71,6 +73,137 @@ func TestExtractMetricValue_TempNil(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestExtractMetricValue_SwapAndPressure(t *testing.T) {
+	snap := &metrics.Snapshot{RAM: metrics.RAMMetrics{
+		SwapTotal: 1024, SwapUsed: 512, SwapPercent: 50,
+		Pressure: &metrics.MemoryPressure{SomeAvg10: 12.5, FullAvg10: 3},
+	}}
+
+	val, ok := extractMetricValue("swap", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, val)
+
+	val, ok = extractMetricValue("mem_pressure", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 12.5, val)
+}
+
+func TestExtractMetricValue_NoSwapOrPSI(t *testing.T) {
+	snap := &metrics.Snapshot{}
+	_, ok := extractMetricValue("swap", snap)
+	assert.False(t, ok)
+	_, ok = extractMetricValue("mem_pressure", snap)
+	assert.False(t, ok)
+}
+
+func TestExtractMetricValue_DiskIO(t *testing.T) {
+	snap := &metrics.Snapshot{
+		DiskIO: []metrics.DiskIO{
+			{Device: "mmcblk0", ReadBytesPS: 2 * 1024 * 1024, WriteBytesPS: 512 * 1024, ReadIOPS: 40, WriteIOPS: 10, BusyPercent: 97, AwaitMs: 120},
+			{Device: "sda", ReadBytesPS: 1024 * 1024, WriteBytesPS: 8 * 1024 * 1024, ReadIOPS: 5, WriteIOPS: 100, BusyPercent: 20, AwaitMs: 3},
+		},
+	}
+
+	tests := map[string]float64{
+		"disk_read":  2,   // MB/s, mmcblk0
+		"disk_write": 8,   // MB/s, sda
+		"disk_iops":  105, // sda reads + writes
+		"disk_busy":  97,
+		"disk_await": 120,
+	}
+	for metric, want := range tests {
+		val, ok := extractMetricValue(metric, snap)
+		assert.True(t, ok, metric)
+		assert.InDelta(t, want, val, 0.001, metric)
+	}
+}
+
+func TestExtractMetricValue_DiskIOEmpty(t *testing.T) {
+	snap := &metrics.Snapshot{}
+	_, ok := extractMetricValue("disk_busy", snap)
+	assert.False(t, ok)
+}
+
+func TestExtractMetricValue_Pi(t *testing.T) {
+	snap := &metrics.Snapshot{Pi: &metrics.PiStatus{UnderVoltage: true, SoftTempLimit: true, ARMClockHz: 1500000000}}
+
+	val, ok := extractMetricValue("undervoltage", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, val)
+
+	val, ok = extractMetricValue("throttled", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, val)
+
+	val, ok = extractMetricValue("arm_clock", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 1500.0, val)
+
+	val, ok = extractMetricValue("undervoltage", &metrics.Snapshot{Pi: &metrics.PiStatus{UnderVoltageSinceBoot: true}})
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, val, "only current under-voltage counts")
+}
+
+func TestExtractMetricValue_NotAPi(t *testing.T) {
+	snap := &metrics.Snapshot{}
+	for _, metric := range []string{"undervoltage", "throttled", "arm_clock"} {
+		_, ok := extractMetricValue(metric, snap)
+		assert.False(t, ok, metric)
+	}
+}
+
+func TestExtractMetricValue_System(t *testing.T) {
+	snap := &metrics.Snapshot{
+		CPU: metrics.CPUMetrics{
+			PerCore: []float64{10, 20, 30, 40},
+			Load1:   3.5, Load5: 10, Load15: 1.25,
+			Times: metrics.CPUTimes{User: 40, System: 12, IOWait: 35, Steal: 4, SoftIRQ: 2},
+		},
+		System: metrics.SystemCounters{ContextSwitchesPS: 5000, InterruptsPS: 1200, Processes: 210, Threads: 640, ProcsBlocked: 3},
+	}
+
+	tests := map[string]float64{
+		"load1":          3.5,
+		"load5":          10,
+		"load15":         1.25,
+		"load5_per_core": 2.5,
+		"cpu_user":       40,
+		"cpu_system":     12,
+		"iowait":         35,
+		"steal":          4,
+		"softirq":        2,
+		"ctx_switches":   5000,
+		"interrupts":     1200,
+		"processes":      210,
+		"threads":        640,
+		"procs_blocked":  3,
+	}
+	for metric, want := range tests {
+		val, ok := extractMetricValue(metric, snap)
+		assert.True(t, ok, metric)
+		assert.InDelta(t, want, val, 0.001, metric)
+	}
+}
+
+func TestExtractMetricValue_LoadPerCoreNoCores(t *testing.T) {
+	_, ok := extractMetricValue("load5_per_core", &metrics.Snapshot{CPU: metrics.CPUMetrics{Load5: 4}})
+	assert.False(t, ok)
+}
+
+func TestEvaluateMetricRule_UndervoltageAlert(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "Under-voltage", Metric: "undervoltage", Operator: ">", Threshold: 0, Severity: "critical", Enabled: true, CooldownMinutes: 60}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Pi: &metrics.PiStatus{}})
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Pi: &metrics.PiStatus{UnderVoltage: true}})
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+}
+
 func TestExtractMetricValue_Unknown(t *testing.T) {
 	snap := &metrics.Snapshot{}
 	_, ok := extractMetricValue("unknown", snap)
@@ -240,40 +373,281 @@ func TestEvaluateMetricRule_TempAlert(t *testing.T) {
 	assert.Len(t, alerts, 1)
 }
 
+func TestEvaluateMetricRule_TempTarget(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "NVMe hot", Metric: "temp", Target: "nvme_composite", Operator: ">", Threshold: 70, Severity: "warning", Enabled: true, CooldownMinutes: 15}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	primary := 50.0
+	snap := &metrics.Snapshot{
+		Temperature:  &primary,
+		PrimaryTemp:  "cpu_thermal",
+		Temperatures: []metrics.TempSensor{{Label: "cpu_thermal", Celsius: 50}, {Label: "nvme_composite", Celsius: 78}},
+	}
+
+	eng.evaluateMetricRule(*ac, snap)
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "temp:nvme_composite", alerts[0].Source)
+	require.NotNil(t, alerts[0].Value)
+	assert.Equal(t, 78.0, *alerts[0].Value)
+}
+
+func TestRuleValue_TempTargetMissing(t *testing.T) {
+	primary := 90.0
+	snap := &metrics.Snapshot{Temperature: &primary, Temperatures: []metrics.TempSensor{{Label: "cpu_thermal", Celsius: 90}}}
+
+	_, ok := ruleValue(database.AlertConfig{Metric: "temp", Target: "nvme_composite"}, snap)
+	assert.False(t, ok, "a missing sensor must not fall back to the primary")
+
+	val, ok := ruleValue(database.AlertConfig{Metric: "temp"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 90.0, val)
+}
+
+func TestRuleValue_DiskTarget(t *testing.T) {
+	snap := &metrics.Snapshot{Disks: []metrics.DiskPartition{
+		{Path: "/", Percent: 40, InodesPercent: 12},
+		{Path: "/data", Percent: 95, InodesPercent: 88},
+	}}
+
+	val, ok := ruleValue(database.AlertConfig{Metric: "disk", Target: "/"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 40.0, val, "a targeted rule ignores fuller mounts")
+
+	val, ok = ruleValue(database.AlertConfig{Metric: "inodes", Target: "/"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 12.0, val)
+
+	val, ok = ruleValue(database.AlertConfig{Metric: "disk"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 95.0, val)
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "disk", Target: "/mnt/usb"}, snap)
+	assert.False(t, ok, "a missing mountpoint must not fall back to the max")
+}
+
+func TestRuleValue_DiskFullETA(t *testing.T) {
+	root, data := 300.0, 40.0
+	snap := &metrics.Snapshot{Disks: []metrics.DiskPartition{
+		{Path: "/", Percent: 60, FullInHours: &root},
+		{Path: "/data", Percent: 30, FullInHours: &data},
+		{Path: "/boot", Percent: 95},
+	}}
+
+	val, ok := ruleValue(database.AlertConfig{Metric: "disk_full_eta_hours"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 40.0, val, "the soonest disk to fill up")
+
+	val, ok = ruleValue(database.AlertConfig{Metric: "disk_full_eta_hours", Target: "/"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 300.0, val)
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "disk_full_eta_hours", Target: "/boot"}, snap)
+	assert.False(t, ok, "a full but stable disk has no estimate")
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "disk_full_eta_hours"}, &metrics.Snapshot{Disks: snap.Disks[2:]})
+	assert.False(t, ok)
+}
+
+func TestRuleValue_CustomTarget(t *testing.T) {
+	snap := &metrics.Snapshot{Custom: []metrics.CustomMetric{
+		{Name: "ups.battery", Collector: "ups", Value: 18, Unit: "%"},
+		{Name: "ups.status", Collector: "ups", Value: 2},
+	}}
+
+	val, ok := ruleValue(database.AlertConfig{Metric: "custom", Target: "ups.battery"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 18.0, val)
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "custom", Target: "queue.depth"}, snap)
+	assert.False(t, ok, "a metric that was not reported has no value")
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "custom"}, snap)
+	assert.False(t, ok, "custom rules need a target")
+	assert.Equal(t, "custom:ups.battery", ruleSource(database.AlertConfig{Metric: "custom", Target: "ups.battery"}))
+}
+
+func TestExtractMetricValue_Inodes(t *testing.T) {
+	snap := &metrics.Snapshot{Disks: []metrics.DiskPartition{{Path: "/", InodesPercent: 30}, {Path: "/data", InodesPercent: 91}}}
+	val, ok := extractMetricValue("inodes", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 91.0, val)
+
+	_, ok = extractMetricValue("inodes", &metrics.Snapshot{})
+	assert.False(t, ok)
+}
+
+func TestExtractMetricValue_Network(t *testing.T) {
+	snap := &metrics.Snapshot{Networks: []metrics.NetworkIface{
+		{Name: "eth0", OperState: "up", ErrorsInPS: 3, ErrorsOutPS: 1, DropsInPS: 0.5},
+		{Name: "wlan0", OperState: "down", DropsOutPS: 7},
+		{Name: "wg0", OperState: "unknown"},
+	}}
+
+	val, ok := extractMetricValue("net_down", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, val, "unknown state counts as up")
+
+	val, ok = extractMetricValue("net_errors", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, val)
+
+	val, ok = extractMetricValue("net_drops", snap)
+	assert.True(t, ok)
+	assert.Equal(t, 7.0, val)
+
+	_, ok = extractMetricValue("net_down", &metrics.Snapshot{})
+	assert.False(t, ok)
+}
+
+func TestRuleValue_NetworkTarget(t *testing.T) {
+	snap := &metrics.Snapshot{Networks: []metrics.NetworkIface{
+		{Name: "eth0", OperState: "up", ErrorsInPS: 2},
+		{Name: "wlan0", OperState: "down", ErrorsInPS: 9},
+	}}
+
+	val, ok := ruleValue(database.AlertConfig{Metric: "net_down", Target: "eth0"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, val)
+
+	val, ok = ruleValue(database.AlertConfig{Metric: "net_errors", Target: "eth0"}, snap)
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, val)
+
+	_, ok = ruleValue(database.AlertConfig{Metric: "net_down", Target: "eth1"}, snap)
+	assert.False(t, ok)
+}
+
+func TestEvaluateMetricRule_LinkDown(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "eth0 link", Metric: "net_down", Target: "eth0", Operator: ">", Threshold: 0, Severity: "critical", Enabled: true, CooldownMinutes: 5}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Networks: []metrics.NetworkIface{{Name: "eth0", OperState: "up"}}})
+	eng.evaluateMetricRule(*ac, &metrics.Snapshot{Networks: []metrics.NetworkIface{{Name: "eth0", OperState: "down"}}})
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "net_down:eth0", alerts[0].Source)
+}
+
 // --- Docker State Change Tests ---
 
 func TestEvaluateDockerChanges_StateTransition(t *testing.T) {
 	db := setupTestDB(t)
 	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
 
 	// First cycle: establish baseline
-	eng.mu.Lock()
-	eng.prevDocker = map[string]string{"nginx": "running"}
-	eng.mu.Unlock()
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{Name: "nginx", State: "running", Health: docker.HealthRunning}}, now)
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{Name: "nginx", State: "exited", ExitCode: 1, Health: docker.HealthError}}, now)
 
-	// Simulate container stopping — we test the method directly
-	// by manipulating prevDocker and calling with containers
-	eng.docker = nil // Can't call evaluateDockerChanges without docker monitor
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Container nginx changed to exited", alerts[0].Message)
+	assert.Equal(t, "docker:nginx", alerts[0].Source)
 
-	// Instead, test the logic manually
 	eng.mu.Lock()
-	eng.prevDocker["nginx"] = "running"
+	_, exists := eng.cooldowns["docker:nginx"]
 	eng.mu.Unlock()
+	assert.True(t, exists)
+}
 
-	// We need a docker monitor to test this. Let's test via the cooldown mechanism.
-	// The core logic is already tested via evaluateMetricRule. Docker/Systemd
-	// state change detection requires a real or mock docker.Monitor.
-	// Let's verify the cooldown map works for docker keys.
-	key := "docker:nginx"
+func TestEvaluateDockerChanges_Unhealthy(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
+	api := docker.ContainerInfo{Name: "api", State: "running", HealthCheck: "healthy", Health: docker.HealthRunning}
+
+	eng.evaluateDockerChanges([]docker.ContainerInfo{api}, now)
+	api.HealthCheck, api.Health = "unhealthy", docker.HealthUnhealthy
+	eng.evaluateDockerChanges([]docker.ContainerInfo{api}, now.Add(time.Minute))
+	eng.evaluateDockerChanges([]docker.ContainerInfo{api}, now.Add(2*time.Minute))
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "alerts once on the transition")
+	assert.Equal(t, "warning", alerts[0].Severity)
+	assert.Equal(t, "Container api is unhealthy", alerts[0].Message)
+}
+
+func TestEvaluateDockerChanges_OOMKilled(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
+
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{Name: "worker", State: "running", Health: docker.HealthRunning}}, now)
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{
+		Name: "worker", State: "exited", ExitCode: 137, OOMKilled: true, Health: docker.HealthOOM,
+	}}, now.Add(time.Minute))
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "an OOM kill is not also reported as an exit")
+	assert.Equal(t, "critical", alerts[0].Severity)
+	assert.Equal(t, "Container worker was killed for running out of memory", alerts[0].Message)
+}
+
+func TestEvaluateDockerChanges_CrashLoop(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
+	app := func(restarts int) []docker.ContainerInfo {
+		return []docker.ContainerInfo{{Name: "app", State: "running", Health: docker.HealthRunning, RestartCount: restarts}}
+	}
+
+	// Restarts before the first evaluation and spread-out ones are no loop.
+	eng.evaluateDockerChanges(app(40), now)
+	eng.evaluateDockerChanges(app(41), now.Add(time.Minute))
+	eng.evaluateDockerChanges(app(42), now.Add(20*time.Minute))
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	eng.evaluateDockerChanges(app(44), now.Add(25*time.Minute))
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "critical", alerts[0].Severity)
+	assert.Equal(t, "Container app is crash looping: 3 restarts in 10 minutes", alerts[0].Message)
+
+	// A recreated container starts counting again.
+	eng.evaluateDockerChanges(app(0), now.Add(26*time.Minute))
 	eng.mu.Lock()
-	eng.cooldowns[key] = time.Now()
+	assert.Empty(t, eng.restarts["app"])
 	eng.mu.Unlock()
 
+	eng.evaluateDockerChanges(nil, now.Add(27*time.Minute))
 	eng.mu.Lock()
-	last, exists := eng.cooldowns[key]
+	assert.Empty(t, eng.restarts)
+	assert.Empty(t, eng.prevDocker)
 	eng.mu.Unlock()
-	assert.True(t, exists)
-	assert.WithinDuration(t, time.Now(), last, time.Second)
+}
+
+func TestEvaluateDockerChanges_CrashLoopConfigured(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	eng.SetCrashLoop(2, 30*time.Minute)
+	now := time.Now()
+	app := func(restarts int) []docker.ContainerInfo {
+		return []docker.ContainerInfo{{Name: "app", State: "running", Health: docker.HealthRunning, RestartCount: restarts}}
+	}
+
+	// Two restarts 20 minutes apart are a loop within a 30 minute window.
+	eng.evaluateDockerChanges(app(0), now)
+	eng.evaluateDockerChanges(app(1), now.Add(time.Minute))
+	eng.evaluateDockerChanges(app(2), now.Add(21*time.Minute))
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Container app is crash looping: 2 restarts in 30 minutes", alerts[0].Message)
 }
 
 // --- Systemd State Change Tests ---
@@ -360,11 +734,235 @@ func TestMultipleRulesFireSimultaneously(t *testing.T) {
 
 func TestDockerHealthStatus_Reference(t *testing.T) {
 	// Verify we can reference docker health constants
-	assert.Equal(t, docker.HealthError, docker.MapHealthStatus("exited", 1))
-	assert.Equal(t, docker.HealthRunning, docker.MapHealthStatus("running", 0))
+	assert.Equal(t, docker.HealthError, docker.MapHealthStatus(docker.ContainerInfo{State: "exited", ExitCode: 1}))
+	assert.Equal(t, docker.HealthRunning, docker.MapHealthStatus(docker.ContainerInfo{State: "running"}))
 }
 
 func TestSystemdServiceHealth_Reference(t *testing.T) {
 	assert.Equal(t, systemd.ServiceFailed, systemd.MapServiceHealth("failed"))
 	assert.Equal(t, systemd.ServiceActive, systemd.MapServiceHealth("active"))
 }
+
+func TestEvaluateMetricRule_CPUIncludesTopProcesses(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "CPU High", Metric: "cpu", Operator: ">", Threshold: 90, Severity: "critical", Enabled: true, CooldownMinutes: 15}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	snap := &metrics.Snapshot{
+		CPU: metrics.CPUMetrics{TotalPercent: 95},
+		TopCPU: []metrics.ProcessInfo{
+			{PID: 123, Name: "python3", CPUPercent: 80.1},
+			{PID: 456, Name: "node", CPUPercent: 10},
+			{PID: 7, Name: "sshd", CPUPercent: 2},
+			{PID: 8, Name: "cron", CPUPercent: 1},
+		},
+	}
+
+	eng.evaluateMetricRule(*ac, snap)
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Contains(t, alerts[0].Message, "python3 (pid 123) 80.1%")
+	assert.Contains(t, alerts[0].Message, "sshd")
+	assert.NotContains(t, alerts[0].Message, "cron", "only the top offenders are listed")
+}
+
+func TestEvaluateMetricRule_RAMIncludesTopProcesses(t *testing.T) {
+	db := setupTestDB(t)
+	ac := &database.AlertConfig{Name: "RAM", Metric: "ram", Operator: ">", Threshold: 85, Severity: "warning", Enabled: true, CooldownMinutes: 15}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	snap := &metrics.Snapshot{
+		RAM:    metrics.RAMMetrics{Percent: 90},
+		TopRAM: []metrics.ProcessInfo{{PID: 42, Name: "java", RSS: 512 * 1024 * 1024}},
+	}
+
+	eng.evaluateMetricRule(*ac, snap)
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Contains(t, alerts[0].Message, "java (pid 42) 512 MiB")
+}
+
+func TestTopOffenders_OtherMetrics(t *testing.T) {
+	snap := &metrics.Snapshot{TopCPU: []metrics.ProcessInfo{{PID: 1, Name: "init"}}}
+	assert.Empty(t, topOffenders("disk", snap))
+	assert.Empty(t, topOffenders("ram", snap))
+}
+
+// --- Probe State Change Tests ---
+
+func TestEvaluateProbeChanges(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	web := database.Probe{ID: 1, Name: "web"}
+
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web}})
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web, Checked: true, Up: true}})
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web, Checked: true, Up: true}})
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts, "no alert without a transition")
+
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web, Checked: true, Error: "status 502"}})
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "critical", alerts[0].Severity)
+	assert.Equal(t, "probe:web", alerts[0].Source)
+	assert.Equal(t, "Probe web is down: status 502", alerts[0].Message)
+
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web, Checked: true, Up: true, LatencyMs: 12}})
+	eng.evaluateProbeChanges([]probes.Status{{Probe: web, Checked: true, Error: "status 502"}})
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2, "the second outage is within the cooldown")
+	var recovered bool
+	for _, a := range alerts {
+		if a.Severity == "info" {
+			recovered = true
+			assert.Equal(t, "Probe web is up again (12 ms)", a.Message)
+		}
+	}
+	assert.True(t, recovered)
+}
+
+func TestEvaluateCertExpiry(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
+	probe := database.Probe{ID: 3, Name: "letsencrypt", Type: "cert", WarnDays: 21, CritDays: 7}
+	cert := func(name string, days float64) probes.CertInfo {
+		return probes.CertInfo{Source: "/etc/letsencrypt/live/" + name + "/fullchain.pem", SANs: []string{name}, Issuer: "R11", NotAfter: now.Add(time.Duration(days * 24 * float64(time.Hour)))}
+	}
+
+	statuses := []probes.Status{{Probe: probe, Checked: true, Up: true, Certs: []probes.CertInfo{
+		cert("cloud.example.com", 60.5),
+		cert("git.example.com", 14.5),
+	}}}
+	eng.evaluateCertExpiry(statuses, now)
+	eng.evaluateCertExpiry(statuses, now.Add(time.Hour))
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "one warning a day")
+	assert.Equal(t, "warning", alerts[0].Severity)
+	assert.Equal(t, "cert:git.example.com", alerts[0].Source)
+	assert.Equal(t, "Certificate for git.example.com (/etc/letsencrypt/live/git.example.com/fullchain.pem) expires in 14 days, issued by R11", alerts[0].Message)
+
+	// Closer to expiry the critical alert fires despite the warning's cooldown.
+	statuses[0].Certs = []probes.CertInfo{cert("git.example.com", 6.5)}
+	eng.evaluateCertExpiry(statuses, now.Add(2*time.Hour))
+	statuses[0].Certs = []probes.CertInfo{cert("git.example.com", -1.5)}
+	eng.evaluateCertExpiry(statuses, now.Add(30*time.Hour))
+
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 3)
+	var messages []string
+	for _, a := range alerts {
+		if a.Severity == "critical" {
+			messages = append(messages, a.Message)
+		}
+	}
+	assert.Len(t, messages, 2)
+	assert.Contains(t, messages[0]+messages[1], "expired 3 days ago")
+}
+
+func TestEvaluateDriveHealth(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	now := time.Now()
+	ssd := smart.Drive{Device: "/dev/sda", Model: "Samsung SSD 870", Serial: "S6PX", Health: smart.HealthPassed}
+	nvme := smart.Drive{Device: "/dev/nvme0", Serial: "N1", Health: smart.HealthUnknown, Standby: true}
+
+	eng.evaluateDriveHealth([]smart.Drive{ssd, nvme}, now)
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	ssd.Health, ssd.Pending, ssd.Problems = smart.HealthWarning, 2, []string{"2 pending sectors"}
+	eng.evaluateDriveHealth([]smart.Drive{ssd, nvme}, now.Add(10*time.Minute))
+	eng.evaluateDriveHealth([]smart.Drive{ssd, nvme}, now.Add(20*time.Minute))
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "one warning a day")
+	assert.Equal(t, "warning", alerts[0].Severity)
+	assert.Equal(t, "smart:/dev/sda", alerts[0].Source)
+	assert.Equal(t, "Drive Samsung SSD 870 (/dev/sda) health is warning: 2 pending sectors", alerts[0].Message)
+
+	// Growing sector counts alert despite the cooldown.
+	ssd.Pending, ssd.Problems = 5, []string{"5 pending sectors"}
+	eng.evaluateDriveHealth([]smart.Drive{ssd}, now.Add(30*time.Minute))
+	ssd.Health = smart.HealthFailing
+	eng.evaluateDriveHealth([]smart.Drive{ssd}, now.Add(40*time.Minute))
+
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 3)
+	var critical int
+	for _, a := range alerts {
+		if a.Severity == "critical" {
+			critical++
+		}
+		assert.NotContains(t, a.Message, "nvme0", "drives in standby are skipped")
+	}
+	assert.Equal(t, 1, critical)
+}
+
+func TestEvaluateDockerEvents(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	code := func(c int) *int { return &c }
+
+	// Events from before the first evaluation are history, not news.
+	eng.evaluateDockerEvents([]docker.Event{{Seq: 1, Name: "old", Action: "die", ExitCode: code(1)}}, 1)
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+
+	// A crash and restart between two polls still alerts, once.
+	eng.evaluateDockerEvents([]docker.Event{
+		{Seq: 2, Name: "worker", Action: "die", ExitCode: code(137)},
+		{Seq: 3, Name: "worker", Action: "start"},
+		{Seq: 4, Name: "worker", Action: "die", ExitCode: code(1)},
+		{Seq: 5, Name: "web", Action: "die", ExitCode: code(0)},
+	}, 5)
+	assert.Equal(t, uint64(5), eng.dockerSeq)
+
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "Container worker died with exit code 137", alerts[0].Message)
+	assert.Equal(t, "docker:worker", alerts[0].Source)
+}
+
+func TestEvaluateDockerEvents_OOMKill(t *testing.T) {
+	db := setupTestDB(t)
+	eng := NewEngine(db, nil, nil, nil, time.Minute)
+	code := 137
+
+	eng.evaluateDockerEvents(nil, 0)
+	eng.evaluateDockerEvents([]docker.Event{
+		{Seq: 1, ContainerID: "c1", Name: "worker", Action: "oom"},
+		{Seq: 2, ContainerID: "c1", Name: "worker", Action: "die", ExitCode: &code},
+		{Seq: 3, ContainerID: "c1", Name: "worker", Action: "start"},
+	}, 3)
+
+	alerts, err := db.ListAlerts(10)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "critical", alerts[0].Severity)
+	assert.Equal(t, "Container worker was killed for running out of memory", alerts[0].Message)
+
+	// The restarted container's poll state doesn't alert again.
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{Name: "worker", State: "running"}}, time.Now())
+	eng.evaluateDockerChanges([]docker.ContainerInfo{{Name: "worker", State: "exited", ExitCode: 137, OOMKilled: true, Health: docker.HealthOOM}}, time.Now())
+	alerts, err = db.ListAlerts(10)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Defaults for custom collectors that leave interval or timeout unset.
+const (
+	defaultCollectorInterval = 60 * time.Second
+	defaultCollectorTimeout  = 10 * time.Second
+)
+
+// CustomCollector is an executable run periodically to produce custom metrics.
+type CustomCollector struct {
+	Name     string
+	Command  string
+	Args     []string
+	Interval time.Duration
+	Timeout  time.Duration
+	Format   string // "json" or "nagios"
+}
+
+// collectorNamePattern keeps names usable in series names and rule targets.
+var collectorNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// reservedCollectorNames prefix the metrics pushed to ULTRON_INGEST_ADDR.
+var reservedCollectorNames = map[string]bool{"statsd": true, "influx": true}
+
+// loadCollectors reads the custom collectors file: a JSON array of
+//
+//	{"name": "ups", "command": "/usr/local/bin/check_ups", "args": [],
+//	 "interval": "30s", "timeout": "5s", "format": "nagios"}
+func loadCollectors(path string) ([]CustomCollector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read collectors file: %w", err)
+	}
+
+	var raw []struct {
+		Name     string   `json:"name"`
+		Command  string   `json:"command"`
+		Args     []string `json:"args"`
+		Interval string   `json:"interval"`
+		Timeout  string   `json:"timeout"`
+		Format   string   `json:"format"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse collectors file %s: %w", path, err)
+	}
+
+	collectors := make([]CustomCollector, 0, len(raw))
+	seen := make(map[string]bool)
+	for i, r := range raw {
+		c := CustomCollector{
+			Name:     r.Name,
+			Command:  r.Command,
+			Args:     r.Args,
+			Interval: defaultCollectorInterval,
+			Timeout:  defaultCollectorTimeout,
+			Format:   r.Format,
+		}
+		if !collectorNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("collector %d: invalid name %q (use letters, digits, - and _)", i, c.Name)
+		}
+		if reservedCollectorNames[c.Name] {
+			return nil, fmt.Errorf("collector %q: name is reserved for pushed metrics", c.Name)
+		}
+		if seen[c.Name] {
+			return nil, fmt.Errorf("collector %q: duplicate name", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Command == "" {
+			return nil, fmt.Errorf("collector %q: command is required", c.Name)
+		}
+		switch c.Format {
+		case "":
+			c.Format = "json"
+		case "json", "nagios":
+		default:
+			return nil, fmt.Errorf("collector %q: invalid format %q (must be json or nagios)", c.Name, c.Format)
+		}
+		if r.Interval != "" {
+			if c.Interval, err = time.ParseDuration(r.Interval); err != nil || c.Interval < time.Second {
+				return nil, fmt.Errorf("collector %q: invalid interval %q (must be >= 1s)", c.Name, r.Interval)
+			}
+		}
+		if r.Timeout != "" {
+			if c.Timeout, err = time.ParseDuration(r.Timeout); err != nil || c.Timeout <= 0 {
+				return nil, fmt.Errorf("collector %q: invalid timeout %q", c.Name, r.Timeout)
+			}
+		}
+		collectors = append(collectors, c)
+	}
+	return collectors, nil
+}
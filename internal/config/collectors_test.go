@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCollectorsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "collectors.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad_Collectors(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_COLLECTORS_FILE", writeCollectorsFile(t, `[
+		{"name": "ups", "command": "/usr/local/bin/check_ups", "args": ["-H", "localhost"], "interval": "30s", "timeout": "5s", "format": "nagios"},
+		{"name": "queue", "command": "/opt/app/queue-depth"}
+	]`))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.Len(t, cfg.Collectors, 2)
+	assert.Equal(t, CustomCollector{
+		Name: "ups", Command: "/usr/local/bin/check_ups", Args: []string{"-H", "localhost"},
+		Interval: 30 * time.Second, Timeout: 5 * time.Second, Format: "nagios",
+	}, cfg.Collectors[0])
+
+	// Defaults
+	assert.Equal(t, 60*time.Second, cfg.Collectors[1].Interval)
+	assert.Equal(t, 10*time.Second, cfg.Collectors[1].Timeout)
+	assert.Equal(t, "json", cfg.Collectors[1].Format)
+}
+
+func TestLoad_NoCollectors(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Collectors)
+}
+
+func TestLoad_InvalidCollectors(t *testing.T) {
+	tests := map[string]string{
+		"not json":         `{`,
+		"missing command":  `[{"name": "ups"}]`,
+		"bad name":         `[{"name": "ups:battery", "command": "x"}]`,
+		"duplicate name":   `[{"name": "a", "command": "x"}, {"name": "a", "command": "y"}]`,
+		"reserved name":    `[{"name": "statsd", "command": "x"}]`,
+		"bad format":       `[{"name": "a", "command": "x", "format": "xml"}]`,
+		"short interval":   `[{"name": "a", "command": "x", "interval": "500ms"}]`,
+		"bad timeout":      `[{"name": "a", "command": "x", "timeout": "soon"}]`,
+		"negative timeout": `[{"name": "a", "command": "x", "timeout": "-1s"}]`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("ULTRON_COLLECTORS_FILE", writeCollectorsFile(t, content))
+			_, err := Load()
+			assert.Error(t, err)
+		})
+	}
+
+	clearEnv(t)
+	t.Setenv("ULTRON_COLLECTORS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	_, err := Load()
+	assert.Error(t, err)
+}
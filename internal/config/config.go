@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +18,32 @@ type Config struct {
 	AdminPass       string
 	SessionTTL      time.Duration
 	MetricsInterval time.Duration
+	ReaderTimeout   time.Duration // deadline for each part of a metrics reading
+	MetricsToken    string        // bearer token for /metrics; empty leaves it open
+	TempSensor      string        // primary temperature sensor label; empty picks the first
+
+	// Filesystem filters for disk metrics. A nil DiskExcludeFSTypes keeps the
+	// built-in defaults (squashfs, overlay, tmpfs, devtmpfs).
+	DiskIncludeFSTypes []string
+	DiskExcludeFSTypes []string
+	DiskIncludeMounts  []string
+	DiskExcludeMounts  []string
+
+	// Network interface filters. A nil NetExclude keeps the built-in defaults
+	// (lo, veth*, docker*, br-*).
+	NetInclude []string
+	NetExclude []string
+
+	// Custom collectors from ULTRON_COLLECTORS_FILE; empty if unset.
+	Collectors []CustomCollector
+
+	// UDP address receiving StatsD and line protocol metrics; empty disables it.
+	IngestAddr string
+
+	// A container restarted CrashLoopRestarts times within CrashLoopWindow
+	// raises a crash loop alert.
+	CrashLoopRestarts int
+	CrashLoopWindow   time.Duration
 }
 
 var validLogLevels = map[string]bool{
@@ -35,6 +62,10 @@ func Load() (*Config, error) {
 		AdminPass:       "",
 		SessionTTL:      24 * time.Hour,
 		MetricsInterval: 5 * time.Second,
+		ReaderTimeout:   3 * time.Second,
+
+		CrashLoopRestarts: 3,
+		CrashLoopWindow:   10 * time.Minute,
 	}
 
 	if v := os.Getenv("ULTRON_PORT"); v != "" {
@@ -88,9 +119,100 @@ func Load() (*Config, error) {
 		cfg.MetricsInterval = d
 	}
 
+	if v := os.Getenv("ULTRON_READER_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reader timeout %q: %w", v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid reader timeout: must be > 0, got %v", d)
+		}
+		cfg.ReaderTimeout = d
+	}
+
+	if v := os.Getenv("ULTRON_METRICS_TOKEN"); v != "" {
+		cfg.MetricsToken = v
+	}
+
+	if v := os.Getenv("ULTRON_TEMP_SENSOR"); v != "" {
+		cfg.TempSensor = v
+	}
+
+	if v := os.Getenv("ULTRON_DISK_INCLUDE_FSTYPES"); v != "" {
+		cfg.DiskIncludeFSTypes = splitList(v)
+	}
+	if v := os.Getenv("ULTRON_DISK_EXCLUDE_FSTYPES"); v != "" {
+		cfg.DiskExcludeFSTypes = splitList(v)
+	}
+	if v := os.Getenv("ULTRON_DISK_INCLUDE_MOUNTS"); v != "" {
+		cfg.DiskIncludeMounts = splitList(v)
+	}
+	if v := os.Getenv("ULTRON_DISK_EXCLUDE_MOUNTS"); v != "" {
+		cfg.DiskExcludeMounts = splitList(v)
+	}
+
+	if v := os.Getenv("ULTRON_NET_INCLUDE"); v != "" {
+		cfg.NetInclude = splitList(v)
+	}
+	if v := os.Getenv("ULTRON_NET_EXCLUDE"); v != "" {
+		cfg.NetExclude = splitList(v)
+	}
+
+	if v := os.Getenv("ULTRON_COLLECTORS_FILE"); v != "" {
+		collectors, err := loadCollectors(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Collectors = collectors
+	}
+
+	if v := os.Getenv("ULTRON_INGEST_ADDR"); v != "" {
+		_, port, err := net.SplitHostPort(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ingest address %q: %w", v, err)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("invalid ingest address %q: port must be 1-65535", v)
+		}
+		cfg.IngestAddr = v
+	}
+
+	if v := os.Getenv("ULTRON_CRASHLOOP_RESTARTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid crash loop restarts %q: %w", v, err)
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("invalid crash loop restarts: must be >= 1, got %d", n)
+		}
+		cfg.CrashLoopRestarts = n
+	}
+
+	if v := os.Getenv("ULTRON_CRASHLOOP_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid crash loop window %q: %w", v, err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("invalid crash loop window: must be >= 1m, got %v", d)
+		}
+		cfg.CrashLoopWindow = d
+	}
+
 	return cfg, nil
 }
 
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(v string) []string {
+	items := []string{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (c *Config) Addr() string {
 	return fmt.Sprintf(":%d", c.Port)
 }
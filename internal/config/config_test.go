@@ -11,7 +11,7 @@ import (
 
 func clearEnv(t *testing.T) {
 	t.Helper()
-	for _, key := range []string{"ULTRON_PORT", "ULTRON_DB_PATH", "ULTRON_LOG_LEVEL", "ULTRON_ADMIN_USER", "ULTRON_ADMIN_PASS", "ULTRON_SESSION_TTL", "ULTRON_METRICS_INTERVAL"} {
+	for _, key := range []string{"ULTRON_PORT", "ULTRON_DB_PATH", "ULTRON_LOG_LEVEL", "ULTRON_ADMIN_USER", "ULTRON_ADMIN_PASS", "ULTRON_SESSION_TTL", "ULTRON_METRICS_INTERVAL", "ULTRON_READER_TIMEOUT", "ULTRON_METRICS_TOKEN", "ULTRON_TEMP_SENSOR", "ULTRON_DISK_INCLUDE_FSTYPES", "ULTRON_DISK_EXCLUDE_FSTYPES", "ULTRON_DISK_INCLUDE_MOUNTS", "ULTRON_DISK_EXCLUDE_MOUNTS", "ULTRON_NET_INCLUDE", "ULTRON_NET_EXCLUDE", "ULTRON_COLLECTORS_FILE", "ULTRON_INGEST_ADDR", "ULTRON_CRASHLOOP_RESTARTS", "ULTRON_CRASHLOOP_WINDOW"} {
 		t.Setenv(key, "")
 		os.Unsetenv(key)
 	}
@@ -30,6 +30,13 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "", cfg.AdminPass)
 	assert.Equal(t, 24*time.Hour, cfg.SessionTTL)
 	assert.Equal(t, 5*time.Second, cfg.MetricsInterval)
+	assert.Equal(t, 3*time.Second, cfg.ReaderTimeout)
+	assert.Equal(t, "", cfg.MetricsToken)
+	assert.Equal(t, "", cfg.TempSensor)
+	assert.Nil(t, cfg.DiskExcludeFSTypes)
+	assert.Nil(t, cfg.DiskIncludeMounts)
+	assert.Equal(t, 3, cfg.CrashLoopRestarts)
+	assert.Equal(t, 10*time.Minute, cfg.CrashLoopWindow)
 }
 
 func TestLoad_CustomPort(t *testing.T) {
@@ -187,3 +194,113 @@ func TestLoad_MetricsIntervalTooLow(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "must be >= 1s")
 }
+
+func TestLoad_CustomReaderTimeout(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_READER_TIMEOUT", "750ms")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 750*time.Millisecond, cfg.ReaderTimeout)
+}
+
+func TestLoad_InvalidReaderTimeout(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_READER_TIMEOUT", "0s")
+
+	_, err := Load()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid reader timeout")
+}
+
+func TestLoad_MetricsToken(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_METRICS_TOKEN", "scrape-secret")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "scrape-secret", cfg.MetricsToken)
+}
+
+func TestLoad_TempSensor(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_TEMP_SENSOR", "cpu_thermal")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "cpu_thermal", cfg.TempSensor)
+}
+
+func TestLoad_DiskFilters(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_DISK_INCLUDE_FSTYPES", "ext4, vfat")
+	t.Setenv("ULTRON_DISK_EXCLUDE_FSTYPES", "squashfs")
+	t.Setenv("ULTRON_DISK_INCLUDE_MOUNTS", "/,/data/*")
+	t.Setenv("ULTRON_DISK_EXCLUDE_MOUNTS", "/boot/firmware,,")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ext4", "vfat"}, cfg.DiskIncludeFSTypes)
+	assert.Equal(t, []string{"squashfs"}, cfg.DiskExcludeFSTypes)
+	assert.Equal(t, []string{"/", "/data/*"}, cfg.DiskIncludeMounts)
+	assert.Equal(t, []string{"/boot/firmware"}, cfg.DiskExcludeMounts)
+}
+
+func TestLoad_NetFilters(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_NET_INCLUDE", "eth*,wlan0")
+	t.Setenv("ULTRON_NET_EXCLUDE", "lo")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eth*", "wlan0"}, cfg.NetInclude)
+	assert.Equal(t, []string{"lo"}, cfg.NetExclude)
+}
+
+func TestLoad_IngestAddr(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.IngestAddr, "disabled by default")
+
+	t.Setenv("ULTRON_INGEST_ADDR", "127.0.0.1:8125")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8125", cfg.IngestAddr)
+}
+
+func TestLoad_InvalidIngestAddr(t *testing.T) {
+	for _, v := range []string{"8125", ":statsd", ":0", "localhost:70000"} {
+		clearEnv(t)
+		t.Setenv("ULTRON_INGEST_ADDR", v)
+		_, err := Load()
+		assert.Error(t, err, v)
+	}
+}
+
+func TestLoad_CrashLoop(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ULTRON_CRASHLOOP_RESTARTS", "5")
+	t.Setenv("ULTRON_CRASHLOOP_WINDOW", "30m")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.CrashLoopRestarts)
+	assert.Equal(t, 30*time.Minute, cfg.CrashLoopWindow)
+}
+
+func TestLoad_InvalidCrashLoop(t *testing.T) {
+	for _, tc := range []struct{ key, value, want string }{
+		{"ULTRON_CRASHLOOP_RESTARTS", "three", "invalid crash loop restarts"},
+		{"ULTRON_CRASHLOOP_RESTARTS", "0", "invalid crash loop restarts"},
+		{"ULTRON_CRASHLOOP_WINDOW", "soon", "invalid crash loop window"},
+		{"ULTRON_CRASHLOOP_WINDOW", "30s", "invalid crash loop window"},
+	} {
+		clearEnv(t)
+		t.Setenv(tc.key, tc.value)
+
+		_, err := Load()
+		require.Error(t, err, "%s=%s", tc.key, tc.value)
+		assert.Contains(t, err.Error(), tc.want)
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Results recorded in ActionLog.
+const (
+	ActionResultSuccess = "success"
+	ActionResultError   = "error"
+)
+
+// ActionLog records a control action taken from the panel, e.g. restarting a
+// container.
+type ActionLog struct {
+	ID        int64
+	UserID    *int64 // nil when no user is known
+	Username  string // filled by ListActionLogs, "" when the user is gone
+	Action    string // e.g. "docker.restart"
+	Target    string // e.g. the container name
+	Result    string // ActionResultSuccess or ActionResultError
+	Details   string // the error message of a failed action
+	CreatedAt time.Time
+}
+
+// LogAction inserts an action log entry.
+func (db *DB) LogAction(a *ActionLog) error {
+	result, err := db.Exec(
+		`INSERT INTO ActionLog (user_id, action, target, result, details) VALUES (?, ?, ?, ?, ?)`,
+		a.UserID, a.Action, a.Target, a.Result, a.Details,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot log action: %w", err)
+	}
+	a.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListActionLogs returns action log entries ordered by most recent first,
+// limited to n rows.
+func (db *DB) ListActionLogs(limit int) ([]ActionLog, error) {
+	rows, err := db.Query(
+		`SELECT a.id, a.user_id, COALESCE(u.username, ''), a.action, a.target, a.result, COALESCE(a.details, ''), a.created_at
+		 FROM ActionLog a LEFT JOIN User u ON u.id = a.user_id
+		 ORDER BY a.created_at DESC, a.id DESC LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list action logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []ActionLog
+	for rows.Next() {
+		var a ActionLog
+		var userID sql.NullInt64
+		if err := rows.Scan(&a.ID, &userID, &a.Username, &a.Action, &a.Target, &a.Result, &a.Details, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("cannot scan action log: %w", err)
+		}
+		if userID.Valid {
+			a.UserID = &userID.Int64
+		}
+		logs = append(logs, a)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAction(t *testing.T) {
+	db := setupAlertTestDB(t)
+	require.NoError(t, db.CreateUser("admin", "hash"))
+	user, err := db.GetUserByUsername("admin")
+	require.NoError(t, err)
+
+	ok := &ActionLog{UserID: &user.ID, Action: "docker.restart", Target: "web", Result: ActionResultSuccess}
+	require.NoError(t, db.LogAction(ok))
+	assert.NotZero(t, ok.ID)
+	require.NoError(t, db.LogAction(&ActionLog{Action: "docker.kill", Target: "db", Result: ActionResultError, Details: "No such container: db"}))
+
+	logs, err := db.ListActionLogs(10)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	assert.Equal(t, "docker.kill", logs[0].Action, "most recent first")
+	assert.Nil(t, logs[0].UserID)
+	assert.Empty(t, logs[0].Username)
+	assert.Equal(t, ActionResultError, logs[0].Result)
+	assert.Equal(t, "No such container: db", logs[0].Details)
+
+	require.NotNil(t, logs[1].UserID)
+	assert.Equal(t, user.ID, *logs[1].UserID)
+	assert.Equal(t, "admin", logs[1].Username)
+	assert.Equal(t, "web", logs[1].Target)
+	assert.Empty(t, logs[1].Details)
+	assert.False(t, logs[1].CreatedAt.IsZero())
+
+	logs, err = db.ListActionLogs(1)
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+}
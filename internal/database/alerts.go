@@ -6,13 +6,22 @@ import (
 	"time"
 )
 
+// Alert rule modes.
+const (
+	AlertModeThreshold = "threshold" // fire when the value compares to Threshold
+	AlertModeAnomaly   = "anomaly"   // fire when the value leaves its learned baseline
+)
+
 // AlertConfig represents a configured alert rule.
 type AlertConfig struct {
 	ID              int64
 	Name            string
 	Metric          string
-	Operator        string
+	Target          string // optional: sensor label or device the rule applies to
+	Mode            string // AlertModeThreshold or AlertModeAnomaly
+	Operator        string // anomaly mode: > or >= for above the baseline, < or <= for below
 	Threshold       float64
+	Hourly          bool // anomaly mode: learn a separate baseline for each hour of the day
 	Severity        string
 	Enabled         bool
 	CooldownMinutes int
@@ -20,6 +29,8 @@ type AlertConfig struct {
 	UpdatedAt       time.Time
 }
 
+const alertConfigColumns = `id, name, metric, target, mode, operator, threshold, hourly, severity, enabled, cooldown_minutes, created_at, updated_at`
+
 // Alert represents a triggered alert record.
 type Alert struct {
 	ID           int64
@@ -32,16 +43,16 @@ type Alert struct {
 	CreatedAt    time.Time
 }
 
-// CreateAlertConfig inserts a new alert rule.
+// CreateAlertConfig inserts a new alert rule. An empty Mode is stored as
+// AlertModeThreshold.
 func (db *DB) CreateAlertConfig(ac *AlertConfig) error {
-	enabled := 0
-	if ac.Enabled {
-		enabled = 1
+	if ac.Mode == "" {
+		ac.Mode = AlertModeThreshold
 	}
 	result, err := db.Exec(
-		`INSERT INTO AlertConfig (name, metric, operator, threshold, severity, enabled, cooldown_minutes)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		ac.Name, ac.Metric, ac.Operator, ac.Threshold, ac.Severity, enabled, ac.CooldownMinutes,
+		`INSERT INTO AlertConfig (name, metric, target, mode, operator, threshold, hourly, severity, enabled, cooldown_minutes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ac.Name, ac.Metric, ac.Target, ac.Mode, ac.Operator, ac.Threshold, boolInt(ac.Hourly), ac.Severity, boolInt(ac.Enabled), ac.CooldownMinutes,
 	)
 	if err != nil {
 		return fmt.Errorf("cannot create alert config: %w", err)
@@ -52,54 +63,59 @@ func (db *DB) CreateAlertConfig(ac *AlertConfig) error {
 
 // ListAlertConfigs returns all alert configs.
 func (db *DB) ListAlertConfigs() ([]AlertConfig, error) {
-	rows, err := db.Query(
-		`SELECT id, name, metric, operator, threshold, severity, enabled, cooldown_minutes, created_at, updated_at
-		 FROM AlertConfig ORDER BY id`,
-	)
+	configs, err := db.queryAlertConfigs(`SELECT ` + alertConfigColumns + ` FROM AlertConfig ORDER BY id`)
 	if err != nil {
 		return nil, fmt.Errorf("cannot list alert configs: %w", err)
 	}
-	defer rows.Close()
-
-	var configs []AlertConfig
-	for rows.Next() {
-		var ac AlertConfig
-		var enabled int
-		if err := rows.Scan(&ac.ID, &ac.Name, &ac.Metric, &ac.Operator, &ac.Threshold,
-			&ac.Severity, &enabled, &ac.CooldownMinutes, &ac.CreatedAt, &ac.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("cannot scan alert config: %w", err)
-		}
-		ac.Enabled = enabled == 1
-		configs = append(configs, ac)
-	}
-	return configs, rows.Err()
+	return configs, nil
 }
 
 // ListEnabledAlertConfigs returns only enabled alert configs.
 func (db *DB) ListEnabledAlertConfigs() ([]AlertConfig, error) {
-	rows, err := db.Query(
-		`SELECT id, name, metric, operator, threshold, severity, enabled, cooldown_minutes, created_at, updated_at
-		 FROM AlertConfig WHERE enabled = 1 ORDER BY id`,
-	)
+	configs, err := db.queryAlertConfigs(`SELECT ` + alertConfigColumns + ` FROM AlertConfig WHERE enabled = 1 ORDER BY id`)
 	if err != nil {
 		return nil, fmt.Errorf("cannot list enabled alert configs: %w", err)
 	}
+	return configs, nil
+}
+
+func (db *DB) queryAlertConfigs(query string) ([]AlertConfig, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var configs []AlertConfig
 	for rows.Next() {
-		var ac AlertConfig
-		var enabled int
-		if err := rows.Scan(&ac.ID, &ac.Name, &ac.Metric, &ac.Operator, &ac.Threshold,
-			&ac.Severity, &enabled, &ac.CooldownMinutes, &ac.CreatedAt, &ac.UpdatedAt); err != nil {
+		ac, err := scanAlertConfig(rows)
+		if err != nil {
 			return nil, fmt.Errorf("cannot scan alert config: %w", err)
 		}
-		ac.Enabled = enabled == 1
-		configs = append(configs, ac)
+		configs = append(configs, *ac)
 	}
 	return configs, rows.Err()
 }
 
+func scanAlertConfig(row interface{ Scan(...any) error }) (*AlertConfig, error) {
+	var ac AlertConfig
+	var hourly, enabled int
+	if err := row.Scan(&ac.ID, &ac.Name, &ac.Metric, &ac.Target, &ac.Mode, &ac.Operator, &ac.Threshold,
+		&hourly, &ac.Severity, &enabled, &ac.CooldownMinutes, &ac.CreatedAt, &ac.UpdatedAt); err != nil {
+		return nil, err
+	}
+	ac.Hourly = hourly == 1
+	ac.Enabled = enabled == 1
+	return &ac, nil
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // CreateAlert inserts a triggered alert.
 func (db *DB) CreateAlert(a *Alert) error {
 	ack := 0
@@ -155,33 +171,25 @@ func (db *DB) AlertConfigCount() (int, error) {
 
 // GetAlertConfig returns a single alert config by ID.
 func (db *DB) GetAlertConfig(id int64) (*AlertConfig, error) {
-	var ac AlertConfig
-	var enabled int
-	err := db.QueryRow(
-		`SELECT id, name, metric, operator, threshold, severity, enabled, cooldown_minutes, created_at, updated_at
-		 FROM AlertConfig WHERE id = ?`, id,
-	).Scan(&ac.ID, &ac.Name, &ac.Metric, &ac.Operator, &ac.Threshold,
-		&ac.Severity, &enabled, &ac.CooldownMinutes, &ac.CreatedAt, &ac.UpdatedAt)
+	ac, err := scanAlertConfig(db.QueryRow(`SELECT `+alertConfigColumns+` FROM AlertConfig WHERE id = ?`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot get alert config: %w", err)
 	}
-	ac.Enabled = enabled == 1
-	return &ac, nil
+	return ac, nil
 }
 
 // UpdateAlertConfig updates an existing alert rule.
 func (db *DB) UpdateAlertConfig(ac *AlertConfig) error {
-	enabled := 0
-	if ac.Enabled {
-		enabled = 1
+	if ac.Mode == "" {
+		ac.Mode = AlertModeThreshold
 	}
 	_, err := db.Exec(
-		`UPDATE AlertConfig SET name=?, metric=?, operator=?, threshold=?, severity=?, enabled=?, cooldown_minutes=?, updated_at=CURRENT_TIMESTAMP
+		`UPDATE AlertConfig SET name=?, metric=?, target=?, mode=?, operator=?, threshold=?, hourly=?, severity=?, enabled=?, cooldown_minutes=?, updated_at=CURRENT_TIMESTAMP
 		 WHERE id=?`,
-		ac.Name, ac.Metric, ac.Operator, ac.Threshold, ac.Severity, enabled, ac.CooldownMinutes, ac.ID,
+		ac.Name, ac.Metric, ac.Target, ac.Mode, ac.Operator, ac.Threshold, boolInt(ac.Hourly), ac.Severity, boolInt(ac.Enabled), ac.CooldownMinutes, ac.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("cannot update alert config %d: %w", ac.ID, err)
@@ -223,7 +231,9 @@ func (db *DB) SeedDefaultAlertConfigs() error {
 		{Name: "High CPU", Metric: "cpu", Operator: ">", Threshold: 90, Severity: "critical", Enabled: true, CooldownMinutes: 15},
 		{Name: "High Memory", Metric: "ram", Operator: ">", Threshold: 85, Severity: "warning", Enabled: true, CooldownMinutes: 15},
 		{Name: "Disk Full", Metric: "disk", Operator: ">", Threshold: 90, Severity: "critical", Enabled: true, CooldownMinutes: 30},
+		{Name: "Disk Filling Up", Metric: "disk_full_eta_hours", Operator: "<", Threshold: 72, Severity: "warning", Enabled: true, CooldownMinutes: 360},
 		{Name: "High Temperature", Metric: "temp", Operator: ">", Threshold: 75, Severity: "warning", Enabled: true, CooldownMinutes: 15},
+		{Name: "Under-voltage", Metric: "undervoltage", Operator: ">", Threshold: 0, Severity: "critical", Enabled: true, CooldownMinutes: 60},
 	}
 
 	for i := range defaults {
@@ -147,11 +147,14 @@ func TestSeedDefaultAlertConfigs(t *testing.T) {
 
 	configs, err := db.ListAlertConfigs()
 	require.NoError(t, err)
-	assert.Len(t, configs, 4)
+	assert.Len(t, configs, 6)
 	assert.Equal(t, "High CPU", configs[0].Name)
 	assert.Equal(t, "High Memory", configs[1].Name)
 	assert.Equal(t, "Disk Full", configs[2].Name)
-	assert.Equal(t, "High Temperature", configs[3].Name)
+	assert.Equal(t, "Disk Filling Up", configs[3].Name)
+	assert.Equal(t, "disk_full_eta_hours", configs[3].Metric)
+	assert.Equal(t, "High Temperature", configs[4].Name)
+	assert.Equal(t, "Under-voltage", configs[5].Name)
 }
 
 func TestSeedDefaultAlertConfigs_Idempotent(t *testing.T) {
@@ -162,7 +165,43 @@ func TestSeedDefaultAlertConfigs_Idempotent(t *testing.T) {
 
 	configs, err := db.ListAlertConfigs()
 	require.NoError(t, err)
-	assert.Len(t, configs, 4)
+	assert.Len(t, configs, 6)
+}
+
+func TestAlertConfig_Target(t *testing.T) {
+	db := setupAlertTestDB(t)
+
+	ac := &AlertConfig{Name: "NVMe hot", Metric: "temp", Target: "nvme_composite", Operator: ">", Threshold: 70, Severity: "warning", Enabled: true, CooldownMinutes: 15}
+	require.NoError(t, db.CreateAlertConfig(ac))
+
+	got, err := db.GetAlertConfig(ac.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "nvme_composite", got.Target)
+
+	got.Target = "cpu_thermal"
+	require.NoError(t, db.UpdateAlertConfig(got))
+
+	configs, err := db.ListEnabledAlertConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "cpu_thermal", configs[0].Target)
+}
+
+func TestAlertConfig_AnomalyMode(t *testing.T) {
+	db := setupAlertTestDB(t)
+
+	ac := &AlertConfig{Name: "CPU", Metric: "cpu", Operator: ">", Threshold: 90, Severity: "warning", Enabled: true}
+	require.NoError(t, db.CreateAlertConfig(ac))
+	assert.Equal(t, AlertModeThreshold, ac.Mode, "empty mode defaults to threshold")
+
+	ac.Mode, ac.Threshold, ac.Hourly = AlertModeAnomaly, 3, true
+	require.NoError(t, db.UpdateAlertConfig(ac))
+
+	got, err := db.GetAlertConfig(ac.ID)
+	require.NoError(t, err)
+	assert.Equal(t, AlertModeAnomaly, got.Mode)
+	assert.Equal(t, 3.0, got.Threshold)
+	assert.True(t, got.Hourly)
 }
 
 func TestAlert_NilConfigID(t *testing.T) {
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MetricSample is one persisted point of a metric series. Raw samples have a
+// Resolution of 0 and Min == Avg == Max; rollups aggregate the bucket of
+// length Resolution starting at Timestamp.
+type MetricSample struct {
+	Resolution time.Duration
+	Timestamp  time.Time
+	Series     string
+	Min        float64
+	Avg        float64
+	Max        float64
+}
+
+// InsertMetricSamples stores raw values for all series at a single point in time.
+func (db *DB) InsertMetricSamples(ts time.Time, values map[string]float64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin metric insert: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT OR REPLACE INTO MetricSample (resolution, ts, series, min, avg, max)
+		 VALUES (0, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot prepare metric insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for series, v := range values {
+		if _, err := stmt.Exec(ts.Unix(), series, v, v, v); err != nil {
+			return fmt.Errorf("cannot insert metric sample %q: %w", series, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit metric samples: %w", err)
+	}
+	return nil
+}
+
+// RollupMetricSamples aggregates samples of resolution src with timestamps in
+// [from, to) into min/avg/max buckets of resolution dst. Existing buckets are
+// replaced, so rolling up the same range twice is harmless.
+func (db *DB) RollupMetricSamples(src, dst time.Duration, from, to time.Time) error {
+	step := int64(dst / time.Second)
+	if step <= 0 {
+		return fmt.Errorf("cannot roll up metric samples: invalid resolution %v", dst)
+	}
+	_, err := db.Exec(
+		`INSERT OR REPLACE INTO MetricSample (resolution, ts, series, min, avg, max)
+		 SELECT ?, (ts / ?) * ?, series, MIN(min), AVG(avg), MAX(max)
+		 FROM MetricSample
+		 WHERE resolution = ? AND ts >= ? AND ts < ?
+		 GROUP BY series, ts / ?`,
+		step, step, step, int64(src/time.Second), from.Unix(), to.Unix(), step,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot roll up metric samples to %v: %w", dst, err)
+	}
+	return nil
+}
+
+// ListMetricSamples returns samples of the given resolution with timestamps in
+// [from, to), ordered by time and series name.
+func (db *DB) ListMetricSamples(resolution time.Duration, from, to time.Time) ([]MetricSample, error) {
+	rows, err := db.Query(
+		`SELECT ts, series, min, avg, max FROM MetricSample
+		 WHERE resolution = ? AND ts >= ? AND ts < ?
+		 ORDER BY ts, series`,
+		int64(resolution/time.Second), from.Unix(), to.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list metric samples: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []MetricSample
+	for rows.Next() {
+		ms := MetricSample{Resolution: resolution}
+		var ts int64
+		if err := rows.Scan(&ts, &ms.Series, &ms.Min, &ms.Avg, &ms.Max); err != nil {
+			return nil, fmt.Errorf("cannot scan metric sample: %w", err)
+		}
+		ms.Timestamp = time.Unix(ts, 0)
+		samples = append(samples, ms)
+	}
+	return samples, rows.Err()
+}
+
+// LatestMetricSampleTime returns the newest timestamp stored for a resolution,
+// or the zero time if there is none.
+func (db *DB) LatestMetricSampleTime(resolution time.Duration) (time.Time, error) {
+	var ts sql.NullInt64
+	err := db.QueryRow(
+		"SELECT MAX(ts) FROM MetricSample WHERE resolution = ?", int64(resolution/time.Second),
+	).Scan(&ts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot get latest metric sample: %w", err)
+	}
+	if !ts.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(ts.Int64, 0), nil
+}
+
+// PruneMetricSamples deletes samples of a resolution older than before.
+func (db *DB) PruneMetricSamples(resolution time.Duration, before time.Time) (int64, error) {
+	result, err := db.Exec(
+		"DELETE FROM MetricSample WHERE resolution = ? AND ts < ?",
+		int64(resolution/time.Second), before.Unix(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("cannot prune metric samples: %w", err)
+	}
+	return result.RowsAffected()
+}
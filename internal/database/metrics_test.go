@@ -0,0 +1,106 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertAndListMetricSamples(t *testing.T) {
+	db := setupAlertTestDB(t)
+	base := time.Unix(1700000000, 0)
+
+	require.NoError(t, db.InsertMetricSamples(base, map[string]float64{"cpu": 10, "ram": 50}))
+	require.NoError(t, db.InsertMetricSamples(base.Add(5*time.Second), map[string]float64{"cpu": 20, "ram": 55}))
+
+	samples, err := db.ListMetricSamples(0, base, base.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, samples, 4)
+
+	assert.Equal(t, "cpu", samples[0].Series)
+	assert.Equal(t, base, samples[0].Timestamp)
+	assert.Equal(t, 10.0, samples[0].Min)
+	assert.Equal(t, 10.0, samples[0].Avg)
+	assert.Equal(t, 10.0, samples[0].Max)
+	assert.Equal(t, "ram", samples[3].Series)
+	assert.Equal(t, 55.0, samples[3].Avg)
+}
+
+func TestListMetricSamples_EndExclusive(t *testing.T) {
+	db := setupAlertTestDB(t)
+	base := time.Unix(1700000000, 0)
+
+	require.NoError(t, db.InsertMetricSamples(base, map[string]float64{"cpu": 10}))
+
+	samples, err := db.ListMetricSamples(0, base.Add(-time.Minute), base)
+	require.NoError(t, err)
+	assert.Empty(t, samples)
+}
+
+func TestRollupMetricSamples(t *testing.T) {
+	db := setupAlertTestDB(t)
+	base := time.Unix(1700000040, 0) // aligned to a minute
+
+	for i, v := range []float64{10, 20, 30, 40} {
+		require.NoError(t, db.InsertMetricSamples(base.Add(time.Duration(i)*15*time.Second), map[string]float64{"cpu": v}))
+	}
+	// Next minute — outside the rollup range
+	require.NoError(t, db.InsertMetricSamples(base.Add(time.Minute), map[string]float64{"cpu": 99}))
+
+	require.NoError(t, db.RollupMetricSamples(0, time.Minute, base, base.Add(time.Minute)))
+
+	rollups, err := db.ListMetricSamples(time.Minute, base, base.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, rollups, 1)
+	assert.Equal(t, base, rollups[0].Timestamp)
+	assert.Equal(t, 10.0, rollups[0].Min)
+	assert.Equal(t, 25.0, rollups[0].Avg)
+	assert.Equal(t, 40.0, rollups[0].Max)
+
+	// Rolling up again replaces the bucket instead of duplicating it
+	require.NoError(t, db.RollupMetricSamples(0, time.Minute, base, base.Add(time.Minute)))
+	rollups, err = db.ListMetricSamples(time.Minute, base, base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, rollups, 1)
+}
+
+func TestRollupMetricSamples_InvalidResolution(t *testing.T) {
+	db := setupAlertTestDB(t)
+	err := db.RollupMetricSamples(0, 0, time.Unix(0, 0), time.Now())
+	assert.Error(t, err)
+}
+
+func TestLatestMetricSampleTime(t *testing.T) {
+	db := setupAlertTestDB(t)
+
+	latest, err := db.LatestMetricSampleTime(0)
+	require.NoError(t, err)
+	assert.True(t, latest.IsZero())
+
+	base := time.Unix(1700000000, 0)
+	require.NoError(t, db.InsertMetricSamples(base, map[string]float64{"cpu": 1}))
+	require.NoError(t, db.InsertMetricSamples(base.Add(10*time.Second), map[string]float64{"cpu": 2}))
+
+	latest, err = db.LatestMetricSampleTime(0)
+	require.NoError(t, err)
+	assert.Equal(t, base.Add(10*time.Second), latest)
+}
+
+func TestPruneMetricSamples(t *testing.T) {
+	db := setupAlertTestDB(t)
+	base := time.Unix(1700000000, 0)
+
+	require.NoError(t, db.InsertMetricSamples(base, map[string]float64{"cpu": 1, "ram": 2}))
+	require.NoError(t, db.InsertMetricSamples(base.Add(time.Hour), map[string]float64{"cpu": 3}))
+
+	n, err := db.PruneMetricSamples(0, base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	samples, err := db.ListMetricSamples(0, base, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, 3.0, samples[0].Avg)
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Probe is a configured synthetic check.
+type Probe struct {
+	ID              int64
+	Name            string
+	Type            string // "http", "tcp", "dns", "icmp", "tls" or "cert"
+	Target          string // URL for http, host:port for tcp and tls, hostname for dns and icmp, file or glob for cert
+	IntervalSeconds int
+	TimeoutSeconds  int
+	ExpectStatus    int    // http: required status code, 0 accepts any 2xx or 3xx
+	Expect          string // http: text the body must contain; dns: address the answer must include
+	MaxLatencyMs    int    // slower checks count as down, 0 for no limit
+	WarnDays        int    // tls and cert: warn when a certificate expires within this many days
+	CritDays        int    // tls and cert: critical alert within this many days
+	Enabled         bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ProbeResult is the outcome of one run of a probe.
+type ProbeResult struct {
+	ProbeID   int64
+	Timestamp time.Time
+	Up        bool
+	LatencyMs float64
+	Error     string // why the check failed, "" when up
+	Detail    string // type-specific JSON, e.g. the certificates of tls and cert probes
+}
+
+const probeColumns = `id, name, type, target, interval_seconds, timeout_seconds, expect_status, expect, max_latency_ms, warn_days, crit_days, enabled, created_at, updated_at`
+
+// CreateProbe inserts a new probe.
+func (db *DB) CreateProbe(p *Probe) error {
+	enabled := 0
+	if p.Enabled {
+		enabled = 1
+	}
+	result, err := db.Exec(
+		`INSERT INTO Probe (name, type, target, interval_seconds, timeout_seconds, expect_status, expect, max_latency_ms, warn_days, crit_days, enabled)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Name, p.Type, p.Target, p.IntervalSeconds, p.TimeoutSeconds, p.ExpectStatus, p.Expect, p.MaxLatencyMs, p.WarnDays, p.CritDays, enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot create probe: %w", err)
+	}
+	p.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListProbes returns all probes.
+func (db *DB) ListProbes() ([]Probe, error) {
+	return db.queryProbes(`SELECT ` + probeColumns + ` FROM Probe ORDER BY id`)
+}
+
+// ListEnabledProbes returns only enabled probes.
+func (db *DB) ListEnabledProbes() ([]Probe, error) {
+	return db.queryProbes(`SELECT ` + probeColumns + ` FROM Probe WHERE enabled = 1 ORDER BY id`)
+}
+
+func (db *DB) queryProbes(query string) ([]Probe, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list probes: %w", err)
+	}
+	defer rows.Close()
+
+	var probes []Probe
+	for rows.Next() {
+		p, err := scanProbe(rows)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, *p)
+	}
+	return probes, rows.Err()
+}
+
+// GetProbe returns a single probe by ID, or nil if it does not exist.
+func (db *DB) GetProbe(id int64) (*Probe, error) {
+	p, err := scanProbe(db.QueryRow(`SELECT `+probeColumns+` FROM Probe WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func scanProbe(row interface{ Scan(...any) error }) (*Probe, error) {
+	var p Probe
+	var enabled int
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Target, &p.IntervalSeconds, &p.TimeoutSeconds,
+		&p.ExpectStatus, &p.Expect, &p.MaxLatencyMs, &p.WarnDays, &p.CritDays, &enabled, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan probe: %w", err)
+	}
+	p.Enabled = enabled == 1
+	return &p, nil
+}
+
+// ToggleProbe flips the enabled state of a probe.
+func (db *DB) ToggleProbe(id int64) error {
+	_, err := db.Exec(
+		`UPDATE Probe SET enabled = CASE WHEN enabled = 1 THEN 0 ELSE 1 END, updated_at=CURRENT_TIMESTAMP WHERE id=?`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot toggle probe %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteProbe removes a probe and its results.
+func (db *DB) DeleteProbe(id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot delete probe %d: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM ProbeResult WHERE probe_id=?", id); err != nil {
+		return fmt.Errorf("cannot delete results of probe %d: %w", id, err)
+	}
+	if _, err := tx.Exec("DELETE FROM Probe WHERE id=?", id); err != nil {
+		return fmt.Errorf("cannot delete probe %d: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot delete probe %d: %w", id, err)
+	}
+	return nil
+}
+
+// InsertProbeResult stores the outcome of a probe run.
+func (db *DB) InsertProbeResult(r *ProbeResult) error {
+	up := 0
+	if r.Up {
+		up = 1
+	}
+	_, err := db.Exec(
+		`INSERT OR REPLACE INTO ProbeResult (probe_id, ts, up, latency_ms, error, detail) VALUES (?, ?, ?, ?, ?, ?)`,
+		r.ProbeID, r.Timestamp.UnixMilli(), up, r.LatencyMs, r.Error, r.Detail,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot insert result of probe %d: %w", r.ProbeID, err)
+	}
+	return nil
+}
+
+// ListProbeResults returns the latest limit results of a probe, oldest first.
+func (db *DB) ListProbeResults(probeID int64, limit int) ([]ProbeResult, error) {
+	rows, err := db.Query(
+		`SELECT ts, up, latency_ms, error, detail FROM
+		 (SELECT ts, up, latency_ms, error, detail FROM ProbeResult WHERE probe_id = ? ORDER BY ts DESC LIMIT ?)
+		 ORDER BY ts`,
+		probeID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list results of probe %d: %w", probeID, err)
+	}
+	defer rows.Close()
+
+	var results []ProbeResult
+	for rows.Next() {
+		r := ProbeResult{ProbeID: probeID}
+		var ts int64
+		var up int
+		if err := rows.Scan(&ts, &up, &r.LatencyMs, &r.Error, &r.Detail); err != nil {
+			return nil, fmt.Errorf("cannot scan probe result: %w", err)
+		}
+		r.Timestamp = time.UnixMilli(ts)
+		r.Up = up == 1
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// PruneProbeResults deletes probe results older than before.
+func (db *DB) PruneProbeResults(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM ProbeResult WHERE ts < ?", before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("cannot prune probe results: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeCRUD(t *testing.T) {
+	db := setupAlertTestDB(t)
+
+	p := &Probe{Name: "web", Type: "http", Target: "http://localhost:8080/health", IntervalSeconds: 30, TimeoutSeconds: 5, ExpectStatus: 200, Expect: "ok", Enabled: true}
+	require.NoError(t, db.CreateProbe(p))
+	require.NoError(t, db.CreateProbe(&Probe{Name: "router", Type: "icmp", Target: "192.168.1.1", IntervalSeconds: 60, TimeoutSeconds: 5}))
+	cert := &Probe{Name: "letsencrypt", Type: "cert", Target: "/etc/letsencrypt/live/*/fullchain.pem", IntervalSeconds: 3600, TimeoutSeconds: 5, WarnDays: 30, CritDays: 10, Enabled: true}
+	require.NoError(t, db.CreateProbe(cert))
+
+	got, err := db.GetProbe(p.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "http://localhost:8080/health", got.Target)
+	assert.Equal(t, 200, got.ExpectStatus)
+	assert.Equal(t, "ok", got.Expect)
+	assert.True(t, got.Enabled)
+
+	got, err = db.GetProbe(cert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, got.WarnDays)
+	assert.Equal(t, 10, got.CritDays)
+
+	all, err := db.ListProbes()
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+	enabled, err := db.ListEnabledProbes()
+	require.NoError(t, err)
+	require.Len(t, enabled, 2)
+	assert.Equal(t, "web", enabled[0].Name)
+
+	require.NoError(t, db.ToggleProbe(p.ID))
+	got, _ = db.GetProbe(p.ID)
+	assert.False(t, got.Enabled)
+
+	require.NoError(t, db.DeleteProbe(p.ID))
+	got, err = db.GetProbe(p.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestProbeResults(t *testing.T) {
+	db := setupAlertTestDB(t)
+	p := &Probe{Name: "ssh", Type: "tcp", Target: "localhost:22", IntervalSeconds: 60, TimeoutSeconds: 5, Enabled: true}
+	require.NoError(t, db.CreateProbe(p))
+
+	base := time.UnixMilli(1700000000000)
+	for i := 0; i < 5; i++ {
+		r := &ProbeResult{ProbeID: p.ID, Timestamp: base.Add(time.Duration(i) * time.Minute), Up: i != 3, LatencyMs: float64(i) + 0.5}
+		if !r.Up {
+			r.Error = "connection refused"
+			r.Detail = `{"attempts":1}`
+		}
+		require.NoError(t, db.InsertProbeResult(r))
+	}
+
+	results, err := db.ListProbeResults(p.ID, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, base.Add(2*time.Minute), results[0].Timestamp, "latest results, oldest first")
+	assert.False(t, results[1].Up)
+	assert.Equal(t, "connection refused", results[1].Error)
+	assert.Equal(t, `{"attempts":1}`, results[1].Detail)
+	assert.Equal(t, 4.5, results[2].LatencyMs)
+
+	n, err := db.PruneProbeResults(base.Add(2 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	require.NoError(t, db.DeleteProbe(p.ID))
+	results, err = db.ListProbeResults(p.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, results, "results are deleted with the probe")
+}
@@ -43,8 +43,11 @@ CREATE TABLE IF NOT EXISTS AlertConfig (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	name TEXT NOT NULL,
 	metric TEXT NOT NULL,
+	target TEXT NOT NULL DEFAULT '',
+	mode TEXT NOT NULL DEFAULT 'threshold',
 	operator TEXT NOT NULL CHECK(operator IN ('>', '<', '>=', '<=', '==')),
 	threshold REAL NOT NULL,
+	hourly INTEGER NOT NULL DEFAULT 0,
 	severity TEXT NOT NULL CHECK(severity IN ('critical', 'warning', 'info')),
 	enabled INTEGER DEFAULT 1,
 	cooldown_minutes INTEGER DEFAULT 15,
@@ -71,8 +74,66 @@ CREATE TABLE IF NOT EXISTS ActionLog (
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	FOREIGN KEY (user_id) REFERENCES User(id)
 );
+
+-- resolution and ts are in seconds (unix time); resolution 0 holds raw samples
+CREATE TABLE IF NOT EXISTS MetricSample (
+	resolution INTEGER NOT NULL,
+	ts INTEGER NOT NULL,
+	series TEXT NOT NULL,
+	min REAL NOT NULL,
+	avg REAL NOT NULL,
+	max REAL NOT NULL,
+	PRIMARY KEY (resolution, series, ts)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_metric_sample_ts ON MetricSample (resolution, ts);
+
+CREATE TABLE IF NOT EXISTS Probe (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL CHECK(type IN ('http', 'tcp', 'dns', 'icmp', 'tls', 'cert')),
+	target TEXT NOT NULL,
+	interval_seconds INTEGER NOT NULL DEFAULT 60,
+	timeout_seconds INTEGER NOT NULL DEFAULT 10,
+	expect_status INTEGER NOT NULL DEFAULT 0,
+	expect TEXT NOT NULL DEFAULT '',
+	max_latency_ms INTEGER NOT NULL DEFAULT 0,
+	warn_days INTEGER NOT NULL DEFAULT 21,
+	crit_days INTEGER NOT NULL DEFAULT 7,
+	enabled INTEGER DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ts is in milliseconds (unix time)
+CREATE TABLE IF NOT EXISTS ProbeResult (
+	probe_id INTEGER NOT NULL,
+	ts INTEGER NOT NULL,
+	up INTEGER NOT NULL,
+	latency_ms REAL NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (probe_id, ts),
+	FOREIGN KEY (probe_id) REFERENCES Probe(id)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_probe_result_ts ON ProbeResult (ts);
 `
 
+// columnMigrations add columns introduced after a table was first released.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so databases
+// created by older versions get the columns here.
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"AlertConfig", "target", "TEXT NOT NULL DEFAULT ''"},
+	{"AlertConfig", "mode", "TEXT NOT NULL DEFAULT 'threshold'"},
+	{"AlertConfig", "hourly", "INTEGER NOT NULL DEFAULT 0"},
+	{"Probe", "warn_days", "INTEGER NOT NULL DEFAULT 21"},
+	{"Probe", "crit_days", "INTEGER NOT NULL DEFAULT 7"},
+	{"ProbeResult", "detail", "TEXT NOT NULL DEFAULT ''"},
+}
+
 type DB struct {
 	*sql.DB
 }
@@ -100,6 +161,11 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("cannot initialize schema: %w", err)
 	}
 
+	if err := migrateColumns(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	// Integrity check
 	var result string
 	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
@@ -113,3 +179,44 @@ func New(dbPath string) (*DB, error) {
 
 	return &DB{db}, nil
 }
+
+func migrateColumns(db *sql.DB) error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("cannot add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("cannot inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name, typ  string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return false, fmt.Errorf("cannot scan table info for %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"path/filepath"
 	"testing"
 
@@ -38,7 +39,7 @@ func TestNew_TablesExist(t *testing.T) {
 	require.NoError(t, err)
 	defer db.Close()
 
-	expectedTables := []string{"User", "Session", "Alert", "AlertConfig", "ActionLog"}
+	expectedTables := []string{"User", "Session", "Alert", "AlertConfig", "ActionLog", "MetricSample"}
 
 	for _, table := range expectedTables {
 		var name string
@@ -93,6 +94,42 @@ func TestNew_IdempotentSchema(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNew_MigratesOldAlertConfig(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Simulate a database created before AlertConfig.target existed
+	raw, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = raw.Exec(`CREATE TABLE AlertConfig (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		operator TEXT NOT NULL,
+		threshold REAL NOT NULL,
+		severity TEXT NOT NULL,
+		enabled INTEGER DEFAULT 1,
+		cooldown_minutes INTEGER DEFAULT 15,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	require.NoError(t, err)
+	_, err = raw.Exec(`INSERT INTO AlertConfig (name, metric, operator, threshold, severity) VALUES ('Old', 'cpu', '>', 90, 'critical')`)
+	require.NoError(t, err)
+	raw.Close()
+
+	db, err := New(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	configs, err := db.ListAlertConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "Old", configs[0].Name)
+	assert.Equal(t, "", configs[0].Target)
+	assert.Equal(t, AlertModeThreshold, configs[0].Mode)
+	assert.False(t, configs[0].Hourly)
+}
+
 func TestDB_Close(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "test.db")
 
@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Container lifecycle actions accepted by ContainerAction.
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+	ActionPause   = "pause"
+	ActionUnpause = "unpause"
+	ActionKill    = "kill"
+)
+
+// ErrUnknownAction is returned by ContainerAction for an unsupported action.
+var ErrUnknownAction = errors.New("unknown container action")
+
+// IsAction reports whether action is a supported container action.
+func IsAction(action string) bool {
+	switch action {
+	case ActionStart, ActionStop, ActionRestart, ActionPause, ActionUnpause, ActionKill:
+		return true
+	}
+	return false
+}
+
+// IsDestructive reports whether an action stops the processes of a container,
+// so that it should be confirmed before it is taken.
+func IsDestructive(action string) bool {
+	return action == ActionStop || action == ActionRestart || action == ActionKill
+}
+
+// ContainerAction starts, stops, restarts, pauses, unpauses or kills a
+// container. Stop and restart give the container its configured grace period;
+// kill sends SIGKILL. The cached state follows through the events stream.
+func (m *Monitor) ContainerAction(ctx context.Context, id, action string) error {
+	if !IsAction(action) {
+		return fmt.Errorf("%w: %q", ErrUnknownAction, action)
+	}
+	client := m.currentClient()
+	if client == nil {
+		return fmt.Errorf("docker not available")
+	}
+
+	var err error
+	switch action {
+	case ActionStart:
+		err = client.ContainerStart(ctx, id, container.StartOptions{})
+	case ActionStop:
+		err = client.ContainerStop(ctx, id, container.StopOptions{})
+	case ActionRestart:
+		err = client.ContainerRestart(ctx, id, container.StopOptions{})
+	case ActionPause:
+		err = client.ContainerPause(ctx, id)
+	case ActionUnpause:
+		err = client.ContainerUnpause(ctx, id)
+	case ActionKill:
+		err = client.ContainerKill(ctx, id, "SIGKILL")
+	}
+	if err != nil {
+		return fmt.Errorf("%s container %s: %w", action, id, err)
+	}
+
+	// Events update the cache too; a refresh also covers a dropped stream.
+	m.requestRefresh()
+	return nil
+}
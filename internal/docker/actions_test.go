@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerAction(t *testing.T) {
+	mock := &mockDockerClient{}
+	m := newMonitorWithClient(mock)
+
+	for _, action := range []string{ActionStart, ActionStop, ActionRestart, ActionPause, ActionUnpause, ActionKill} {
+		require.NoError(t, m.ContainerAction(context.Background(), "abc", action))
+	}
+	assert.Equal(t, []string{"start abc", "stop abc", "restart abc", "pause abc", "unpause abc", "kill abc SIGKILL"}, mock.actions)
+
+	select {
+	case <-m.refreshCh:
+	default:
+		t.Fatal("expected a refresh request")
+	}
+}
+
+func TestContainerAction_Errors(t *testing.T) {
+	mock := &mockDockerClient{actionErr: errors.New("No such container: abc")}
+	m := newMonitorWithClient(mock)
+
+	err := m.ContainerAction(context.Background(), "abc", "remove")
+	assert.ErrorIs(t, err, ErrUnknownAction)
+	assert.Empty(t, mock.actions)
+
+	err = m.ContainerAction(context.Background(), "abc", ActionStop)
+	assert.EqualError(t, err, "stop container abc: No such container: abc")
+
+	err = newMonitorWithClient(nil).ContainerAction(context.Background(), "abc", ActionStart)
+	assert.EqualError(t, err, "docker not available")
+}
+
+func TestIsDestructive(t *testing.T) {
+	assert.True(t, IsDestructive(ActionStop))
+	assert.True(t, IsDestructive(ActionRestart))
+	assert.True(t, IsDestructive(ActionKill))
+	assert.False(t, IsDestructive(ActionStart))
+	assert.False(t, IsDestructive(ActionPause))
+	assert.False(t, IsDestructive(ActionUnpause))
+	assert.False(t, IsAction("rm"))
+}
+
+func TestMonitor_ContainerDetail_NameAndState(t *testing.T) {
+	mock := &mockDockerClient{inspectResult: types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			Name:  "/web-app",
+			State: &types.ContainerState{Status: "paused"},
+		},
+		Config: &container.Config{},
+	}}
+	m := newMonitorWithClient(mock)
+
+	detail, err := m.ContainerDetail(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "web-app", detail.Name)
+	assert.Equal(t, "paused", detail.State)
+}
@@ -0,0 +1,238 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const (
+	// eventBacklog is how far back the first subscription replays events, so
+	// that timelines are not empty after a restart.
+	eventBacklog = time.Hour
+	// eventRetryDelay is the wait before resubscribing after the stream ends.
+	eventRetryDelay = 5 * time.Second
+	// maxEvents bounds the event log read by EventsSince.
+	maxEvents = 256
+	// timelineSize is the number of events kept per container.
+	timelineSize = 20
+)
+
+// watchedActions are the container events subscribed to. health_status
+// matches every "health_status: <status>" action.
+var watchedActions = []events.Action{
+	events.ActionCreate, events.ActionStart, events.ActionRestart, events.ActionStop,
+	events.ActionKill, events.ActionDie, events.ActionOOM, events.ActionPause,
+	events.ActionUnPause, events.ActionDestroy, events.ActionHealthStatus,
+}
+
+// EventsSince returns the events received after seq, oldest first, and the
+// sequence number of the latest event. Only the last maxEvents are kept.
+func (m *Monitor) EventsSince(seq uint64) ([]Event, uint64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []Event
+	for _, ev := range m.events {
+		if ev.Seq > seq {
+			result = append(result, ev)
+		}
+	}
+	return result, m.seq
+}
+
+// Timeline returns the recent events of a container, newest first.
+func (m *Monitor) Timeline(id string) []Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	timeline := m.timelines[id]
+	result := make([]Event, len(timeline))
+	for i, ev := range timeline {
+		result[len(timeline)-1-i] = ev
+	}
+	return result
+}
+
+// watchEvents keeps a subscription to the Docker events stream open,
+// resubscribing after errors. Events missed while disconnected are replayed
+// from the time of the last one received; a refresh brings the cached state
+// up to date, so replayed events only fill in timelines.
+func (m *Monitor) watchEvents(ctx context.Context) {
+	since := time.Now().Add(-eventBacklog)
+	reconnect := false
+
+	for {
+		if client := m.currentClient(); client != nil {
+			if reconnect {
+				m.requestRefresh()
+			}
+			since = m.streamEvents(ctx, client, since)
+			reconnect = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventRetryDelay):
+		}
+	}
+}
+
+// streamEvents applies events until the stream ends and returns the time to
+// resume from. Events from before the subscription are replayed.
+func (m *Monitor) streamEvents(ctx context.Context, client DockerClient, since time.Time) time.Time {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	subscribed := time.Now()
+
+	args := filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)))
+	for _, action := range watchedActions {
+		args.Add("event", string(action))
+	}
+	msgs, errs := client.Events(ctx, events.ListOptions{
+		Since:   fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()),
+		Filters: args,
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return since
+		case err := <-errs:
+			if ctx.Err() == nil {
+				log.Printf("docker: event stream ended: %v", err)
+			}
+			return since
+		case msg := <-msgs:
+			ev := m.applyEvent(msg, eventTime(msg).Before(subscribed))
+			since = ev.Time.Add(time.Nanosecond)
+		}
+	}
+}
+
+// applyEvent records an event and updates the cached container at once,
+// without waiting for the next poll. Containers not yet in the cache are
+// picked up by an immediate refresh. A replayed event is older than the
+// cached state and is only added to the container's timeline.
+func (m *Monitor) applyEvent(msg events.Message, replayed bool) Event {
+	ev := Event{
+		Time:        eventTime(msg),
+		ContainerID: msg.Actor.ID,
+		Name:        msg.Actor.Attributes["name"],
+		Action:      string(msg.Action),
+	}
+	switch msg.Action {
+	case events.ActionDie:
+		if code, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+			ev.ExitCode = &code
+		}
+	case events.ActionKill:
+		ev.Signal = msg.Actor.Attributes["signal"]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !replayed {
+		m.seq++
+		ev.Seq = m.seq
+		m.events = append(m.events, ev)
+		if len(m.events) > maxEvents {
+			m.events = m.events[len(m.events)-maxEvents:]
+		}
+	}
+
+	if msg.Action == events.ActionDestroy {
+		delete(m.timelines, ev.ContainerID)
+		if replayed {
+			return ev
+		}
+		for i, c := range m.containers {
+			if c.ID == ev.ContainerID {
+				m.containers = append(m.containers[:i:i], m.containers[i+1:]...)
+				break
+			}
+		}
+		return ev
+	}
+
+	if m.timelines == nil {
+		m.timelines = make(map[string][]Event)
+	}
+	timeline := append(m.timelines[ev.ContainerID], ev)
+	if len(timeline) > timelineSize {
+		timeline = timeline[len(timeline)-timelineSize:]
+	}
+	m.timelines[ev.ContainerID] = timeline
+	if replayed {
+		return ev
+	}
+
+	for i := range m.containers {
+		if m.containers[i].ID == ev.ContainerID {
+			applyEventState(&m.containers[i], ev)
+			return ev
+		}
+	}
+	m.requestRefresh()
+	return ev
+}
+
+// applyEventState moves a cached container to the state an event implies.
+// The status text mimics the one Docker reports until the next poll.
+func applyEventState(c *ContainerInfo, ev Event) {
+	switch action := events.Action(ev.Action); {
+	case action == events.ActionStart || action == events.ActionRestart:
+		// Docker clears the last exit on start and rechecks health from scratch.
+		c.State, c.Status = "running", "Up Less than a second"
+		c.ExitCode, c.OOMKilled = 0, false
+		if c.HealthCheck != "" {
+			c.HealthCheck = "starting"
+		}
+	case action == events.ActionUnPause:
+		c.State, c.Status = "running", "Up Less than a second"
+	case action == events.ActionPause:
+		c.State, c.Status = "paused", "Up (Paused)"
+	case action == events.ActionOOM:
+		c.OOMKilled = true
+	case action == events.ActionDie:
+		c.ExitCode = 0
+		if ev.ExitCode != nil {
+			c.ExitCode = *ev.ExitCode
+		}
+		c.State = "exited"
+		c.Status = fmt.Sprintf("Exited (%d) Less than a second ago", c.ExitCode)
+		c.CPUPercent, c.MemUsage, c.MemPercent = 0, 0, 0
+	case strings.HasPrefix(ev.Action, string(events.ActionHealthStatus)+": "):
+		c.HealthCheck = strings.TrimPrefix(ev.Action, string(events.ActionHealthStatus)+": ")
+	default:
+		return
+	}
+	c.Health = MapHealthStatus(*c)
+}
+
+// eventTime returns the time of an event with nanosecond precision when the
+// daemon reports it.
+func eventTime(msg events.Message) time.Time {
+	if msg.TimeNano != 0 {
+		return time.Unix(0, msg.TimeNano)
+	}
+	return time.Unix(msg.Time, 0)
+}
+
+// requestRefresh asks the run loop for an immediate refresh. Requests made
+// while one is pending are merged.
+func (m *Monitor) requestRefresh() {
+	select {
+	case m.refreshCh <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,184 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func containerEvent(action events.Action, id string, attrs map[string]string) events.Message {
+	return events.Message{
+		Type:     events.ContainerEventType,
+		Action:   action,
+		Actor:    events.Actor{ID: id, Attributes: attrs},
+		TimeNano: time.Now().UnixNano(),
+	}
+}
+
+func TestApplyEvent_UpdatesCachedState(t *testing.T) {
+	mock := &mockDockerClient{containers: sampleContainers(), statsJSON: sampleStats()}
+	m := newMonitorWithClient(mock)
+	m.refresh(context.Background())
+	web := sampleContainers()[0].ID
+
+	m.applyEvent(containerEvent(events.ActionDie, web, map[string]string{"name": "web-app", "exitCode": "137"}), false)
+	c := m.Containers()[0]
+	assert.Equal(t, "exited", c.State)
+	assert.Equal(t, HealthError, c.Health)
+	assert.Equal(t, "Exited (137) Less than a second ago", c.Status)
+	assert.Zero(t, c.CPUPercent)
+
+	m.applyEvent(containerEvent(events.ActionStart, web, map[string]string{"name": "web-app"}), false)
+	assert.Equal(t, HealthRunning, m.Containers()[0].Health)
+
+	m.applyEvent(containerEvent(events.ActionPause, web, map[string]string{"name": "web-app"}), false)
+	assert.Equal(t, "paused", m.Containers()[0].State)
+
+	m.applyEvent(containerEvent(events.ActionDestroy, web, map[string]string{"name": "web-app"}), false)
+	assert.Len(t, m.Containers(), 2)
+	assert.Empty(t, m.Timeline(web))
+}
+
+func TestApplyEvent_HealthAndOOM(t *testing.T) {
+	c := ContainerInfo{State: "running", HealthCheck: "healthy", Health: HealthRunning}
+
+	applyEventState(&c, Event{Action: "health_status: unhealthy"})
+	assert.Equal(t, "unhealthy", c.HealthCheck)
+	assert.Equal(t, HealthUnhealthy, c.Health)
+
+	code := 137
+	applyEventState(&c, Event{Action: "oom"})
+	applyEventState(&c, Event{Action: "die", ExitCode: &code})
+	assert.True(t, c.OOMKilled)
+	assert.Equal(t, 137, c.ExitCode)
+	assert.Equal(t, HealthOOM, c.Health)
+
+	applyEventState(&c, Event{Action: "start"})
+	assert.False(t, c.OOMKilled)
+	assert.Zero(t, c.ExitCode)
+	assert.Equal(t, "starting", c.HealthCheck, "health is rechecked after a start")
+	assert.Equal(t, HealthRunning, c.Health)
+}
+
+func TestApplyEvent_UnknownContainerRequestsRefresh(t *testing.T) {
+	m := newMonitorWithClient(&mockDockerClient{})
+
+	m.applyEvent(containerEvent(events.ActionStart, "new123", map[string]string{"name": "new"}), false)
+	m.applyEvent(containerEvent(events.ActionHealthStatusHealthy, "new123", map[string]string{"name": "new"}), false)
+
+	select {
+	case <-m.refreshCh:
+	default:
+		t.Fatal("expected a refresh request")
+	}
+	assert.Len(t, m.Timeline("new123"), 2)
+}
+
+func TestEventsSinceAndTimeline(t *testing.T) {
+	m := newMonitorWithClient(&mockDockerClient{})
+
+	m.applyEvent(containerEvent(events.ActionKill, "a", map[string]string{"name": "a", "signal": "15"}), false)
+	m.applyEvent(containerEvent(events.ActionDie, "a", map[string]string{"name": "a", "exitCode": "0"}), false)
+	m.applyEvent(containerEvent(events.ActionStart, "b", map[string]string{"name": "b"}), false)
+
+	all, last := m.EventsSince(0)
+	require.Len(t, all, 3)
+	assert.Equal(t, uint64(3), last)
+	assert.Equal(t, "15", all[0].Signal)
+	require.NotNil(t, all[1].ExitCode)
+	assert.Equal(t, 0, *all[1].ExitCode)
+
+	newer, _ := m.EventsSince(2)
+	require.Len(t, newer, 1)
+	assert.Equal(t, "b", newer[0].Name)
+
+	timeline := m.Timeline("a")
+	require.Len(t, timeline, 2)
+	assert.Equal(t, "die", timeline[0].Action, "newest first")
+
+	for i := 0; i < maxEvents+timelineSize; i++ {
+		m.applyEvent(containerEvent(events.ActionRestart, "a", map[string]string{"name": "a"}), false)
+	}
+	all, _ = m.EventsSince(0)
+	assert.Len(t, all, maxEvents)
+	assert.Len(t, m.Timeline("a"), timelineSize)
+}
+
+func TestRefresh_PrunesTimelinesOfRemovedContainers(t *testing.T) {
+	mock := &mockDockerClient{containers: sampleContainers(), statsJSON: sampleStats()}
+	m := newMonitorWithClient(mock)
+	m.applyEvent(containerEvent(events.ActionStart, sampleContainers()[0].ID, nil), false)
+	m.applyEvent(containerEvent(events.ActionStart, "gone", nil), false)
+
+	m.refresh(context.Background())
+	assert.Len(t, m.Timeline(sampleContainers()[0].ID), 1)
+	assert.Empty(t, m.Timeline("gone"))
+}
+
+func TestStreamEvents_ResumesAfterLastEvent(t *testing.T) {
+	mock := &mockDockerClient{
+		eventMsgs: make(chan events.Message),
+		eventErrs: make(chan error, 1),
+	}
+	m := newMonitorWithClient(mock)
+	start := time.Now().Add(-time.Hour)
+
+	done := make(chan time.Time)
+	go func() { done <- m.streamEvents(context.Background(), mock, start) }()
+
+	old := containerEvent(events.ActionStart, "a", map[string]string{"name": "a"})
+	old.TimeNano = start.Add(time.Minute).UnixNano()
+	msg := containerEvent(events.ActionDie, "a", map[string]string{"name": "a", "exitCode": "1"})
+	msg.TimeNano = time.Now().Add(time.Minute).UnixNano()
+	mock.eventMsgs <- old
+	mock.eventMsgs <- msg
+	mock.eventErrs <- errors.New("unexpected EOF")
+
+	resume := <-done
+	assert.Equal(t, time.Unix(0, msg.TimeNano).Add(time.Nanosecond), resume)
+	require.Len(t, mock.eventOpts, 1)
+	assert.Equal(t, []string{"container"}, mock.eventOpts[0].Filters.Get("type"))
+	assert.Contains(t, mock.eventOpts[0].Filters.Get("event"), "health_status")
+
+	evs, _ := m.EventsSince(0)
+	require.Len(t, evs, 1, "events from before the subscription are replayed")
+	assert.Equal(t, "die", evs[0].Action)
+	assert.Len(t, m.Timeline("a"), 2)
+}
+
+func TestApplyEvent_ReplayedOnlyFillsTimeline(t *testing.T) {
+	mock := &mockDockerClient{containers: sampleContainers(), statsJSON: sampleStats()}
+	m := newMonitorWithClient(mock)
+	m.refresh(context.Background())
+	web := sampleContainers()[0].ID
+	before := m.Containers()[0]
+
+	m.applyEvent(containerEvent(events.ActionDie, web, map[string]string{"name": "web-app", "exitCode": "1"}), true)
+	m.applyEvent(containerEvent(events.ActionStart, "gone", map[string]string{"name": "gone"}), true)
+
+	assert.Equal(t, before, m.Containers()[0], "a replayed event does not override the polled state")
+	evs, _ := m.EventsSince(0)
+	assert.Empty(t, evs)
+	assert.Len(t, m.Timeline(web), 1)
+	select {
+	case <-m.refreshCh:
+		t.Fatal("a replayed event of an unknown container requests no refresh")
+	default:
+	}
+}
+
+func TestMonitor_ContainerDetail_Events(t *testing.T) {
+	mock := &mockDockerClient{}
+	m := newMonitorWithClient(mock)
+	m.applyEvent(containerEvent(events.ActionOOM, "abc", map[string]string{"name": "web"}), false)
+
+	detail, err := m.ContainerDetail(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, detail.Events, 1)
+	assert.Equal(t, "oom", detail.Events[0].Action)
+}
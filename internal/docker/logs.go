@@ -0,0 +1,190 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Stream IDs in the header of multiplexed log frames (see stdcopy).
+const (
+	frameStdout    = 1
+	frameStderr    = 2
+	frameSystemErr = 3
+)
+
+// Limits on what a log stream may make us hold in memory. A frame larger
+// than maxFrameSize means a corrupt or hostile stream, and a line longer
+// than maxLineLength is passed on in pieces of that length.
+const (
+	maxFrameSize  = 1 << 20
+	maxLineLength = 64 << 10
+)
+
+// Logs reads the log of a container and calls fn for each line, in order,
+// until the log ends, the context is done or fn returns an error. Containers
+// without a TTY send stdout and stderr multiplexed into frames, which are
+// split back into lines per stream.
+func (m *Monitor) Logs(ctx context.Context, id string, opts LogOptions, fn func(LogLine) error) error {
+	client := m.currentClient()
+	if client == nil {
+		return fmt.Errorf("docker not available")
+	}
+
+	inspect, err := client.ContainerInspect(ctx, id)
+	if err != nil {
+		return fmt.Errorf("inspect container %s: %w", id, err)
+	}
+	tty := inspect.Config != nil && inspect.Config.Tty
+
+	rc, err := client.ContainerLogs(ctx, id, logsOptions(opts))
+	if err != nil {
+		return fmt.Errorf("logs of container %s: %w", id, err)
+	}
+	defer rc.Close()
+
+	if tty {
+		err = readLines(rc, fn)
+	} else {
+		err = demuxLines(rc, fn)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// logsOptions converts LogOptions to the SDK options. Timestamps are always
+// requested so that every line carries its time.
+func logsOptions(opts LogOptions) container.LogsOptions {
+	o := container.LogsOptions{
+		ShowStdout: opts.Stdout,
+		ShowStderr: opts.Stderr,
+		Follow:     opts.Follow,
+		Timestamps: true,
+		Tail:       "all",
+	}
+	if opts.Tail > 0 {
+		o.Tail = strconv.Itoa(opts.Tail)
+	}
+	if !opts.Since.IsZero() {
+		o.Since = unixTimestamp(opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		o.Until = unixTimestamp(opts.Until)
+	}
+	return o
+}
+
+func unixTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// readLines reads the raw output of a TTY container, which is all stdout.
+func readLines(r io.Reader, fn func(LogLine) error) error {
+	br := bufio.NewReaderSize(r, maxLineLength)
+	for {
+		line, err := br.ReadSlice('\n')
+		if len(line) > 0 {
+			if ferr := fn(parseLogLine("stdout", string(line))); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+}
+
+// demuxLines splits multiplexed frames into lines. A frame holds part of a
+// stream and need not end at a line break, so partial lines are kept per
+// stream until the rest arrives, up to maxLineLength.
+func demuxLines(r io.Reader, fn func(LogLine) error) error {
+	var header [8]byte
+	var pending [frameSystemErr + 1][]byte
+
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[4:])
+		if size > maxFrameSize {
+			return fmt.Errorf("docker: log frame of %d bytes exceeds %d", size, maxFrameSize)
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return err
+		}
+
+		stream := header[0]
+		switch stream {
+		case frameStdout, frameStderr:
+		case frameSystemErr:
+			return fmt.Errorf("docker: %s", strings.TrimSpace(string(frame)))
+		default:
+			continue // stdin is never sent
+		}
+
+		buf := append(pending[stream], frame...)
+		for {
+			i := bytes.IndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			if err := fn(parseLogLine(streamName(stream), string(buf[:i]))); err != nil {
+				return err
+			}
+			buf = buf[i+1:]
+		}
+		for len(buf) > maxLineLength {
+			if err := fn(parseLogLine(streamName(stream), string(buf[:maxLineLength]))); err != nil {
+				return err
+			}
+			buf = buf[maxLineLength:]
+		}
+		pending[stream] = append([]byte(nil), buf...)
+	}
+
+	for _, stream := range []byte{frameStdout, frameStderr} {
+		if len(pending[stream]) > 0 {
+			if err := fn(parseLogLine(streamName(stream), string(pending[stream]))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func streamName(stream byte) string {
+	if stream == frameStderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// parseLogLine splits the timestamp Docker puts before each line from the text.
+func parseLogLine(stream, raw string) LogLine {
+	raw = strings.TrimRight(raw, "\r\n")
+	line := LogLine{Stream: stream, Text: raw}
+	if ts, text, ok := strings.Cut(raw, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			line.Time, line.Text = t, text
+		}
+	}
+	return line
+}
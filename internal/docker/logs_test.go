@@ -0,0 +1,153 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frame encodes one multiplexed log frame.
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func collectLogs(t *testing.T, m *Monitor, opts LogOptions) ([]LogLine, error) {
+	t.Helper()
+	var lines []LogLine
+	err := m.Logs(context.Background(), "abc", opts, func(l LogLine) error {
+		lines = append(lines, l)
+		return nil
+	})
+	return lines, err
+}
+
+func inspectTTY(tty bool) types.ContainerJSON {
+	return types.ContainerJSON{Config: &container.Config{Tty: tty}}
+}
+
+func TestLogs_Demultiplexes(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(frame(frameStdout, "2026-03-04T10:15:00.123456789Z listening on :8080\n2026-03-04T10:15:01Z GET /hea"))
+	stream.Write(frame(frameStderr, "2026-03-04T10:15:01.5Z warn: slow query\n"))
+	stream.Write(frame(frameStdout, "lth 200\n2026-03-04T10:15:02Z shutting down"))
+
+	m := newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: stream.Bytes()})
+	lines, err := collectLogs(t, m, LogOptions{Stdout: true, Stderr: true})
+	require.NoError(t, err)
+	require.Len(t, lines, 4)
+
+	assert.Equal(t, LogLine{Stream: "stdout", Time: time.Date(2026, 3, 4, 10, 15, 0, 123456789, time.UTC), Text: "listening on :8080"}, lines[0])
+	assert.Equal(t, "stderr", lines[1].Stream)
+	assert.Equal(t, "warn: slow query", lines[1].Text)
+	assert.Equal(t, "GET /health 200", lines[2].Text, "a line split across frames is joined")
+	assert.Equal(t, "shutting down", lines[3].Text, "a final line without a newline is kept")
+}
+
+func TestLogs_TTY(t *testing.T) {
+	m := newMonitorWithClient(&mockDockerClient{
+		inspectResult: inspectTTY(true),
+		logs:          []byte("2026-03-04T10:15:00Z \x1b[32mready\x1b[0m\r\n2026-03-04T10:15:01Z done\r\n"),
+	})
+	lines, err := collectLogs(t, m, LogOptions{Stdout: true})
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "stdout", lines[0].Stream)
+	assert.Equal(t, "\x1b[32mready\x1b[0m", lines[0].Text)
+	assert.Equal(t, "done", lines[1].Text)
+}
+
+func TestLogs_Options(t *testing.T) {
+	mock := &mockDockerClient{inspectResult: inspectTTY(false)}
+	m := newMonitorWithClient(mock)
+	since := time.Unix(1772618100, 500)
+
+	_, err := collectLogs(t, m, LogOptions{Tail: 200, Since: since, Stderr: true, Follow: true})
+	require.NoError(t, err)
+	_, err = collectLogs(t, m, LogOptions{Stdout: true, Until: since})
+	require.NoError(t, err)
+
+	require.Len(t, mock.logsOpts, 2)
+	assert.Equal(t, container.LogsOptions{ShowStderr: true, Follow: true, Timestamps: true, Tail: "200", Since: "1772618100.000000500"}, mock.logsOpts[0])
+	assert.Equal(t, container.LogsOptions{ShowStdout: true, Timestamps: true, Tail: "all", Until: "1772618100.000000500"}, mock.logsOpts[1])
+}
+
+func TestLogs_Errors(t *testing.T) {
+	_, err := collectLogs(t, newMonitorWithClient(nil), LogOptions{Stdout: true})
+	assert.EqualError(t, err, "docker not available")
+
+	m := newMonitorWithClient(&mockDockerClient{inspectErr: errors.New("No such container: abc")})
+	_, err = collectLogs(t, m, LogOptions{Stdout: true})
+	assert.ErrorContains(t, err, "No such container")
+
+	m = newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: frame(frameSystemErr, "configured logging driver does not support reading\n")})
+	_, err = collectLogs(t, m, LogOptions{Stdout: true})
+	assert.EqualError(t, err, "docker: configured logging driver does not support reading")
+
+	m = newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: frame(frameStdout, "a\n")[:6]})
+	_, err = collectLogs(t, m, LogOptions{Stdout: true})
+	assert.Error(t, err, "a truncated header is an error")
+
+	header := make([]byte, 8)
+	header[0] = frameStdout
+	binary.BigEndian.PutUint32(header[4:], maxFrameSize+1)
+	m = newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: header})
+	_, err = collectLogs(t, m, LogOptions{Stdout: true})
+	assert.ErrorContains(t, err, "exceeds", "an oversized frame is rejected before it is allocated")
+}
+
+func TestLogs_LongLinesAreSplit(t *testing.T) {
+	long := strings.Repeat("x", maxLineLength+10)
+
+	var stream bytes.Buffer
+	for i := 0; i < len(long); i += 1000 {
+		stream.Write(frame(frameStdout, long[i:min(i+1000, len(long))]))
+	}
+	stream.Write(frame(frameStdout, "\nshort\n"))
+	m := newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: stream.Bytes()})
+	lines, err := collectLogs(t, m, LogOptions{Stdout: true})
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Len(t, lines[0].Text, maxLineLength)
+	assert.Equal(t, "xxxxxxxxxx", lines[1].Text)
+	assert.Equal(t, "short", lines[2].Text)
+
+	m = newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(true), logs: []byte(long + "\nshort\n")})
+	lines, err = collectLogs(t, m, LogOptions{Stdout: true})
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Len(t, lines[0].Text, maxLineLength)
+	assert.Equal(t, "xxxxxxxxxx", lines[1].Text)
+	assert.Equal(t, "short", lines[2].Text)
+}
+
+func TestLogs_CallbackStops(t *testing.T) {
+	var stream bytes.Buffer
+	for i := 0; i < 5; i++ {
+		stream.Write(frame(frameStdout, "line\n"))
+	}
+	m := newMonitorWithClient(&mockDockerClient{inspectResult: inspectTTY(false), logs: stream.Bytes()})
+
+	stop := errors.New("client gone")
+	n := 0
+	err := m.Logs(context.Background(), "abc", LogOptions{Stdout: true}, func(LogLine) error {
+		n++
+		if n == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 2, n)
+}
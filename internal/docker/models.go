@@ -6,33 +6,54 @@ import "time"
 type HealthStatus string
 
 const (
-	HealthRunning HealthStatus = "running" // green
-	HealthStopped HealthStatus = "stopped" // grey
-	HealthError   HealthStatus = "error"   // red
-	HealthPaused  HealthStatus = "paused"  // yellow
+	HealthRunning    HealthStatus = "running"    // green
+	HealthStopped    HealthStatus = "stopped"    // grey
+	HealthError      HealthStatus = "error"      // red
+	HealthPaused     HealthStatus = "paused"     // yellow
+	HealthUnhealthy  HealthStatus = "unhealthy"  // red: running, but failing its HEALTHCHECK
+	HealthRestarting HealthStatus = "restarting" // yellow: waiting to be restarted by its restart policy
+	HealthOOM        HealthStatus = "oom"        // red: killed for running out of memory
 )
 
 // ContainerInfo holds summary data for a single Docker container.
 type ContainerInfo struct {
-	ID         string       `json:"id"`
-	Name       string       `json:"name"`
-	Image      string       `json:"image"`
-	State      string       `json:"state"`
-	Status     string       `json:"status"`
-	Health     HealthStatus `json:"health"`
-	CreatedAt  time.Time    `json:"created_at"`
-	CPUPercent float64      `json:"cpu_percent"`
-	MemUsage   uint64       `json:"mem_usage"`
-	MemLimit   uint64       `json:"mem_limit"`
-	MemPercent float64      `json:"mem_percent"`
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Image        string       `json:"image"`
+	State        string       `json:"state"`
+	Status       string       `json:"status"`
+	Health       HealthStatus `json:"health"`
+	HealthCheck  string       `json:"health_check,omitempty"` // "starting", "healthy" or "unhealthy"; empty without a HEALTHCHECK
+	RestartCount int          `json:"restart_count"`          // restarts by the restart policy
+	OOMKilled    bool         `json:"oom_killed"`             // last exit was an OOM kill
+	ExitCode     int          `json:"exit_code"`              // of the last exit; reset on start
+	CreatedAt    time.Time    `json:"created_at"`
+	CPUPercent   float64      `json:"cpu_percent"`
+	MemUsage     uint64       `json:"mem_usage"`
+	MemLimit     uint64       `json:"mem_limit"`
+	MemPercent   float64      `json:"mem_percent"`
 }
 
 // ContainerDetail holds extended data for a single container.
 type ContainerDetail struct {
 	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	State       string        `json:"state"`
 	Ports       []PortMapping `json:"ports"`
 	Volumes     []VolumeMount `json:"volumes"`
 	EnvVarNames []string      `json:"env_var_names"`
+	Events      []Event       `json:"events"` // newest first
+}
+
+// Event is a container lifecycle event from the Docker events stream.
+type Event struct {
+	Seq         uint64    `json:"seq"` // increases by one per event received
+	Time        time.Time `json:"time"`
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Action      string    `json:"action"`              // e.g. "start", "die", "oom", "health_status: unhealthy"
+	ExitCode    *int      `json:"exit_code,omitempty"` // die events only
+	Signal      string    `json:"signal,omitempty"`    // kill events only
 }
 
 // PortMapping describes a port mapping between host and container.
@@ -49,15 +70,24 @@ type VolumeMount struct {
 	Mode        string `json:"mode"`
 }
 
-// MapHealthStatus maps Docker container state + exit code to a HealthStatus.
-func MapHealthStatus(state string, exitCode int) HealthStatus {
-	switch state {
+// MapHealthStatus maps a container's state, HEALTHCHECK status, OOM kill and
+// exit code to a HealthStatus.
+func MapHealthStatus(c ContainerInfo) HealthStatus {
+	switch c.State {
 	case "running":
+		if c.HealthCheck == "unhealthy" {
+			return HealthUnhealthy
+		}
 		return HealthRunning
+	case "restarting":
+		return HealthRestarting
 	case "created", "paused":
 		return HealthPaused
 	case "exited", "dead":
-		if exitCode != 0 {
+		if c.OOMKilled {
+			return HealthOOM
+		}
+		if c.ExitCode != 0 {
 			return HealthError
 		}
 		return HealthStopped
@@ -65,3 +95,20 @@ func MapHealthStatus(state string, exitCode int) HealthStatus {
 		return HealthStopped
 	}
 }
+
+// LogOptions selects the container log lines returned by Monitor.Logs.
+type LogOptions struct {
+	Tail   int       // last N lines, 0 for all
+	Since  time.Time // zero for no lower bound
+	Until  time.Time // zero for no upper bound
+	Stdout bool
+	Stderr bool
+	Follow bool // keep streaming new lines until the context ends or the container stops
+}
+
+// LogLine is one line of container output.
+type LogLine struct {
+	Stream string    `json:"stream"` // "stdout" or "stderr"
+	Time   time.Time `json:"time"`
+	Text   string    `json:"text"`
+}
@@ -14,14 +14,32 @@ import (
 	dclient "github.com/docker/docker/client"
 )
 
+// refreshInterval is how often containers and their stats are polled. State
+// changes arrive sooner through the events stream; polling reconciles
+// whatever the stream missed.
 const refreshInterval = 10 * time.Second
 
-// Monitor periodically refreshes Docker container data.
+const (
+	// statsWorkers bounds the concurrent stats requests of a refresh. Each
+	// request makes the daemon sample twice about a second apart, so serial
+	// requests would make a refresh grow by a second or two per container.
+	statsWorkers = 8
+	// statsTimeout bounds a single stats request, e.g. of a hung container.
+	statsTimeout = 5 * time.Second
+)
+
+// Monitor keeps Docker container data up to date from the events stream and
+// periodic polling.
 type Monitor struct {
 	client     DockerClient
 	mu         sync.RWMutex
 	containers []ContainerInfo
 	available  bool
+	events     []Event            // last maxEvents events, oldest first
+	timelines  map[string][]Event // containerID -> last timelineSize events
+	seq        uint64
+	refreshCh  chan struct{}
+	statsLimit time.Duration // per-request stats timeout
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 }
@@ -29,7 +47,11 @@ type Monitor struct {
 // NewMonitor creates a Docker monitor. If Docker is not reachable, it logs a
 // warning and returns a monitor that reports Available() == false.
 func NewMonitor() *Monitor {
-	m := &Monitor{}
+	m := &Monitor{
+		timelines:  make(map[string][]Event),
+		refreshCh:  make(chan struct{}, 1),
+		statsLimit: statsTimeout,
+	}
 
 	cli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
 	if err != nil {
@@ -55,20 +77,28 @@ func NewMonitor() *Monitor {
 // newMonitorWithClient creates a monitor with an injected client (for testing).
 func newMonitorWithClient(client DockerClient) *Monitor {
 	return &Monitor{
-		client:    client,
-		available: client != nil,
+		client:     client,
+		available:  client != nil,
+		timelines:  make(map[string][]Event),
+		refreshCh:  make(chan struct{}, 1),
+		statsLimit: statsTimeout,
 	}
 }
 
-// Start begins periodic container refresh in a background goroutine.
+// Start begins periodic container refresh and event watching in background
+// goroutines.
 func (m *Monitor) Start(ctx context.Context) {
 	ctx, m.cancel = context.WithCancel(ctx)
 
-	m.wg.Add(1)
+	m.wg.Add(2)
 	go func() {
 		defer m.wg.Done()
 		m.run(ctx)
 	}()
+	go func() {
+		defer m.wg.Done()
+		m.watchEvents(ctx)
+	}()
 
 	log.Printf("Docker monitor started (interval=%v)", refreshInterval)
 }
@@ -103,16 +133,23 @@ func (m *Monitor) Containers() []ContainerInfo {
 
 // ContainerDetail fetches extended info for a single container on demand.
 func (m *Monitor) ContainerDetail(ctx context.Context, id string) (*ContainerDetail, error) {
-	if m.client == nil {
+	client := m.currentClient()
+	if client == nil {
 		return nil, fmt.Errorf("docker not available")
 	}
 
-	inspect, err := m.client.ContainerInspect(ctx, id)
+	inspect, err := client.ContainerInspect(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("inspect container %s: %w", id, err)
 	}
 
-	detail := &ContainerDetail{ID: id}
+	detail := &ContainerDetail{ID: id, Events: m.Timeline(id)}
+	if inspect.ContainerJSONBase != nil {
+		detail.Name = strings.TrimPrefix(inspect.Name, "/")
+		if inspect.State != nil {
+			detail.State = inspect.State.Status
+		}
+	}
 
 	// Ports
 	if inspect.NetworkSettings != nil {
@@ -159,12 +196,21 @@ func (m *Monitor) run(ctx context.Context) {
 			return
 		case <-ticker.C:
 			m.refresh(ctx)
+		case <-m.refreshCh:
+			m.refresh(ctx)
 		}
 	}
 }
 
+// currentClient returns the client, which refresh replaces on reconnect.
+func (m *Monitor) currentClient() DockerClient {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.client
+}
+
 func (m *Monitor) refresh(ctx context.Context) {
-	if m.client == nil {
+	if m.currentClient() == nil {
 		// Try to reconnect
 		cli, err := dclient.NewClientWithOpts(dclient.FromEnv, dclient.WithAPIVersionNegotiation())
 		if err != nil {
@@ -196,22 +242,30 @@ func (m *Monitor) refresh(ctx context.Context) {
 
 	infos := make([]ContainerInfo, 0, len(containers))
 	for _, c := range containers {
-		info := containerToInfo(c)
-
-		// Fetch stats only for running containers
-		if c.State == "running" {
-			m.fetchStats(ctx, c.ID, &info)
-		}
-
-		infos = append(infos, info)
+		infos = append(infos, containerToInfo(c))
 	}
+	m.fetchAllDetails(ctx, infos)
 
 	m.mu.Lock()
 	m.containers = infos
 	m.available = true
+	for id := range m.timelines {
+		if !hasContainer(infos, id) {
+			delete(m.timelines, id)
+		}
+	}
 	m.mu.Unlock()
 }
 
+func hasContainer(infos []ContainerInfo, id string) bool {
+	for _, c := range infos {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func containerToInfo(c types.Container) ContainerInfo {
 	name := ""
 	if len(c.Names) > 0 {
@@ -224,23 +278,21 @@ func containerToInfo(c types.Container) ContainerInfo {
 		}
 	}
 
-	exitCode := 0
-	// Docker reports exit code in State for stopped containers
-	// The Status string contains "Exited (1)" etc.
-	if c.State == "exited" || c.State == "dead" {
-		// Parse exit code from status text like "Exited (1) 5 minutes ago"
-		exitCode = parseExitCode(c.Status)
-	}
-
-	return ContainerInfo{
+	info := ContainerInfo{
 		ID:        c.ID,
 		Name:      name,
 		Image:     c.Image,
 		State:     c.State,
 		Status:    c.Status,
-		Health:    MapHealthStatus(c.State, exitCode),
 		CreatedAt: time.Unix(c.Created, 0),
 	}
+	// The list has no exit code; until inspect fills it in, parse it from
+	// the status text, like "Exited (1) 5 minutes ago".
+	if c.State == "exited" || c.State == "dead" {
+		info.ExitCode = parseExitCode(c.Status)
+	}
+	info.Health = MapHealthStatus(info)
+	return info
 }
 
 // parseExitCode extracts exit code from Docker status string like "Exited (1) 5 minutes ago".
@@ -250,22 +302,70 @@ func parseExitCode(status string) int {
 	return code
 }
 
-func (m *Monitor) fetchStats(ctx context.Context, id string, info *ContainerInfo) {
-	shortID := id
-	if len(shortID) > 12 {
-		shortID = shortID[:12]
+// fetchAllDetails fills in the inspect data of every container and the stats
+// of the running ones, with up to statsWorkers containers in flight. A
+// refresh then takes about as long as its slowest requests rather than the
+// sum of all of them.
+func (m *Monitor) fetchAllDetails(ctx context.Context, infos []ContainerInfo) {
+	sem := make(chan struct{}, statsWorkers)
+	var wg sync.WaitGroup
+
+	for i := range infos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(info *ContainerInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.fetchState(ctx, info)
+			// Fetch stats only for running containers
+			if info.State == "running" {
+				m.fetchStats(ctx, info.ID, info)
+			}
+		}(&infos[i])
+	}
+	wg.Wait()
+}
+
+// fetchState fills in what the container list lacks: the HEALTHCHECK status,
+// restart count, OOM kill and exit code.
+func (m *Monitor) fetchState(ctx context.Context, info *ContainerInfo) {
+	ctx, cancel := context.WithTimeout(ctx, m.statsLimit)
+	defer cancel()
+
+	inspect, err := m.client.ContainerInspect(ctx, info.ID)
+	if err != nil {
+		log.Printf("docker: inspect error for %s: %v", shortID(info.ID), err)
+		return
 	}
+	if inspect.ContainerJSONBase == nil || inspect.State == nil {
+		return
+	}
+
+	info.RestartCount = inspect.RestartCount
+	info.OOMKilled = inspect.State.OOMKilled
+	info.ExitCode = inspect.State.ExitCode
+	if inspect.State.Health != nil {
+		info.HealthCheck = inspect.State.Health.Status
+	}
+	info.Health = MapHealthStatus(*info)
+}
+
+func (m *Monitor) fetchStats(ctx context.Context, id string, info *ContainerInfo) {
+	sid := shortID(id)
+
+	ctx, cancel := context.WithTimeout(ctx, m.statsLimit)
+	defer cancel()
 
 	statsResp, err := m.client.ContainerStats(ctx, id, false)
 	if err != nil {
-		log.Printf("docker: stats error for %s: %v", shortID, err)
+		log.Printf("docker: stats error for %s: %v", sid, err)
 		return
 	}
 	defer statsResp.Body.Close()
 
 	var stats container.StatsResponse
 	if err := json.NewDecoder(statsResp.Body).Decode(&stats); err != nil {
-		log.Printf("docker: stats decode error for %s: %v", shortID, err)
+		log.Printf("docker: stats decode error for %s: %v", sid, err)
 		return
 	}
 
@@ -277,6 +377,14 @@ func (m *Monitor) fetchStats(ctx context.Context, id string, info *ContainerInfo
 	}
 }
 
+// shortID returns the 12-character form of a container ID used in logs.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // calculateCPUPercent computes CPU usage percentage from Docker stats.
 func calculateCPUPercent(stats *container.StatsResponse) float64 {
 	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage - stats.PreCPUStats.CPUUsage.TotalUsage)
@@ -1,15 +1,19 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +30,23 @@ type mockDockerClient struct {
 	inspectErr    error
 	statsErr      error
 	pingErr       error
+	eventMsgs     chan events.Message // nil: a stream that stays open without events
+	eventErrs     chan error
+	eventOpts     []events.ListOptions
+
+	// Stats latency: every call takes statsDelay, calls for hangID block
+	// until their context ends. inFlight/maxInFlight count concurrent calls.
+	statsDelay  time.Duration
+	hangID      string
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+
+	actions   []string // lifecycle calls as "<method> <id>", e.g. "kill abc SIGKILL"
+	actionErr error
+
+	logs     []byte
+	logsErr  error
+	logsOpts []container.LogsOptions
 }
 
 func (m *mockDockerClient) Ping(_ context.Context) (types.Ping, error) {
@@ -36,7 +57,27 @@ func (m *mockDockerClient) ContainerList(_ context.Context, _ container.ListOpti
 	return m.containers, m.listErr
 }
 
-func (m *mockDockerClient) ContainerStats(_ context.Context, _ string, _ bool) (container.StatsResponseReader, error) {
+func (m *mockDockerClient) ContainerStats(ctx context.Context, id string, _ bool) (container.StatsResponseReader, error) {
+	n := m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+	for {
+		peak := m.maxInFlight.Load()
+		if n <= peak || m.maxInFlight.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	if id == m.hangID {
+		<-ctx.Done()
+		return container.StatsResponseReader{}, ctx.Err()
+	}
+	if m.statsDelay > 0 {
+		select {
+		case <-time.After(m.statsDelay):
+		case <-ctx.Done():
+			return container.StatsResponseReader{}, ctx.Err()
+		}
+	}
 	if m.statsErr != nil {
 		return container.StatsResponseReader{}, m.statsErr
 	}
@@ -50,6 +91,52 @@ func (m *mockDockerClient) ContainerInspect(_ context.Context, _ string) (types.
 	return m.inspectResult, m.inspectErr
 }
 
+func (m *mockDockerClient) Events(_ context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	m.eventOpts = append(m.eventOpts, options)
+	if m.eventMsgs == nil {
+		return make(chan events.Message), make(chan error)
+	}
+	return m.eventMsgs, m.eventErrs
+}
+
+func (m *mockDockerClient) ContainerStart(_ context.Context, id string, _ container.StartOptions) error {
+	m.actions = append(m.actions, "start "+id)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerStop(_ context.Context, id string, _ container.StopOptions) error {
+	m.actions = append(m.actions, "stop "+id)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerRestart(_ context.Context, id string, _ container.StopOptions) error {
+	m.actions = append(m.actions, "restart "+id)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerPause(_ context.Context, id string) error {
+	m.actions = append(m.actions, "pause "+id)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerUnpause(_ context.Context, id string) error {
+	m.actions = append(m.actions, "unpause "+id)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerKill(_ context.Context, id, signal string) error {
+	m.actions = append(m.actions, "kill "+id+" "+signal)
+	return m.actionErr
+}
+
+func (m *mockDockerClient) ContainerLogs(_ context.Context, _ string, options container.LogsOptions) (io.ReadCloser, error) {
+	m.logsOpts = append(m.logsOpts, options)
+	if m.logsErr != nil {
+		return nil, m.logsErr
+	}
+	return io.NopCloser(bytes.NewReader(m.logs)), nil
+}
+
 func (m *mockDockerClient) Close() error {
 	return nil
 }
@@ -112,31 +199,44 @@ func sampleStats() container.StatsResponse {
 // --- Tests: Health Status Mapping (AC3) ---
 
 func TestMapHealthStatus_Running(t *testing.T) {
-	assert.Equal(t, HealthRunning, MapHealthStatus("running", 0))
+	assert.Equal(t, HealthRunning, MapHealthStatus(ContainerInfo{State: "running"}))
 }
 
 func TestMapHealthStatus_ExitedClean(t *testing.T) {
-	assert.Equal(t, HealthStopped, MapHealthStatus("exited", 0))
+	assert.Equal(t, HealthStopped, MapHealthStatus(ContainerInfo{State: "exited"}))
 }
 
 func TestMapHealthStatus_ExitedError(t *testing.T) {
-	assert.Equal(t, HealthError, MapHealthStatus("exited", 1))
+	assert.Equal(t, HealthError, MapHealthStatus(ContainerInfo{State: "exited", ExitCode: 1}))
 }
 
 func TestMapHealthStatus_Dead(t *testing.T) {
-	assert.Equal(t, HealthError, MapHealthStatus("dead", 137))
+	assert.Equal(t, HealthError, MapHealthStatus(ContainerInfo{State: "dead", ExitCode: 137}))
 }
 
 func TestMapHealthStatus_Paused(t *testing.T) {
-	assert.Equal(t, HealthPaused, MapHealthStatus("paused", 0))
+	assert.Equal(t, HealthPaused, MapHealthStatus(ContainerInfo{State: "paused"}))
 }
 
 func TestMapHealthStatus_Created(t *testing.T) {
-	assert.Equal(t, HealthPaused, MapHealthStatus("created", 0))
+	assert.Equal(t, HealthPaused, MapHealthStatus(ContainerInfo{State: "created"}))
+}
+
+func TestMapHealthStatus_Unhealthy(t *testing.T) {
+	assert.Equal(t, HealthUnhealthy, MapHealthStatus(ContainerInfo{State: "running", HealthCheck: "unhealthy"}))
+	assert.Equal(t, HealthRunning, MapHealthStatus(ContainerInfo{State: "running", HealthCheck: "starting"}))
+}
+
+func TestMapHealthStatus_Restarting(t *testing.T) {
+	assert.Equal(t, HealthRestarting, MapHealthStatus(ContainerInfo{State: "restarting", ExitCode: 1}))
+}
+
+func TestMapHealthStatus_OOMKilled(t *testing.T) {
+	assert.Equal(t, HealthOOM, MapHealthStatus(ContainerInfo{State: "exited", ExitCode: 137, OOMKilled: true}))
 }
 
 func TestMapHealthStatus_Unknown(t *testing.T) {
-	assert.Equal(t, HealthStopped, MapHealthStatus("removing", 0))
+	assert.Equal(t, HealthStopped, MapHealthStatus(ContainerInfo{State: "removing"}))
 }
 
 // --- Tests: Container Listing (AC1) ---
@@ -242,6 +342,56 @@ func TestMonitor_NoStatsForStoppedContainer(t *testing.T) {
 	assert.Equal(t, uint64(0), containers[0].MemUsage)
 }
 
+func TestMonitor_InspectState(t *testing.T) {
+	mock := &mockDockerClient{
+		containers: []types.Container{
+			{ID: "abc123", Names: []string{"/web"}, State: "running", Status: "Up 2 minutes (unhealthy)"},
+		},
+		inspectResult: types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			RestartCount: 4,
+			State: &types.ContainerState{
+				Status:   "running",
+				Running:  true,
+				ExitCode: 0,
+				Health:   &types.Health{Status: types.Unhealthy},
+			},
+		}},
+		statsJSON: sampleStats(),
+	}
+	m := newMonitorWithClient(mock)
+	m.refresh(context.Background())
+
+	c := m.Containers()[0]
+	assert.Equal(t, "unhealthy", c.HealthCheck)
+	assert.Equal(t, 4, c.RestartCount)
+	assert.Equal(t, HealthUnhealthy, c.Health)
+	assert.Greater(t, c.CPUPercent, 0.0)
+
+	mock.containers[0].State, mock.containers[0].Status = "exited", "Exited (137) 3 seconds ago"
+	mock.inspectResult.State = &types.ContainerState{Status: "exited", ExitCode: 137, OOMKilled: true}
+	m.refresh(context.Background())
+
+	c = m.Containers()[0]
+	assert.True(t, c.OOMKilled)
+	assert.Equal(t, 137, c.ExitCode)
+	assert.Equal(t, HealthOOM, c.Health)
+}
+
+func TestMonitor_InspectError_KeepsListState(t *testing.T) {
+	mock := &mockDockerClient{
+		containers: []types.Container{
+			{ID: "abc123", Names: []string{"/job"}, State: "exited", Status: "Exited (2) 1 hour ago"},
+		},
+		inspectErr: assert.AnError,
+	}
+	m := newMonitorWithClient(mock)
+	m.refresh(context.Background())
+
+	c := m.Containers()[0]
+	assert.Equal(t, 2, c.ExitCode, "exit code parsed from the status text")
+	assert.Equal(t, HealthError, c.Health)
+}
+
 // --- Tests: Container Details (AC4) ---
 
 func TestMonitor_ContainerDetail_Ports(t *testing.T) {
@@ -469,6 +619,74 @@ func TestMonitor_ThreadSafety(t *testing.T) {
 	}
 }
 
+// --- Tests: Concurrent stats collection ---
+
+// runningContainers returns n running containers with distinct IDs.
+func runningContainers(n int) []types.Container {
+	containers := make([]types.Container, n)
+	for i := range containers {
+		containers[i] = types.Container{
+			ID:     fmt.Sprintf("%064d", i),
+			Names:  []string{fmt.Sprintf("/app-%d", i)},
+			Image:  "alpine:latest",
+			State:  "running",
+			Status: "Up",
+		}
+	}
+	return containers
+}
+
+// timedRefresh returns how long a refresh of n containers takes when every
+// stats call takes delay.
+func timedRefresh(n int, delay time.Duration) (time.Duration, *mockDockerClient, *Monitor) {
+	mock := &mockDockerClient{containers: runningContainers(n), statsJSON: sampleStats(), statsDelay: delay}
+	m := newMonitorWithClient(mock)
+	start := time.Now()
+	m.refresh(context.Background())
+	return time.Since(start), mock, m
+}
+
+func TestMonitor_StatsFetchedConcurrently(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	elapsed, mock, m := timedRefresh(20, delay)
+
+	// Serially this would take 20 x 100ms = 2s; 8 workers need 3 rounds.
+	assert.Less(t, elapsed, 8*delay)
+	assert.Equal(t, int32(statsWorkers), mock.maxInFlight.Load(), "requests are bounded by the worker pool")
+	for _, c := range m.Containers() {
+		assert.InDelta(t, 80.0, c.CPUPercent, 0.01, "every container gets its stats")
+	}
+}
+
+func TestMonitor_RefreshLatencyDoesNotGrowLinearly(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	small, _, _ := timedRefresh(statsWorkers, delay)
+	large, _, _ := timedRefresh(4*statsWorkers, delay)
+
+	// 4x the containers takes about 4 rounds: far below 4x serial time
+	// (1.6s), and bounded by rounds rather than by containers.
+	assert.Less(t, large, 4*statsWorkers*delay/2)
+	assert.Less(t, large, small+6*delay)
+}
+
+func TestMonitor_StatsTimeout(t *testing.T) {
+	containers := runningContainers(3)
+	mock := &mockDockerClient{containers: containers, statsJSON: sampleStats(), hangID: containers[1].ID}
+	m := newMonitorWithClient(mock)
+	m.statsLimit = 50 * time.Millisecond
+
+	start := time.Now()
+	m.refresh(context.Background())
+	assert.Less(t, time.Since(start), time.Second, "a hung container does not stall the refresh")
+
+	result := m.Containers()
+	require.Len(t, result, 3)
+	assert.Greater(t, result[0].CPUPercent, 0.0)
+	assert.Zero(t, result[1].CPUPercent)
+	assert.Zero(t, result[1].MemUsage)
+	assert.Greater(t, result[2].CPUPercent, 0.0)
+}
+
 // --- Tests: Containers returns copy ---
 
 func TestMonitor_ContainersReturnsCopy(t *testing.T) {
@@ -0,0 +1,35 @@
+// Package execrunner runs external commands behind an interface, so that the
+// monitors built on command-line tools (systemctl, ping, smartctl) can be
+// tested with canned output.
+package execrunner
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Runner runs a command and returns its output.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// Combined runs commands with os/exec and returns their combined standard
+// output and standard error, for tools whose error messages are read along
+// with their results.
+type Combined struct{}
+
+// Run executes a command and returns its combined output.
+func (Combined) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// Stdout runs commands with os/exec and returns only their standard output,
+// for tools that print machine-readable output there. Such tools may exit
+// non-zero to report findings, so the output of a failed command is returned
+// along with the error.
+type Stdout struct{}
+
+// Run executes a command and returns its standard output.
+func (Stdout) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
@@ -0,0 +1,21 @@
+package execrunner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombined(t *testing.T) {
+	out, err := Combined{}.Run(context.Background(), "sh", "-c", "echo out; echo err >&2")
+	require.NoError(t, err)
+	assert.Equal(t, "out\nerr\n", string(out))
+}
+
+func TestStdout_ReturnsOutputOfFailedCommand(t *testing.T) {
+	out, err := Stdout{}.Run(context.Background(), "sh", "-c", "echo '{}'; echo warning >&2; exit 4")
+	assert.Error(t, err)
+	assert.Equal(t, "{}\n", string(out))
+}
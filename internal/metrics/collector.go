@@ -5,15 +5,27 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
 )
 
 // Collector periodically reads system metrics and stores them in a ring buffer.
 type Collector struct {
-	reader   Reader
-	buffer   *RingBuffer
-	interval time.Duration
-	cancel   context.CancelFunc
-	wg       sync.WaitGroup
+	reader    Reader
+	buffer    *RingBuffer
+	interval  time.Duration
+	retention time.Duration
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	// Optional persistence, see EnablePersistence
+	store    *database.DB
+	tiers    []Tier
+	rolledUp []time.Time // per tier: end of the last rolled-up bucket
+
+	// Disk usage trends in percentage points per hour by mountpoint, see forecastDisks
+	trends   map[string]float64
+	trendsAt time.Time
 }
 
 // NewCollector creates a collector with the given reader, interval, and retention period.
@@ -25,9 +37,10 @@ func NewCollector(reader Reader, interval time.Duration, retention time.Duration
 	}
 
 	return &Collector{
-		reader:   reader,
-		buffer:   NewRingBuffer(capacity),
-		interval: interval,
+		reader:    reader,
+		buffer:    NewRingBuffer(capacity),
+		interval:  interval,
+		retention: retention,
 	}
 }
 
@@ -58,9 +71,21 @@ func (c *Collector) Latest() *Snapshot {
 	return c.buffer.Latest()
 }
 
-// History returns the last n snapshots in chronological order.
-func (c *Collector) History(n int) []Snapshot {
-	return c.buffer.History(n)
+// History returns the last n readings in chronological order. When persistence
+// is enabled and the ring buffer holds fewer than n entries (e.g. after a
+// restart), the rest of the n*interval time span is filled in from the database.
+func (c *Collector) History(n int) *History {
+	recent := c.buffer.History(n)
+	if c.store == nil || recent.Len() >= n {
+		return recent
+	}
+
+	before := time.Now()
+	if recent.Len() > 0 {
+		before = recent.Time(0)
+	}
+	from := time.Now().Add(-time.Duration(n) * c.interval)
+	return concatHistory(c.persistedHistory(from, before), recent)
 }
 
 // Len returns the number of stored snapshots.
@@ -91,5 +116,10 @@ func (c *Collector) collect(ctx context.Context) {
 		log.Printf("metrics: collection error: %v", err)
 		return
 	}
+	c.forecastDisks(snapshot)
 	c.buffer.Add(*snapshot)
+
+	if c.store != nil {
+		c.persist(snapshot)
+	}
 }
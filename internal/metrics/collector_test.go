@@ -108,11 +108,11 @@ func TestCollector_HistoryReturnsChrono(t *testing.T) {
 	c.Stop()
 
 	history := c.History(c.Len())
-	require.NotEmpty(t, history)
+	require.NotZero(t, history.Len())
 
 	// Verify chronological order
-	for i := 1; i < len(history); i++ {
-		assert.True(t, !history[i].Timestamp.Before(history[i-1].Timestamp),
+	for i := 1; i < history.Len(); i++ {
+		assert.True(t, !history.Time(i).Before(history.Time(i-1)),
 			"history[%d] timestamp should be >= history[%d]", i, i-1)
 	}
 }
@@ -0,0 +1,355 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output formats understood from custom collectors.
+const (
+	// CustomFormatJSON expects a JSON object on stdout mapping metric names to
+	// numbers or to {"value": n, "unit": "..."} objects. A non-zero exit
+	// status is a failure.
+	CustomFormatJSON = "json"
+	// CustomFormatNagios expects Nagios plugin output: the exit status is the
+	// state (0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN) and perfdata after "|"
+	// holds the values, e.g. "OK - 87% | battery=87%;20;10;0;100".
+	CustomFormatNagios = "nagios"
+)
+
+// customOutputLimit caps the output kept from a collector run.
+const customOutputLimit = 64 << 10
+
+// CustomCollectorConfig describes an executable producing custom metrics.
+type CustomCollectorConfig struct {
+	Name     string // prefix of every metric it produces, e.g. "ups"
+	Command  string
+	Args     []string
+	Interval time.Duration
+	Timeout  time.Duration
+	Format   string // CustomFormatJSON or CustomFormatNagios
+}
+
+// CustomCollectors runs the configured executables, each on its own interval,
+// and keeps the metrics of their latest runs.
+type CustomCollectors struct {
+	configs []CustomCollectorConfig
+	mu      sync.RWMutex
+	results map[string]*customResult // by collector name
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// customResult is the state of one collector after its latest run.
+type customResult struct {
+	metrics     []CustomMetric // from the last successful run
+	err         error          // of the latest run, nil if it succeeded
+	duration    time.Duration
+	timedOut    bool
+	lastSuccess time.Time
+}
+
+// NewCustomCollectors creates a runner for the given collectors.
+func NewCustomCollectors(configs []CustomCollectorConfig) *CustomCollectors {
+	return &CustomCollectors{
+		configs: configs,
+		results: make(map[string]*customResult),
+	}
+}
+
+// Start runs every collector immediately and then on its interval, each in
+// its own goroutine.
+func (c *CustomCollectors) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	for _, cfg := range c.configs {
+		c.wg.Add(1)
+		go func(cfg CustomCollectorConfig) {
+			defer c.wg.Done()
+			c.loop(ctx, cfg)
+		}(cfg)
+	}
+
+	log.Printf("Custom collectors started (%d configured)", len(c.configs))
+}
+
+// Stop cancels the collectors and waits for them to exit.
+func (c *CustomCollectors) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	log.Println("Custom collectors stopped")
+}
+
+func (c *CustomCollectors) loop(ctx context.Context, cfg CustomCollectorConfig) {
+	c.run(ctx, cfg)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.run(ctx, cfg)
+		}
+	}
+}
+
+// run executes a collector once and records the outcome.
+func (c *CustomCollectors) run(ctx context.Context, cfg CustomCollectorConfig) {
+	start := time.Now()
+	metrics, err := runCustomCollector(ctx, cfg, start)
+	duration := time.Since(start)
+	if ctx.Err() == context.Canceled {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, ok := c.results[cfg.Name]
+	if !ok {
+		res = &customResult{}
+		c.results[cfg.Name] = res
+	}
+	res.duration = duration
+	res.err = err
+	res.timedOut = errors.Is(err, context.DeadlineExceeded)
+	if err != nil {
+		log.Printf("metrics: custom collector %s failed: %v", cfg.Name, err)
+		return
+	}
+	res.metrics = metrics
+	res.lastSuccess = start
+}
+
+// runCustomCollector executes cfg.Command under cfg.Timeout and parses its
+// output into metrics named "<collector>.<metric>".
+func runCustomCollector(ctx context.Context, cfg CustomCollectorConfig, now time.Time) ([]CustomMetric, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, cfg.Command, cfg.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait for grandchildren holding the pipes open past the deadline.
+	cmd.WaitDelay = time.Second
+
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %v: %w", cfg.Timeout, ctx.Err())
+	}
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(runErr, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case runErr != nil:
+		return nil, fmt.Errorf("cannot run %s: %w", cfg.Command, runErr)
+	}
+
+	var (
+		values []CustomMetric
+		err    error
+	)
+	if cfg.Format == CustomFormatNagios {
+		values, err = parseNagiosOutput(stdout.Bytes(), exitCode)
+	} else {
+		if exitCode != 0 {
+			return nil, fmt.Errorf("exit status %d: %s", exitCode, firstLine(stderr.Bytes()))
+		}
+		values, err = parseJSONOutput(stdout.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range values {
+		values[i].Name = cfg.Name + "." + values[i].Name
+		values[i].Collector = cfg.Name
+		values[i].Timestamp = now
+	}
+	return values, nil
+}
+
+// parseJSONOutput parses the CustomFormatJSON output into metrics sorted by name.
+func parseJSONOutput(out []byte) ([]CustomMetric, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse JSON output: %w", err)
+	}
+
+	metrics := make([]CustomMetric, 0, len(raw))
+	for name, msg := range raw {
+		m := CustomMetric{Name: sanitizeMetricName(name)}
+		if err := json.Unmarshal(msg, &m.Value); err != nil {
+			var obj struct {
+				Value *float64 `json:"value"`
+				Unit  string   `json:"unit"`
+			}
+			if err := json.Unmarshal(msg, &obj); err != nil || obj.Value == nil {
+				return nil, fmt.Errorf("cannot parse JSON output: %q is neither a number nor {\"value\": n}", name)
+			}
+			m.Value, m.Unit = *obj.Value, obj.Unit
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	return metrics, nil
+}
+
+// parseNagiosOutput parses plugin output and exit status into a "status"
+// metric holding the exit status plus one metric per perfdata value.
+func parseNagiosOutput(out []byte, exitCode int) ([]CustomMetric, error) {
+	if exitCode < 0 || exitCode > 3 {
+		return nil, fmt.Errorf("exit status %d is not a plugin state: %s", exitCode, firstLine(out))
+	}
+
+	metrics := []CustomMetric{{Name: "status", Value: float64(exitCode)}}
+	// Perfdata follows the "|" of the first line and, in the long output on
+	// the following lines, everything after the first "|".
+	first, long, _ := strings.Cut(string(out), "\n")
+	_, perf, _ := strings.Cut(first, "|")
+	if _, more, ok := strings.Cut(long, "|"); ok {
+		perf += " " + strings.ReplaceAll(more, "\n", " ")
+	}
+	for _, field := range splitPerfdata(perf) {
+		if m, ok := parsePerfdata(field); ok {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// splitPerfdata splits perfdata on spaces, keeping quoted labels together.
+func splitPerfdata(s string) []string {
+	var fields []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			cur.WriteRune(r)
+		case r == ' ' && !quoted:
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+// parsePerfdata parses one 'label'=value[UOM];warn;crit;min;max item. Values
+// of "U" (undetermined) are skipped.
+func parsePerfdata(field string) (CustomMetric, bool) {
+	label, rest, ok := strings.Cut(field, "=")
+	if !ok {
+		return CustomMetric{}, false
+	}
+	label = strings.Trim(label, "'")
+	value, _, _ := strings.Cut(rest, ";")
+
+	end := len(value)
+	for end > 0 && !strings.ContainsRune("0123456789.", rune(value[end-1])) {
+		end--
+	}
+	v, err := strconv.ParseFloat(value[:end], 64)
+	if label == "" || err != nil {
+		return CustomMetric{}, false
+	}
+	return CustomMetric{Name: sanitizeMetricName(label), Value: v, Unit: value[end:]}, true
+}
+
+// sanitizeMetricName makes a name safe to use in series names and rule
+// targets by replacing whitespace and ":" with "_".
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ':' || r == ' ' || r == '\t' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
+
+func firstLine(b []byte) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(string(b)), "\n")
+	return line
+}
+
+// limitedBuffer keeps the first customOutputLimit bytes written to it and
+// discards the rest, so a runaway collector cannot exhaust memory.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := customOutputLimit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// readCustom fills s.Custom with the latest metrics of every collector and
+// reports failed collectors in s.Errors as "custom:<name>".
+func (c *CustomCollectors) readCustom(_ context.Context, s *Snapshot) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, cfg := range c.configs {
+		res, ok := c.results[cfg.Name]
+		if !ok {
+			continue // first run still in progress
+		}
+		s.Custom = append(s.Custom, res.metrics...)
+		if res.err != nil {
+			s.Errors = append(s.Errors, ReaderError{
+				Reader:      "custom:" + cfg.Name,
+				Error:       res.err.Error(),
+				Duration:    res.duration,
+				TimedOut:    res.timedOut,
+				Stale:       !res.lastSuccess.IsZero(),
+				LastSuccess: res.lastSuccess,
+			})
+		}
+	}
+	return nil
+}
+
+// SetCustomCollectors adds the metrics of c to every reading. It must be
+// called before the reader is used.
+func (r *SystemReader) SetCustomCollectors(c *CustomCollectors) {
+	r.sections = append(r.sections, readerSection{
+		name: "custom",
+		read: func(ctx context.Context, s *Snapshot, _ time.Time) error { return c.readCustom(ctx, s) },
+		merge: func(dst, src *Snapshot) {
+			dst.Custom = append(dst.Custom, src.Custom...)
+			dst.Errors = append(dst.Errors, src.Errors...)
+		},
+	})
+}
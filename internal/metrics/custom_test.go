@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript creates an executable shell script in a temporary directory.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "collector.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755))
+	return path
+}
+
+func TestParseJSONOutput(t *testing.T) {
+	metrics, err := parseJSONOutput([]byte(`{"queue_depth": 12, "battery": {"value": 87.5, "unit": "%"}, "room temp": 21}`))
+	require.NoError(t, err)
+	assert.Equal(t, []CustomMetric{
+		{Name: "battery", Value: 87.5, Unit: "%"},
+		{Name: "queue_depth", Value: 12},
+		{Name: "room_temp", Value: 21},
+	}, metrics)
+
+	_, err = parseJSONOutput([]byte(`{"state": "ok"}`))
+	assert.Error(t, err)
+	_, err = parseJSONOutput([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestParseNagiosOutput(t *testing.T) {
+	out := "UPS WARNING - battery at 35% | battery=35%;40;20;0;100 'input voltage'=229.5V;;;\nOn battery for 5 minutes | load=12.5;;;\nruntime=U\n"
+	metrics, err := parseNagiosOutput([]byte(out), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []CustomMetric{
+		{Name: "status", Value: 1},
+		{Name: "battery", Value: 35, Unit: "%"},
+		{Name: "input_voltage", Value: 229.5, Unit: "V"},
+		{Name: "load", Value: 12.5},
+	}, metrics)
+}
+
+func TestParseNagiosOutput_NoPerfdata(t *testing.T) {
+	metrics, err := parseNagiosOutput([]byte("CRITICAL - host unreachable\n"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []CustomMetric{{Name: "status", Value: 2}}, metrics)
+
+	_, err = parseNagiosOutput([]byte("Segmentation fault"), 139)
+	assert.Error(t, err, "only 0-3 are plugin states")
+}
+
+func TestRunCustomCollector_JSON(t *testing.T) {
+	cfg := CustomCollectorConfig{
+		Name:    "queue",
+		Command: writeScript(t, `echo '{"depth": 42, "workers": {"value": 3}}'`),
+		Timeout: 5 * time.Second,
+		Format:  CustomFormatJSON,
+	}
+	now := time.Now()
+
+	metrics, err := runCustomCollector(context.Background(), cfg, now)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, CustomMetric{Name: "queue.depth", Collector: "queue", Value: 42, Timestamp: now}, metrics[0])
+	assert.Equal(t, "queue.workers", metrics[1].Name)
+}
+
+func TestRunCustomCollector_JSONExitStatus(t *testing.T) {
+	cfg := CustomCollectorConfig{
+		Name:    "queue",
+		Command: writeScript(t, `echo "connection refused" >&2; exit 1`),
+		Timeout: 5 * time.Second,
+		Format:  CustomFormatJSON,
+	}
+	_, err := runCustomCollector(context.Background(), cfg, time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+func TestRunCustomCollector_Nagios(t *testing.T) {
+	cfg := CustomCollectorConfig{
+		Name:    "ups",
+		Command: writeScript(t, `echo "CRITICAL - battery 9% | battery=9%;20;10"; exit 2`),
+		Timeout: 5 * time.Second,
+		Format:  CustomFormatNagios,
+	}
+	metrics, err := runCustomCollector(context.Background(), cfg, time.Now())
+	require.NoError(t, err, "a CRITICAL state is a result, not a failure")
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "ups.status", metrics[0].Name)
+	assert.Equal(t, 2.0, metrics[0].Value)
+	assert.Equal(t, "ups.battery", metrics[1].Name)
+	assert.Equal(t, 9.0, metrics[1].Value)
+}
+
+func TestRunCustomCollector_Timeout(t *testing.T) {
+	cfg := CustomCollectorConfig{
+		Name:    "slow",
+		Command: writeScript(t, `sleep 10`),
+		Timeout: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	_, err := runCustomCollector(context.Background(), cfg, start)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunCustomCollector_MissingCommand(t *testing.T) {
+	cfg := CustomCollectorConfig{Name: "gone", Command: "/nonexistent/collector", Timeout: time.Second}
+	_, err := runCustomCollector(context.Background(), cfg, time.Now())
+	assert.Error(t, err)
+}
+
+func TestCustomCollectors_ReadKeepsLastValues(t *testing.T) {
+	flag := filepath.Join(t.TempDir(), "fail")
+	cfg := CustomCollectorConfig{
+		Name:     "ups",
+		Command:  writeScript(t, `[ -e "`+flag+`" ] && exit 1; echo '{"battery": 87}'`),
+		Interval: time.Hour,
+		Timeout:  5 * time.Second,
+	}
+	c := NewCustomCollectors([]CustomCollectorConfig{cfg})
+
+	s := &Snapshot{}
+	require.NoError(t, c.readCustom(context.Background(), s))
+	assert.Empty(t, s.Custom, "nothing before the first run")
+
+	c.run(context.Background(), cfg)
+	s = &Snapshot{}
+	require.NoError(t, c.readCustom(context.Background(), s))
+	require.Len(t, s.Custom, 1)
+	assert.Equal(t, "ups.battery", s.Custom[0].Name)
+	assert.Empty(t, s.Errors)
+
+	require.NoError(t, os.WriteFile(flag, nil, 0644))
+	c.run(context.Background(), cfg)
+	s = &Snapshot{}
+	require.NoError(t, c.readCustom(context.Background(), s))
+	require.Len(t, s.Custom, 1, "a failed run keeps the last values")
+	re := s.ErrorFor("custom:ups")
+	require.NotNil(t, re)
+	assert.True(t, re.Stale)
+	assert.Equal(t, s.Custom[0].Timestamp, re.LastSuccess)
+}
+
+func TestSystemReader_CustomCollectors(t *testing.T) {
+	cfg := CustomCollectorConfig{
+		Name:     "room",
+		Command:  writeScript(t, `echo '{"temp": 21.5}'`),
+		Interval: time.Hour,
+		Timeout:  5 * time.Second,
+	}
+	c := NewCustomCollectors([]CustomCollectorConfig{cfg})
+	c.Start(context.Background())
+	defer c.Stop()
+
+	r := fakeSections(time.Second)
+	r.SetCustomCollectors(c)
+
+	require.Eventually(t, func() bool {
+		s, err := r.Read(context.Background())
+		return err == nil && len(s.Custom) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+
+	s, _ := r.Read(context.Background())
+	assert.Equal(t, 21.5, s.Series()["custom:room.temp"])
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import "path"
+
+// DefaultExcludeFSTypes are the filesystem types skipped unless configured
+// otherwise: snap and container images, overlays and RAM-backed mounts that
+// would otherwise show up as (and alert as) full disks.
+var DefaultExcludeFSTypes = []string{"squashfs", "overlay", "tmpfs", "devtmpfs"}
+
+// DiskFilter decides which mounted filesystems are reported. Filesystem types
+// match exactly; mountpoints match exactly or as path.Match globs such as
+// "/snap/*". Empty include lists keep everything; excludes always win.
+type DiskFilter struct {
+	IncludeFSTypes []string
+	ExcludeFSTypes []string
+	IncludeMounts  []string
+	ExcludeMounts  []string
+}
+
+// DefaultDiskFilter returns the filter used when nothing is configured.
+func DefaultDiskFilter() DiskFilter {
+	return DiskFilter{ExcludeFSTypes: DefaultExcludeFSTypes}
+}
+
+// Keep reports whether a filesystem of fstype mounted at mountpoint is reported.
+func (f DiskFilter) Keep(fstype, mountpoint string) bool {
+	if len(f.IncludeFSTypes) > 0 && !containsString(f.IncludeFSTypes, fstype) {
+		return false
+	}
+	if len(f.IncludeMounts) > 0 && !matchesPattern(f.IncludeMounts, mountpoint) {
+		return false
+	}
+	if containsString(f.ExcludeFSTypes, fstype) {
+		return false
+	}
+	return !matchesPattern(f.ExcludeMounts, mountpoint)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPattern reports whether name equals or matches (as a path.Match glob)
+// any of patterns.
+func matchesPattern(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name {
+			return true
+		}
+		if ok, err := path.Match(p, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
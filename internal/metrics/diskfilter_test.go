@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskFilter_Defaults(t *testing.T) {
+	f := DefaultDiskFilter()
+
+	assert.True(t, f.Keep("ext4", "/"))
+	assert.True(t, f.Keep("vfat", "/boot/firmware"))
+	assert.False(t, f.Keep("squashfs", "/snap/core22/1122"))
+	assert.False(t, f.Keep("overlay", "/var/lib/docker/overlay2/abc/merged"))
+	assert.False(t, f.Keep("tmpfs", "/run"))
+	assert.False(t, f.Keep("devtmpfs", "/dev"))
+}
+
+func TestDiskFilter_ExcludeMounts(t *testing.T) {
+	f := DiskFilter{ExcludeMounts: []string{"/boot/firmware", "/media/*"}}
+
+	assert.True(t, f.Keep("ext4", "/"))
+	assert.False(t, f.Keep("vfat", "/boot/firmware"))
+	assert.False(t, f.Keep("exfat", "/media/usb0"))
+	assert.True(t, f.Keep("exfat", "/media"), "glob only matches below /media")
+}
+
+func TestDiskFilter_Include(t *testing.T) {
+	f := DiskFilter{IncludeFSTypes: []string{"ext4"}, IncludeMounts: []string{"/", "/data*"}}
+
+	assert.True(t, f.Keep("ext4", "/"))
+	assert.True(t, f.Keep("ext4", "/data2"))
+	assert.False(t, f.Keep("xfs", "/data2"), "fstype not included")
+	assert.False(t, f.Keep("ext4", "/home"), "mountpoint not included")
+}
+
+func TestDiskFilter_ExcludeWinsOverInclude(t *testing.T) {
+	f := DiskFilter{IncludeFSTypes: []string{"tmpfs"}, ExcludeFSTypes: []string{"tmpfs"}}
+	assert.False(t, f.Keep("tmpfs", "/run"))
+}
+
+func TestDiskFilter_Empty(t *testing.T) {
+	assert.True(t, DiskFilter{}.Keep("tmpfs", "/run"))
+}
+
+func TestSystemReader_ReadDisksFiltered(t *testing.T) {
+	r := NewSystemReader()
+	ctx := context.Background()
+
+	// Including only a filesystem type that does not exist leaves no disks
+	r.SetDiskFilter(DiskFilter{IncludeFSTypes: []string{"nosuchfs"}})
+	s := &Snapshot{}
+	r.readDisks(ctx, s)
+	assert.Empty(t, s.Disks)
+
+	r.SetDiskFilter(DiskFilter{})
+	s = &Snapshot{}
+	r.readDisks(ctx, s)
+	for _, d := range s.Disks {
+		require.NotEmpty(t, d.Fstype)
+		assert.GreaterOrEqual(t, d.InodesPercent, 0.0)
+		assert.LessOrEqual(t, d.InodesUsed, d.InodesTotal)
+	}
+}
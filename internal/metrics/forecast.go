@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// forecastWindow is how much history the disk usage trend is fitted to.
+	forecastWindow = 24 * time.Hour
+	// forecastRefresh is how often the trends are refitted; between fits the
+	// estimate moves with the current usage.
+	forecastRefresh = 5 * time.Minute
+	// forecastMinSpan is the least history a trend needs before it is trusted.
+	forecastMinSpan = time.Hour
+	// forecastResetDrop is the fall in usage, in percentage points, taken as a
+	// cleanup or resize. The trend is fitted from the last such drop on.
+	forecastResetDrop = 5
+	// forecastMaxHours caps estimates; slower growth is reported as none.
+	forecastMaxHours = 365 * 24
+)
+
+// forecastDisks sets FullInHours on the disks of s from the usage trend of
+// each mountpoint, refitting the trends when they are older than
+// forecastRefresh. Only called from the collection goroutine.
+func (c *Collector) forecastDisks(s *Snapshot) {
+	if len(s.Disks) == 0 {
+		return
+	}
+	if c.trends == nil || s.Timestamp.Sub(c.trendsAt) >= forecastRefresh {
+		h := c.History(int(forecastWindow / c.interval))
+		c.trends = make(map[string]float64, len(s.Disks))
+		for _, d := range s.Disks {
+			if slope, ok := fitTrend(h, seriesDiskPrefix+d.Path); ok {
+				c.trends[d.Path] = slope
+			}
+		}
+		c.trendsAt = s.Timestamp
+	}
+
+	for i := range s.Disks {
+		d := &s.Disks[i]
+		if eta, ok := fullInHours(d.Percent, c.trends[d.Path]); ok {
+			d.FullInHours = &eta
+		}
+	}
+}
+
+// fullInHours returns the hours until percent reaches 100 when growing by
+// slope points per hour.
+func fullInHours(percent, slope float64) (float64, bool) {
+	if slope <= 0 {
+		return 0, false
+	}
+	hours := math.Max(100-percent, 0) / slope
+	if hours > forecastMaxHours {
+		return 0, false
+	}
+	return hours, true
+}
+
+// fitTrend fits a least-squares line to a column of h and returns its slope
+// in units per hour. Rows before the last drop of forecastResetDrop or more
+// are ignored, so freeing space starts a new trend.
+func fitTrend(h *History, name string) (float64, bool) {
+	col := h.Column(name)
+
+	start, prev := 0, math.NaN()
+	for i, v := range col {
+		if math.IsNaN(float64(v)) {
+			continue
+		}
+		if prev-float64(v) >= forecastResetDrop {
+			start = i
+		}
+		prev = float64(v)
+	}
+
+	var n, sumX, sumY, sumXX, sumXY float64
+	var first, last time.Time
+	for i := start; i < len(col); i++ {
+		if math.IsNaN(float64(col[i])) {
+			continue
+		}
+		t := h.Time(i)
+		if first.IsZero() {
+			first = t
+		}
+		last = t
+		x, y := t.Sub(first).Hours(), float64(col[i])
+		n++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	if last.Sub(first) < forecastMinSpan {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX), true
+}
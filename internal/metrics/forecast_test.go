@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// growingDiskReader returns snapshots whose "/" usage grows by perStep each read.
+type growingDiskReader struct {
+	now     time.Time
+	step    time.Duration
+	percent float64
+	perStep float64
+}
+
+func (r *growingDiskReader) Read(_ context.Context) (*Snapshot, error) {
+	s := &Snapshot{
+		Timestamp: r.now,
+		Disks:     []DiskPartition{{Path: "/", Percent: r.percent}, {Path: "/boot", Percent: 30}},
+	}
+	r.now = r.now.Add(r.step)
+	r.percent += r.perStep
+	return s, nil
+}
+
+func historyOf(start time.Time, step time.Duration, name string, values ...float32) *History {
+	rb := NewRingBuffer(len(values))
+	for i, v := range values {
+		s := Snapshot{Timestamp: start.Add(time.Duration(i) * step)}
+		if !math.IsNaN(float64(v)) {
+			s.Disks = []DiskPartition{{Path: name, Percent: float64(v)}}
+		}
+		rb.Add(s)
+	}
+	return rb.All()
+}
+
+func TestFitTrend(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	h := historyOf(start, 30*time.Minute, "/", 50, 51, 52, nan32, 54, 55)
+
+	slope, ok := fitTrend(h, "disk:/")
+	require.True(t, ok)
+	assert.InDelta(t, 2, slope, 0.001, "one point per half hour")
+
+	_, ok = fitTrend(h, "disk:/missing")
+	assert.False(t, ok)
+	_, ok = fitTrend(nil, "disk:/")
+	assert.False(t, ok)
+}
+
+func TestFitTrend_RestartsAfterCleanup(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	h := historyOf(start, 30*time.Minute, "/", 80, 85, 90, 40, 40.5, 41, 41.5)
+
+	slope, ok := fitTrend(h, "disk:/")
+	require.True(t, ok)
+	assert.InDelta(t, 1, slope, 0.001, "only rows after the drop count")
+
+	h = historyOf(start, 30*time.Minute, "/", 80, 85, 90, 40, 40.5)
+	_, ok = fitTrend(h, "disk:/")
+	assert.False(t, ok, "less than an hour since the drop")
+}
+
+func TestFullInHours(t *testing.T) {
+	hours, ok := fullInHours(80, 0.5)
+	require.True(t, ok)
+	assert.InDelta(t, 40, hours, 0.001)
+
+	_, ok = fullInHours(80, 0)
+	assert.False(t, ok)
+	_, ok = fullInHours(80, -1)
+	assert.False(t, ok)
+	_, ok = fullInHours(10, 0.001)
+	assert.False(t, ok, "beyond a year")
+}
+
+func TestCollector_ForecastsDiskFull(t *testing.T) {
+	reader := &growingDiskReader{now: time.Unix(1700000000, 0), step: time.Minute, percent: 50, perStep: 0.1}
+	c := NewCollector(reader, time.Minute, 24*time.Hour)
+
+	for i := 0; i < 30; i++ {
+		c.collect(context.Background())
+	}
+	assert.Nil(t, c.Latest().Disks[0].FullInHours, "not enough history yet")
+
+	for i := 0; i < 60; i++ {
+		c.collect(context.Background())
+	}
+	d := c.Latest().Disks
+	require.NotNil(t, d[0].FullInHours)
+	// 6 points per hour: at ~59% the remaining 41 points take ~6.8 hours.
+	assert.InDelta(t, (100-d[0].Percent)/6, *d[0].FullInHours, 0.01)
+	assert.Nil(t, d[1].FullInHours, "stable usage")
+}
+
+func TestCollector_ForecastUsesPersistedHistory(t *testing.T) {
+	db := setupHistoryDB(t)
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Minute)
+
+	reader := &growingDiskReader{now: start, step: time.Minute, percent: 50, perStep: 0.1}
+	before := NewCollector(reader, time.Minute, 24*time.Hour)
+	before.EnablePersistence(db, nil)
+	for i := 0; i < 120; i++ {
+		before.collect(context.Background())
+	}
+
+	// A new collector starts with an empty ring buffer, as after a restart.
+	after := NewCollector(reader, time.Minute, 24*time.Hour)
+	after.EnablePersistence(db, nil)
+	after.collect(context.Background())
+
+	d := after.Latest().Disks[0]
+	require.NotNil(t, d.FullInHours)
+	assert.InDelta(t, (100-d.Percent)/6, *d.FullInHours, 0.01)
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"log"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+// Tier is a downsampled history resolution with its own retention period.
+type Tier struct {
+	Resolution time.Duration
+	Retention  time.Duration
+}
+
+// DefaultTiers are the rollup tiers kept when persistence is enabled:
+// 1-minute for a week, 15-minute for a month, 1-hour for a year.
+var DefaultTiers = []Tier{
+	{Resolution: time.Minute, Retention: 7 * 24 * time.Hour},
+	{Resolution: 15 * time.Minute, Retention: 30 * 24 * time.Hour},
+	{Resolution: time.Hour, Retention: 365 * 24 * time.Hour},
+}
+
+// EnablePersistence makes the collector write every snapshot to the database
+// and roll raw samples up into the given tiers, each aggregated from the
+// previous one. Raw samples are kept for the collector's retention period.
+// Must be called before Start.
+func (c *Collector) EnablePersistence(store *database.DB, tiers []Tier) {
+	c.store = store
+	c.tiers = tiers
+	c.rolledUp = make([]time.Time, len(tiers))
+}
+
+func (c *Collector) persist(s *Snapshot) {
+	if err := c.store.InsertMetricSamples(s.Timestamp, s.Series()); err != nil {
+		log.Printf("metrics: failed to persist snapshot: %v", err)
+		return
+	}
+	c.rollup(s.Timestamp)
+}
+
+// rollup aggregates every tier bucket that closed since the previous call and
+// prunes samples that fell out of their retention period.
+func (c *Collector) rollup(now time.Time) {
+	src := time.Duration(0)
+	for i, tier := range c.tiers {
+		end := bucketStart(now, tier.Resolution)
+
+		if c.rolledUp[i].IsZero() {
+			// Resume from the newest stored bucket so samples written before
+			// a restart are rolled up as well.
+			latest, err := c.store.LatestMetricSampleTime(tier.Resolution)
+			if err != nil {
+				log.Printf("metrics: %v", err)
+				return
+			}
+			c.rolledUp[i] = latest
+		}
+
+		if end.After(c.rolledUp[i]) {
+			if err := c.store.RollupMetricSamples(src, tier.Resolution, c.rolledUp[i], end); err != nil {
+				log.Printf("metrics: %v", err)
+				return
+			}
+			c.rolledUp[i] = end
+
+			if i == 0 {
+				c.prune(0, now.Add(-c.retention))
+			}
+			c.prune(tier.Resolution, now.Add(-tier.Retention))
+		}
+
+		src = tier.Resolution
+	}
+}
+
+func (c *Collector) prune(resolution time.Duration, before time.Time) {
+	if _, err := c.store.PruneMetricSamples(resolution, before); err != nil {
+		log.Printf("metrics: %v", err)
+	}
+}
+
+// persistedHistory loads history with timestamps in [from, before) from the
+// database. See persistedSamples for how tiers are chosen.
+func (c *Collector) persistedHistory(from, before time.Time) *History {
+	return historyFromSamples(c.persistedSamples(from, before))
+}
+
+// persistedSamples loads samples with timestamps in [from, before), preferring
+// raw samples and falling back to coarser tiers for the part of the range that
+// is older than the finer data.
+func (c *Collector) persistedSamples(from, before time.Time) []database.MetricSample {
+	var result []database.MetricSample
+
+	resolutions := make([]time.Duration, 0, len(c.tiers)+1)
+	resolutions = append(resolutions, 0)
+	for _, tier := range c.tiers {
+		resolutions = append(resolutions, tier.Resolution)
+	}
+
+	for _, res := range resolutions {
+		if !before.After(from) {
+			break
+		}
+		samples, err := c.store.ListMetricSamples(res, from, before)
+		if err != nil {
+			log.Printf("metrics: %v", err)
+			break
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		result = append(samples, result...)
+		before = samples[0].Timestamp
+	}
+	return result
+}
+
+// historyFromSamples turns time-ordered samples into one row per timestamp,
+// using the bucket average for rolled-up tiers.
+func historyFromSamples(samples []database.MetricSample) *History {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	h := &History{columns: make(map[string][]float32)}
+	for _, ms := range samples {
+		ts := ms.Timestamp.UnixNano()
+		if len(h.times) == 0 || h.times[len(h.times)-1] != ts {
+			h.times = append(h.times, ts)
+		}
+		col := h.columns[ms.Series]
+		for len(col) < len(h.times)-1 {
+			col = append(col, nan32)
+		}
+		h.columns[ms.Series] = append(col, float32(ms.Avg))
+	}
+	for name, col := range h.columns {
+		for len(col) < len(h.times) {
+			col = append(col, nan32)
+		}
+		h.columns[name] = col
+	}
+	return h
+}
+
+// bucketStart returns the start of the resolution-sized bucket containing t,
+// aligned to the unix epoch the same way the database rollup query is.
+func bucketStart(t time.Time, resolution time.Duration) time.Time {
+	step := int64(resolution / time.Second)
+	return time.Unix(t.Unix()/step*step, 0)
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+// clockReader returns snapshots with deterministic timestamps.
+type clockReader struct {
+	now  time.Time
+	step time.Duration
+	cpu  float64
+}
+
+func (r *clockReader) Read(_ context.Context) (*Snapshot, error) {
+	temp := 50.0
+	s := &Snapshot{
+		Timestamp:   r.now,
+		CPU:         CPUMetrics{TotalPercent: r.cpu},
+		RAM:         RAMMetrics{Percent: 40},
+		Disks:       []DiskPartition{{Path: "/", Percent: 70}},
+		Networks:    []NetworkIface{{Name: "eth0", BytesSentPS: 100, BytesRecvPS: 200}},
+		Temperature: &temp,
+	}
+	r.now = r.now.Add(r.step)
+	r.cpu += 10
+	return s, nil
+}
+
+func setupHistoryDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSnapshotSeries_AllValues(t *testing.T) {
+	temp := 61.5
+	s := Snapshot{
+		CPU:          CPUMetrics{TotalPercent: 12.5, Load1: 0.5, Load5: 1.5, Load15: 2.5, Times: CPUTimes{IOWait: 7, Steal: 1}},
+		RAM:          RAMMetrics{Percent: 48, SwapTotal: 1024, SwapPercent: 25, Pressure: &MemoryPressure{SomeAvg10: 4.5, FullAvg10: 1}},
+		Disks:        []DiskPartition{{Path: "/", Percent: 70}, {Path: "/data", Percent: 20}},
+		DiskIO:       []DiskIO{{Device: "mmcblk0", ReadBytesPS: 4096, WriteBytesPS: 8192, BusyPercent: 35}},
+		Networks:     []NetworkIface{{Name: "eth0", BytesSentPS: 1000, BytesRecvPS: 2000}},
+		Temperature:  &temp,
+		Temperatures: []TempSensor{{Label: "cpu_thermal", Celsius: 61.5}, {Label: "nvme_composite", Celsius: 44}},
+	}
+
+	values := s.Series()
+	assert.Equal(t, 12.5, values["cpu"])
+	assert.Equal(t, 70.0, values["disk:/"])
+	assert.Equal(t, 2000.0, values["net_rx:eth0"])
+
+	assert.Equal(t, map[string]float64{
+		"cpu": 12.5, "ram": 48, "load1": 0.5, "load5": 1.5, "load15": 2.5,
+		"iowait": 7, "steal": 1, "swap": 25, "mem_pressure": 4.5,
+		"disk:/": 70, "disk:/data": 20,
+		"disk_read:mmcblk0": 4096, "disk_write:mmcblk0": 8192, "disk_busy:mmcblk0": 35,
+		"net_tx:eth0": 1000, "net_rx:eth0": 2000,
+		"temp": 61.5, "temp:cpu_thermal": 61.5, "temp:nvme_composite": 44,
+	}, values)
+
+	// The ring buffer stores exactly the same series
+	rb := NewRingBuffer(1)
+	rb.Add(s)
+	assert.Len(t, rb.All().Names(), len(values))
+}
+
+func TestSnapshotSeries_NoTemperature(t *testing.T) {
+	s := Snapshot{}
+	_, ok := s.Series()["temp"]
+	assert.False(t, ok)
+	_, ok = s.Series()["mem_pressure"]
+	assert.False(t, ok)
+}
+
+func TestCollector_PersistsAndRollsUp(t *testing.T) {
+	db := setupHistoryDB(t)
+	start := time.Unix(1700000040, 0) // aligned to a minute
+	reader := &clockReader{now: start, step: 15 * time.Second, cpu: 10}
+
+	c := NewCollector(reader, 15*time.Second, time.Hour)
+	c.EnablePersistence(db, []Tier{{Resolution: time.Minute, Retention: 24 * time.Hour}})
+
+	// 4 samples in the first minute, then one in the next minute closes the bucket
+	for i := 0; i < 5; i++ {
+		c.collect(context.Background())
+	}
+
+	raw, err := db.ListMetricSamples(0, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.NotEmpty(t, raw)
+
+	rollups, err := db.ListMetricSamples(time.Minute, start, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	var cpu *database.MetricSample
+	for i := range rollups {
+		if rollups[i].Series == "cpu" {
+			cpu = &rollups[i]
+		}
+	}
+	require.NotNil(t, cpu, "expected a 1-minute cpu rollup")
+	assert.Equal(t, start, cpu.Timestamp)
+	assert.Equal(t, 10.0, cpu.Min)
+	assert.Equal(t, 25.0, cpu.Avg)
+	assert.Equal(t, 40.0, cpu.Max)
+}
+
+func TestCollector_PrunesExpiredSamples(t *testing.T) {
+	db := setupHistoryDB(t)
+	start := time.Unix(1700000040, 0)
+
+	// Stale raw sample well outside the one-hour raw retention
+	require.NoError(t, db.InsertMetricSamples(start.Add(-3*time.Hour), map[string]float64{"cpu": 1}))
+
+	reader := &clockReader{now: start, step: time.Minute}
+	c := NewCollector(reader, time.Minute, time.Hour)
+	c.EnablePersistence(db, []Tier{{Resolution: time.Minute, Retention: time.Hour}})
+
+	c.collect(context.Background())
+	c.collect(context.Background())
+
+	raw, err := db.ListMetricSamples(0, start.Add(-4*time.Hour), start.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, raw)
+}
+
+func TestCollector_HistoryMergesPersisted(t *testing.T) {
+	db := setupHistoryDB(t)
+	now := time.Now()
+
+	// Simulate samples written by a previous process
+	for i := 10; i > 0; i-- {
+		ts := now.Add(-time.Duration(i) * 5 * time.Second)
+		require.NoError(t, db.InsertMetricSamples(ts, map[string]float64{"cpu": float64(i), "ram": 30}))
+	}
+
+	reader := &clockReader{now: now, step: 5 * time.Second, cpu: 99}
+	c := NewCollector(reader, 5*time.Second, time.Hour)
+	c.EnablePersistence(db, DefaultTiers)
+	latest, _ := reader.Read(context.Background())
+	c.buffer.Add(*latest)
+
+	history := c.History(20)
+	require.Equal(t, 11, history.Len())
+	cpu := cpuValues(history)
+	assert.Equal(t, 10.0, cpu[0])
+	assert.Equal(t, 99.0, cpu[10])
+	assert.Equal(t, float32(30), history.Column("ram")[0])
+	// Only the live reading has per-device series
+	assert.True(t, math.IsNaN(float64(history.Column("disk:/")[0])))
+	assert.Equal(t, float32(70), history.Column("disk:/")[10])
+
+	for i := 1; i < history.Len(); i++ {
+		assert.True(t, history.Time(i).After(history.Time(i-1)))
+	}
+}
+
+func TestCollector_HistoryFallsBackToTiers(t *testing.T) {
+	db := setupHistoryDB(t)
+	now := time.Now()
+
+	// Only a 1-minute rollup exists for an hour ago (raw already pruned)
+	hourAgo := bucketStart(now.Add(-time.Hour), time.Minute)
+	require.NoError(t, db.InsertMetricSamples(hourAgo, map[string]float64{"cpu": 42}))
+	require.NoError(t, db.RollupMetricSamples(0, time.Minute, hourAgo, hourAgo.Add(time.Minute)))
+	_, err := db.PruneMetricSamples(0, now)
+	require.NoError(t, err)
+
+	c := NewCollector(&clockReader{now: now, step: time.Second}, 5*time.Second, time.Hour)
+	c.EnablePersistence(db, DefaultTiers)
+
+	history := c.History(2 * 720) // two hours at 5s
+	require.Equal(t, 1, history.Len())
+	assert.Equal(t, []float64{42}, cpuValues(history))
+}
+
+func TestCollector_HistoryWithoutStore(t *testing.T) {
+	c := NewCollector(&mockReader{}, time.Second, time.Hour)
+	assert.Nil(t, c.History(10))
+}
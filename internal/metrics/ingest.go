@@ -0,0 +1,437 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxIngestSeries bounds the number of pushed series kept, so a client
+	// sending unique names cannot exhaust memory.
+	maxIngestSeries = 500
+	// maxIngestSamples bounds the timer samples kept per series and interval;
+	// further samples still count towards mean and max.
+	maxIngestSamples = 10000
+	// ingestExpiry is how long a series is kept after its last update.
+	ingestExpiry = 10 * time.Minute
+)
+
+// StatsD metric types, plus ingestInflux for line protocol fields.
+const (
+	ingestCounter = "c"
+	ingestGauge   = "g"
+	ingestTimer   = "ms"
+	ingestHisto   = "h"
+	ingestDist    = "d"
+	ingestSet     = "s"
+	ingestInflux  = "influx"
+)
+
+// Ingest receives metrics pushed over UDP in StatsD or InfluxDB line protocol
+// and aggregates them between readings. Each line is parsed as StatsD if it
+// contains "|", and as line protocol otherwise.
+//
+// On every reading, StatsD counters are reported as a per-second rate over
+// the interval, timers and histograms as <name>.mean, .p95 and .max, sets as
+// the number of unique values, and gauges and line protocol fields as their
+// latest value. Tags are appended to the name as {key=value,...}, and the
+// name is prefixed with "statsd." or "influx." so that pushed series never
+// collide with those of a custom collector.
+type Ingest struct {
+	conn net.PacketConn
+	wg   sync.WaitGroup
+
+	mu        sync.Mutex
+	series    map[string]*ingestSeries
+	lastFlush time.Time
+	invalid   int    // lines rejected since the last flush
+	lastError string // of the most recent rejected line
+	full      bool   // series were dropped because of maxIngestSeries
+}
+
+// ingestSeries accumulates one pushed series between flushes.
+type ingestSeries struct {
+	kind     string
+	source   string // "statsd" or "influx"
+	lastSeen time.Time
+
+	value   float64 // gauge value, or counter sum in the current interval
+	samples []float64
+	sum     float64
+	max     float64
+	count   int
+	set     map[string]struct{}
+}
+
+// NewIngest creates an ingest that is not listening yet.
+func NewIngest() *Ingest {
+	return &Ingest{
+		series:    make(map[string]*ingestSeries),
+		lastFlush: time.Now(),
+	}
+}
+
+// Listen starts receiving packets on the UDP address, e.g. ":8125".
+func (in *Ingest) Listen(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen for pushed metrics: %w", err)
+	}
+	in.conn = conn
+
+	in.wg.Add(1)
+	go func() {
+		defer in.wg.Done()
+		in.serve()
+	}()
+
+	log.Printf("Metrics ingest listening on udp %s", conn.LocalAddr())
+	return nil
+}
+
+// Addr returns the address being listened on, or nil.
+func (in *Ingest) Addr() net.Addr {
+	if in.conn == nil {
+		return nil
+	}
+	return in.conn.LocalAddr()
+}
+
+// Close stops listening and waits for the receive loop to exit.
+func (in *Ingest) Close() {
+	if in.conn != nil {
+		in.conn.Close()
+	}
+	in.wg.Wait()
+}
+
+func (in *Ingest) serve() {
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := in.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("metrics: ingest read failed: %v", err)
+			continue
+		}
+		in.handlePacket(string(buf[:n]), time.Now())
+	}
+}
+
+// handlePacket records every newline-separated line of a packet.
+func (in *Ingest) handlePacket(packet string, now time.Time) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, line := range strings.Split(packet, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var err error
+		if strings.Contains(line, "|") {
+			err = in.addStatsD(line, now)
+		} else {
+			err = in.addInflux(line, now)
+		}
+		if err != nil {
+			in.invalid++
+			in.lastError = err.Error()
+		}
+	}
+}
+
+// addStatsD parses name:value|type[|@rate][|#tag:value,...].
+func (in *Ingest) addStatsD(line string, now time.Time) error {
+	parts := strings.Split(line, "|")
+	name, raw, ok := strings.Cut(parts[0], ":")
+	if !ok || name == "" || len(parts) < 2 {
+		return fmt.Errorf("invalid statsd line %q", line)
+	}
+	kind := parts[1]
+
+	rate := 1.0
+	var tags []string
+	for _, p := range parts[2:] {
+		switch {
+		case strings.HasPrefix(p, "@"):
+			r, err := strconv.ParseFloat(p[1:], 64)
+			if err != nil || r <= 0 || r > 1 {
+				return fmt.Errorf("invalid sample rate in %q", line)
+			}
+			rate = r
+		case strings.HasPrefix(p, "#"):
+			for _, tag := range strings.Split(p[1:], ",") {
+				k, v, _ := strings.Cut(tag, ":")
+				tags = append(tags, k+"="+v)
+			}
+		}
+	}
+	name = sanitizeMetricName(name) + formatTags(tags)
+
+	if kind == ingestSet {
+		s := in.get(name, kind, "statsd", now)
+		if s == nil {
+			return nil
+		}
+		s.set[raw] = struct{}{}
+		return nil
+	}
+
+	// Gauges accept a sign prefix to change the current value.
+	delta := kind == ingestGauge && (strings.HasPrefix(raw, "+") || strings.HasPrefix(raw, "-"))
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("invalid value in %q", line)
+	}
+
+	switch kind {
+	case ingestCounter:
+		if s := in.get(name, kind, "statsd", now); s != nil {
+			s.value += v / rate
+		}
+	case ingestGauge:
+		if s := in.get(name, kind, "statsd", now); s != nil {
+			if delta {
+				s.value += v
+			} else {
+				s.value = v
+			}
+		}
+	case ingestTimer, ingestHisto, ingestDist:
+		if s := in.get(name, kind, "statsd", now); s != nil {
+			if len(s.samples) < maxIngestSamples {
+				s.samples = append(s.samples, v)
+			}
+			if s.count == 0 || v > s.max {
+				s.max = v
+			}
+			s.sum += v
+			s.count++
+		}
+	default:
+		return fmt.Errorf("unsupported statsd type %q", kind)
+	}
+	return nil
+}
+
+// addInflux parses measurement[,tag=value...] field=value[,field=value...] [timestamp].
+// Each numeric or boolean field becomes a gauge named measurement.field;
+// string fields are ignored and the timestamp is replaced by the time of
+// receipt.
+func (in *Ingest) addInflux(line string, now time.Time) error {
+	if strings.HasPrefix(line, "#") {
+		return nil // comment
+	}
+	parts := splitEscaped(line, ' ')
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid line protocol %q", line)
+	}
+
+	key := splitEscaped(parts[0], ',')
+	measurement := unescapeInflux(key[0])
+	if measurement == "" {
+		return fmt.Errorf("missing measurement in %q", line)
+	}
+	var tags []string
+	for _, tag := range key[1:] {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok {
+			return fmt.Errorf("invalid tag %q in %q", tag, line)
+		}
+		tags = append(tags, unescapeInflux(k)+"="+unescapeInflux(v))
+	}
+	suffix := formatTags(tags)
+
+	for _, field := range splitEscaped(parts[1], ',') {
+		k, raw, ok := strings.Cut(field, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid field %q in %q", field, line)
+		}
+		v, ok, err := parseInfluxValue(raw)
+		if err != nil {
+			return fmt.Errorf("invalid field %q in %q: %w", field, line, err)
+		}
+		if !ok {
+			continue
+		}
+		name := sanitizeMetricName(measurement+"."+unescapeInflux(k)) + suffix
+		if s := in.get(name, ingestInflux, "influx", now); s != nil {
+			s.value = v
+		}
+	}
+	return nil
+}
+
+// parseInfluxValue parses a field value. ok is false for string fields.
+func parseInfluxValue(raw string) (v float64, ok bool, err error) {
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, true, nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		return 0, false, nil
+	}
+	raw = strings.TrimSuffix(strings.TrimSuffix(raw, "i"), "u")
+	v, err = strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false, fmt.Errorf("not a number")
+	}
+	return v, true, nil
+}
+
+// splitEscaped splits s on sep, ignoring separators escaped with a backslash
+// or inside double quotes.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescapeInflux(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// formatTags renders key=value tags as a sorted "{k=v,...}" name suffix.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	sort.Strings(tags)
+	return "{" + strings.Join(tags, ",") + "}"
+}
+
+// get returns the series for name, creating it if needed. A series whose
+// type changed starts over. It returns nil when the series limit is reached.
+func (in *Ingest) get(name, kind, source string, now time.Time) *ingestSeries {
+	s, ok := in.series[name]
+	if !ok || s.kind != kind {
+		if !ok && len(in.series) >= maxIngestSeries {
+			in.full = true
+			return nil
+		}
+		s = &ingestSeries{kind: kind, source: source}
+		if kind == ingestSet {
+			s.set = make(map[string]struct{})
+		}
+		in.series[name] = s
+	}
+	s.lastSeen = now
+	return s
+}
+
+// flush returns the aggregated values since the previous flush, named
+// <source>.<name> and sorted by name, and starts a new interval.
+func (in *Ingest) flush(now time.Time) []CustomMetric {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if in.invalid > 0 {
+		log.Printf("metrics: ingest rejected %d lines, last: %s", in.invalid, in.lastError)
+		in.invalid = 0
+	}
+	if in.full {
+		log.Printf("metrics: ingest is tracking %d series, dropping new ones", maxIngestSeries)
+		in.full = false
+	}
+
+	secs := now.Sub(in.lastFlush).Seconds()
+	in.lastFlush = now
+
+	var result []CustomMetric
+	emit := func(name, source string, v float64, unit string) {
+		result = append(result, CustomMetric{Name: source + "." + name, Collector: source, Value: v, Unit: unit, Timestamp: now})
+	}
+	for name, s := range in.series {
+		if now.Sub(s.lastSeen) > ingestExpiry {
+			delete(in.series, name)
+			continue
+		}
+		switch s.kind {
+		case ingestCounter:
+			if secs > 0 {
+				emit(name, s.source, s.value/secs, "/s")
+			}
+			s.value = 0
+		case ingestGauge, ingestInflux:
+			emit(name, s.source, s.value, "")
+		case ingestSet:
+			emit(name, s.source, float64(len(s.set)), "")
+			s.set = make(map[string]struct{})
+		default: // timers, histograms, distributions
+			if s.count == 0 {
+				continue
+			}
+			unit := ""
+			if s.kind == ingestTimer {
+				unit = "ms"
+			}
+			emit(name+".mean", s.source, s.sum/float64(s.count), unit)
+			emit(name+".p95", s.source, percentile(s.samples, 0.95), unit)
+			emit(name+".max", s.source, s.max, unit)
+			s.samples, s.sum, s.max, s.count = s.samples[:0], 0, 0, 0
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// percentile returns the nearest-rank percentile of values, sorting them in place.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	idx := int(math.Ceil(p*float64(len(values)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return values[idx]
+}
+
+// SetIngest adds the metrics pushed to in since the previous reading to every
+// reading. It must be called before the reader is used.
+func (r *SystemReader) SetIngest(in *Ingest) {
+	r.sections = append(r.sections, readerSection{
+		name: "ingest",
+		read: func(_ context.Context, s *Snapshot, now time.Time) error {
+			s.Custom = in.flush(now)
+			return nil
+		},
+		merge: func(dst, src *Snapshot) { dst.Custom = append(dst.Custom, src.Custom...) },
+	})
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ingestValues flushes in at now and returns the values by name.
+func ingestValues(in *Ingest, now time.Time) map[string]CustomMetric {
+	values := make(map[string]CustomMetric)
+	for _, m := range in.flush(now) {
+		values[m.Name] = m
+	}
+	return values
+}
+
+func TestIngest_StatsDCounter(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	in := NewIngest()
+	in.lastFlush = start
+
+	in.handlePacket("app.requests:10|c\napp.requests:5|c|@0.5", start.Add(time.Second))
+	values := ingestValues(in, start.Add(5*time.Second))
+	// (10 + 5/0.5) over 5s
+	assert.Equal(t, CustomMetric{Name: "statsd.app.requests", Collector: "statsd", Value: 4, Unit: "/s", Timestamp: start.Add(5 * time.Second)}, values["statsd.app.requests"])
+
+	values = ingestValues(in, start.Add(10*time.Second))
+	assert.Equal(t, 0.0, values["statsd.app.requests"].Value, "an idle counter reports 0")
+}
+
+func TestIngest_StatsDGauge(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	in.handlePacket("queue.depth:40|g\nqueue.depth:+2|g\nqueue.depth:-12|g", now)
+	assert.Equal(t, 30.0, ingestValues(in, now)["statsd.queue.depth"].Value)
+	assert.Equal(t, 30.0, ingestValues(in, now.Add(time.Second))["statsd.queue.depth"].Value, "gauges keep their value")
+
+	in.handlePacket("queue.depth:7|g", now)
+	assert.Equal(t, 7.0, ingestValues(in, now.Add(time.Second))["statsd.queue.depth"].Value)
+}
+
+func TestIngest_StatsDTimer(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	var packet string
+	for i := 1; i <= 20; i++ {
+		packet += "db.query:" + strconv.Itoa(i*10) + "|ms\n"
+	}
+	in.handlePacket(packet, now)
+
+	values := ingestValues(in, now.Add(time.Second))
+	assert.Equal(t, 105.0, values["statsd.db.query.mean"].Value)
+	assert.Equal(t, 190.0, values["statsd.db.query.p95"].Value)
+	assert.Equal(t, 200.0, values["statsd.db.query.max"].Value)
+	assert.Equal(t, "ms", values["statsd.db.query.max"].Unit)
+
+	values = ingestValues(in, now.Add(2*time.Second))
+	assert.NotContains(t, values, "statsd.db.query.mean", "no samples, no value")
+}
+
+func TestIngest_StatsDSetAndTags(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	in.handlePacket("app.users:alice|s\napp.users:bob|s\napp.users:alice|s\nhttp.hits:1|c|#status:200,method:GET", now)
+	values := ingestValues(in, now.Add(time.Second))
+	assert.Equal(t, 2.0, values["statsd.app.users"].Value)
+	assert.Contains(t, values, "statsd.http.hits{method=GET,status=200}")
+}
+
+func TestIngest_Influx(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	in.handlePacket(`room,sensor=kitchen temp=21.5,humidity=48i,door=t,label="north wall" 1700000000000000000
+ups battery=87u
+# a comment
+my\ app,host=pi\ 1 value=3`, now)
+	values := ingestValues(in, now.Add(time.Second))
+
+	assert.Equal(t, CustomMetric{Name: "influx.room.temp{sensor=kitchen}", Collector: "influx", Value: 21.5, Timestamp: now.Add(time.Second)}, values["influx.room.temp{sensor=kitchen}"])
+	assert.Equal(t, 48.0, values["influx.room.humidity{sensor=kitchen}"].Value)
+	assert.Equal(t, 1.0, values["influx.room.door{sensor=kitchen}"].Value)
+	assert.NotContains(t, values, "influx.room.label{sensor=kitchen}", "string fields are skipped")
+	assert.Equal(t, 87.0, values["influx.ups.battery"].Value)
+	assert.Equal(t, 3.0, values["influx.my_app.value{host=pi 1}"].Value)
+	assert.Len(t, values, 5)
+}
+
+func TestIngest_InvalidLines(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	in.handlePacket("nocolon|c\nx:abc|c\nx:1|zz\nx:1|c|@2\nmeasurement_only\nm bad\nm f=notanumber", now)
+	assert.Equal(t, 7, in.invalid)
+	assert.Empty(t, in.flush(now))
+	assert.Equal(t, 0, in.invalid, "reset after being logged")
+}
+
+func TestIngest_SeriesLimitAndExpiry(t *testing.T) {
+	now := time.Now()
+	in := NewIngest()
+
+	for i := 0; i < maxIngestSeries+10; i++ {
+		in.handlePacket("s"+strconv.Itoa(i)+":1|g", now)
+	}
+	assert.Len(t, in.series, maxIngestSeries)
+	assert.True(t, in.full)
+
+	assert.Len(t, in.flush(now.Add(time.Second)), maxIngestSeries)
+	assert.Empty(t, in.flush(now.Add(ingestExpiry+time.Minute)), "idle series expire")
+	assert.Empty(t, in.series)
+}
+
+func TestIngest_ListenUDP(t *testing.T) {
+	in := NewIngest()
+	require.NoError(t, in.Listen("127.0.0.1:0"))
+	defer in.Close()
+
+	conn, err := net.Dial("udp", in.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	r := fakeSections(time.Second)
+	r.SetIngest(in)
+
+	require.Eventually(t, func() bool {
+		conn.Write([]byte("pi.fan_rpm:2400|g"))
+		s, err := r.Read(context.Background())
+		return err == nil && len(s.Custom) == 1 && s.Custom[0].Value == 2400
+	}, 5*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readZram sums the mm_stat counters of every zram device under sysBlockDir.
+// It returns nil when there is no zram device.
+func readZram(sysBlockDir string) *ZramStats {
+	paths, _ := filepath.Glob(filepath.Join(sysBlockDir, "zram*", "mm_stat"))
+	if len(paths) == 0 {
+		return nil
+	}
+
+	z := &ZramStats{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// orig_data_size compr_data_size mem_used_total mem_limit ...
+		fields := strings.Fields(string(data))
+		if len(fields) < 3 {
+			continue
+		}
+		orig, err1 := strconv.ParseUint(fields[0], 10, 64)
+		compr, err2 := strconv.ParseUint(fields[1], 10, 64)
+		used, err3 := strconv.ParseUint(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		z.Devices++
+		z.OrigDataSize += orig
+		z.ComprDataSize += compr
+		z.MemUsedTotal += used
+	}
+	if z.Devices == 0 {
+		return nil
+	}
+	if z.ComprDataSize > 0 {
+		z.CompressionRatio = float64(z.OrigDataSize) / float64(z.ComprDataSize)
+	}
+	return z
+}
+
+// readMemoryPressure parses <procDir>/pressure/memory, e.g.
+//
+//	some avg10=0.00 avg60=0.12 avg300=0.05 total=123456
+//	full avg10=0.00 avg60=0.03 avg300=0.01 total=65432
+func readMemoryPressure(procDir string) (*MemoryPressure, error) {
+	f, err := os.Open(filepath.Join(procDir, "pressure", "memory"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := &MemoryPressure{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var avg10, avg60, avg300 *float64
+		switch fields[0] {
+		case "some":
+			avg10, avg60, avg300 = &p.SomeAvg10, &p.SomeAvg60, &p.SomeAvg300
+		case "full":
+			avg10, avg60, avg300 = &p.FullAvg10, &p.FullAvg60, &p.FullAvg300
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "avg10":
+				*avg10 = v
+			case "avg60":
+				*avg60 = v
+			case "avg300":
+				*avg300 = v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeZramDevice(t *testing.T, dir, name, mmStat string) {
+	t.Helper()
+	devDir := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(devDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(devDir, "mm_stat"), []byte(mmStat), 0644))
+}
+
+func TestReadZram(t *testing.T) {
+	dir := t.TempDir()
+	writeZramDevice(t, dir, "zram0", "  4096000  1024000  1200000        0  1200000      12     0     0     0\n")
+	writeZramDevice(t, dir, "zram1", "  2048000   512000   600000        0   600000       0     0     0     0\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sda"), 0755))
+
+	z := readZram(dir)
+	require.NotNil(t, z)
+	assert.Equal(t, 2, z.Devices)
+	assert.Equal(t, uint64(6144000), z.OrigDataSize)
+	assert.Equal(t, uint64(1536000), z.ComprDataSize)
+	assert.Equal(t, uint64(1800000), z.MemUsedTotal)
+	assert.InDelta(t, 4.0, z.CompressionRatio, 0.001)
+}
+
+func TestReadZram_EmptyDevice(t *testing.T) {
+	dir := t.TempDir()
+	writeZramDevice(t, dir, "zram0", "0 0 0 0 0 0 0 0 0\n")
+
+	z := readZram(dir)
+	require.NotNil(t, z)
+	assert.Equal(t, 1, z.Devices)
+	assert.Equal(t, 0.0, z.CompressionRatio)
+}
+
+func TestReadZram_None(t *testing.T) {
+	assert.Nil(t, readZram(t.TempDir()))
+	assert.Nil(t, readZram(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestReadMemoryPressure(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pressure"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pressure", "memory"), []byte(
+		"some avg10=1.50 avg60=0.75 avg300=0.20 total=123456\n"+
+			"full avg10=0.40 avg60=0.10 avg300=0.05 total=65432\n"), 0644))
+
+	p, err := readMemoryPressure(dir)
+	require.NoError(t, err)
+	assert.Equal(t, MemoryPressure{
+		SomeAvg10: 1.5, SomeAvg60: 0.75, SomeAvg300: 0.2,
+		FullAvg10: 0.4, FullAvg60: 0.1, FullAvg300: 0.05,
+	}, *p)
+}
+
+func TestReadMemoryPressure_Unsupported(t *testing.T) {
+	_, err := readMemoryPressure(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestSystemReader_ReadRAMBreakdown(t *testing.T) {
+	r := NewSystemReader()
+	r.sysBlockDir = t.TempDir()
+	writeZramDevice(t, r.sysBlockDir, "zram0", "3000 1000 1100 0 1100 0 0 0 0\n")
+
+	s := &Snapshot{}
+	r.readRAM(context.Background(), s)
+	assert.Greater(t, s.RAM.Total, uint64(0))
+	assert.LessOrEqual(t, s.RAM.SwapUsed, s.RAM.SwapTotal)
+	require.NotNil(t, s.RAM.Zram)
+	assert.InDelta(t, 3.0, s.RAM.Zram.CompressionRatio, 0.001)
+}
@@ -4,18 +4,86 @@ import "time"
 
 // Snapshot captures all system metrics at a single point in time.
 type Snapshot struct {
-	Timestamp   time.Time       `json:"timestamp"`
-	CPU         CPUMetrics      `json:"cpu"`
-	RAM         RAMMetrics      `json:"ram"`
-	Disks       []DiskPartition `json:"disks"`
-	Networks    []NetworkIface  `json:"networks"`
-	Temperature *float64        `json:"temperature"` // nil if sensor unavailable
+	Timestamp    time.Time       `json:"timestamp"`
+	CPU          CPUMetrics      `json:"cpu"`
+	RAM          RAMMetrics      `json:"ram"`
+	Disks        []DiskPartition `json:"disks"`
+	DiskIO       []DiskIO        `json:"disk_io"`
+	Networks     []NetworkIface  `json:"networks"`
+	Temperature  *float64        `json:"temperature"`  // primary sensor; nil if none available
+	PrimaryTemp  string          `json:"primary_temp"` // label of the primary sensor
+	Temperatures []TempSensor    `json:"temperatures"` // every sensor with a valid reading
+	Pi           *PiStatus       `json:"pi"`           // nil if not a Raspberry Pi
+	TopCPU       []ProcessInfo   `json:"top_cpu"`      // highest CPU users, descending
+	TopRAM       []ProcessInfo   `json:"top_ram"`      // highest RSS users, descending
+	System       SystemCounters  `json:"system"`
+	Custom       []CustomMetric  `json:"custom"` // from custom collectors and pushed metrics
+	Errors       []ReaderError   `json:"errors"` // sections that failed or timed out
 }
 
-// CPUMetrics holds CPU usage percentages.
+// ReaderError describes a section of a reading that failed or timed out.
+// When Stale is set the section holds the values from LastSuccess instead of zeros.
+type ReaderError struct {
+	Reader      string        `json:"reader"` // e.g. "disks", "network"
+	Error       string        `json:"error"`
+	Duration    time.Duration `json:"duration"`
+	TimedOut    bool          `json:"timed_out"`
+	Stale       bool          `json:"stale"`
+	LastSuccess time.Time     `json:"last_success"` // zero if it never succeeded
+}
+
+// CustomMetric is a value produced by a custom collector (see
+// CustomCollectors) or pushed over StatsD or line protocol (see Ingest).
+type CustomMetric struct {
+	Name      string    `json:"name"`      // e.g. "ups.battery"
+	Collector string    `json:"collector"` // collector name, "statsd" or "influx"
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`      // as reported, e.g. "%"; may be empty
+	Timestamp time.Time `json:"timestamp"` // when the collector produced it
+}
+
+// ErrorFor returns the error recorded for the named section, or nil.
+func (s *Snapshot) ErrorFor(reader string) *ReaderError {
+	for i := range s.Errors {
+		if s.Errors[i].Reader == reader {
+			return &s.Errors[i]
+		}
+	}
+	return nil
+}
+
+// CPUMetrics holds CPU usage percentages and load averages.
 type CPUMetrics struct {
 	TotalPercent float64   `json:"total_percent"`
 	PerCore      []float64 `json:"per_core"`
+	Times        CPUTimes  `json:"times"` // zero on the first reading
+	Load1        float64   `json:"load1"`
+	Load5        float64   `json:"load5"`
+	Load15       float64   `json:"load15"`
+}
+
+// CPUTimes is the share of CPU time spent in each mode since the previous
+// reading, as percentages of all cores combined.
+type CPUTimes struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Idle    float64 `json:"idle"`
+	IOWait  float64 `json:"iowait"`
+	IRQ     float64 `json:"irq"`
+	SoftIRQ float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
+}
+
+// SystemCounters holds kernel-wide activity counters and host uptime.
+type SystemCounters struct {
+	ContextSwitchesPS float64 `json:"context_switches_ps"` // 0 on the first reading
+	InterruptsPS      float64 `json:"interrupts_ps"`       // 0 on the first reading
+	Processes         int     `json:"processes"`
+	Threads           int     `json:"threads"`
+	ProcsRunning      int     `json:"procs_running"`
+	ProcsBlocked      int     `json:"procs_blocked"` // waiting on I/O
+	UptimeSeconds     uint64  `json:"uptime_seconds"`
 }
 
 // RAMMetrics holds memory usage data.
@@ -24,20 +92,129 @@ type RAMMetrics struct {
 	Used      uint64  `json:"used"`
 	Available uint64  `json:"available"`
 	Percent   float64 `json:"percent"`
+	Buffers   uint64  `json:"buffers"`
+	Cached    uint64  `json:"cached"`
+	Dirty     uint64  `json:"dirty"`     // waiting to be written back to disk
+	Writeback uint64  `json:"writeback"` // being written back to disk now
+
+	SwapTotal   uint64  `json:"swap_total"`
+	SwapUsed    uint64  `json:"swap_used"`
+	SwapPercent float64 `json:"swap_percent"`
+
+	Zram     *ZramStats      `json:"zram"`     // nil if no zram device is in use
+	Pressure *MemoryPressure `json:"pressure"` // nil if the kernel has no PSI support
+}
+
+// ZramStats aggregates all zram devices.
+type ZramStats struct {
+	Devices          int     `json:"devices"`
+	OrigDataSize     uint64  `json:"orig_data_size"`  // uncompressed size of the stored data
+	ComprDataSize    uint64  `json:"compr_data_size"` // compressed size of the stored data
+	MemUsedTotal     uint64  `json:"mem_used_total"`  // RAM used including allocator overhead
+	CompressionRatio float64 `json:"compression_ratio"`
+}
+
+// MemoryPressure holds the PSI averages from /proc/pressure/memory: the share
+// of time some (or all) tasks were stalled waiting for memory.
+type MemoryPressure struct {
+	SomeAvg10  float64 `json:"some_avg10"`
+	SomeAvg60  float64 `json:"some_avg60"`
+	SomeAvg300 float64 `json:"some_avg300"`
+	FullAvg10  float64 `json:"full_avg10"`
+	FullAvg60  float64 `json:"full_avg60"`
+	FullAvg300 float64 `json:"full_avg300"`
 }
 
 // DiskPartition holds usage data for a single mounted partition.
 type DiskPartition struct {
-	Path    string  `json:"path"`
-	Total   uint64  `json:"total"`
-	Used    uint64  `json:"used"`
-	Free    uint64  `json:"free"`
-	Percent float64 `json:"percent"`
+	Path          string  `json:"path"`
+	Device        string  `json:"device"`
+	Fstype        string  `json:"fstype"`
+	Total         uint64  `json:"total"`
+	Used          uint64  `json:"used"`
+	Free          uint64  `json:"free"`
+	Percent       float64 `json:"percent"`
+	InodesTotal   uint64  `json:"inodes_total"`
+	InodesUsed    uint64  `json:"inodes_used"`
+	InodesFree    uint64  `json:"inodes_free"`
+	InodesPercent float64 `json:"inodes_percent"`
+
+	// FullInHours estimates when the filesystem fills up at its current
+	// growth trend. Nil when usage is not growing or history is too short.
+	FullInHours *float64 `json:"full_in_hours,omitempty"`
 }
 
-// NetworkIface holds network I/O rates for a single interface.
+// DiskIO holds I/O rates for a single block device, computed from the
+// difference between two consecutive counter readings.
+type DiskIO struct {
+	Device       string  `json:"device"`
+	ReadBytesPS  uint64  `json:"read_bytes_ps"`  // bytes per second read
+	WriteBytesPS uint64  `json:"write_bytes_ps"` // bytes per second written
+	ReadIOPS     float64 `json:"read_iops"`
+	WriteIOPS    float64 `json:"write_iops"`
+	BusyPercent  float64 `json:"busy_percent"` // share of time with I/O in flight
+	AwaitMs      float64 `json:"await_ms"`     // average time per completed request
+}
+
+// NetworkIface holds traffic rates, cumulative counters and link details for
+// a single interface. Rates are 0 on the first reading.
 type NetworkIface struct {
-	Name        string `json:"name"`
-	BytesSentPS uint64 `json:"bytes_sent_ps"` // bytes per second sent
-	BytesRecvPS uint64 `json:"bytes_recv_ps"` // bytes per second received
+	Name          string  `json:"name"`
+	BytesSentPS   uint64  `json:"bytes_sent_ps"` // bytes per second sent
+	BytesRecvPS   uint64  `json:"bytes_recv_ps"` // bytes per second received
+	PacketsSentPS float64 `json:"packets_sent_ps"`
+	PacketsRecvPS float64 `json:"packets_recv_ps"`
+	ErrorsInPS    float64 `json:"errors_in_ps"`
+	ErrorsOutPS   float64 `json:"errors_out_ps"`
+	DropsInPS     float64 `json:"drops_in_ps"`
+	DropsOutPS    float64 `json:"drops_out_ps"`
+
+	// Totals since boot (or since the interface was created)
+	BytesSent   uint64 `json:"bytes_sent"`
+	BytesRecv   uint64 `json:"bytes_recv"`
+	PacketsSent uint64 `json:"packets_sent"`
+	PacketsRecv uint64 `json:"packets_recv"`
+	ErrorsIn    uint64 `json:"errors_in"`
+	ErrorsOut   uint64 `json:"errors_out"`
+	DropsIn     uint64 `json:"drops_in"`
+	DropsOut    uint64 `json:"drops_out"`
+
+	OperState string   `json:"operstate"` // "up", "down", "unknown", ...; empty if unavailable
+	Carrier   bool     `json:"carrier"`   // physical link detected
+	MTU       int      `json:"mtu"`
+	SpeedMbps int      `json:"speed_mbps"` // 0 if unknown, e.g. on wireless links
+	MAC       string   `json:"mac"`
+	Addrs     []string `json:"addrs"` // IPv4 and IPv6 addresses in CIDR notation
+}
+
+// TempSensor holds the reading of a single temperature sensor.
+type TempSensor struct {
+	Label   string  `json:"label"`
+	Celsius float64 `json:"celsius"`
+}
+
+// PiStatus holds Raspberry Pi firmware state: the decoded throttled bitmask,
+// ARM clock, and core voltage.
+type PiStatus struct {
+	ThrottledMask          uint32  `json:"throttled_mask"`
+	UnderVoltage           bool    `json:"under_voltage"`
+	FreqCapped             bool    `json:"freq_capped"`
+	Throttled              bool    `json:"throttled"`
+	SoftTempLimit          bool    `json:"soft_temp_limit"`
+	UnderVoltageSinceBoot  bool    `json:"under_voltage_since_boot"`
+	FreqCappedSinceBoot    bool    `json:"freq_capped_since_boot"`
+	ThrottledSinceBoot     bool    `json:"throttled_since_boot"`
+	SoftTempLimitSinceBoot bool    `json:"soft_temp_limit_since_boot"`
+	ARMClockHz             uint64  `json:"arm_clock_hz"` // 0 if unavailable
+	CoreVolts              float64 `json:"core_volts"`   // 0 if unavailable
+}
+
+// ProcessInfo holds resource usage for a single process.
+type ProcessInfo struct {
+	PID        int32   `json:"pid"`
+	Name       string  `json:"name"`
+	Cmdline    string  `json:"cmdline"`
+	User       string  `json:"user"`
+	CPUPercent float64 `json:"cpu_percent"` // 100 = one full core
+	RSS        uint64  `json:"rss"`         // resident set size in bytes
 }
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// DefaultExcludeInterfaces are the interfaces skipped unless configured
+// otherwise: loopback and the virtual links Docker creates per container and
+// network.
+var DefaultExcludeInterfaces = []string{"lo", "veth*", "docker*", "br-*"}
+
+// NetFilter decides which network interfaces are reported. Names match
+// exactly or as path.Match globs such as "veth*". An empty include list keeps
+// everything; excludes always win.
+type NetFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// DefaultNetFilter returns the filter used when nothing is configured.
+func DefaultNetFilter() NetFilter {
+	return NetFilter{Exclude: DefaultExcludeInterfaces}
+}
+
+// Keep reports whether the interface name is reported.
+func (f NetFilter) Keep(name string) bool {
+	if len(f.Include) > 0 && !matchesPattern(f.Include, name) {
+		return false
+	}
+	return !matchesPattern(f.Exclude, name)
+}
+
+// prevNetCounters stores the previous network I/O counters for rate calculation.
+type prevNetCounters struct {
+	stat      net.IOCountersStat
+	timestamp time.Time
+}
+
+func (r *SystemReader) readNetwork(ctx context.Context, s *Snapshot, now time.Time) error {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return fmt.Errorf("cannot read network counters: %w", err)
+	}
+
+	// Interface details are best effort; counters are still reported without them.
+	details := make(map[string]net.InterfaceStat)
+	if ifaces, err := net.InterfacesWithContext(ctx); err == nil {
+		for _, i := range ifaces {
+			details[i.Name] = i
+		}
+	} else {
+		log.Printf("metrics: failed to list network interfaces: %v", err)
+	}
+
+	r.prevNetMu.Lock()
+	defer r.prevNetMu.Unlock()
+
+	for _, c := range counters {
+		if !r.netFilter.Keep(c.Name) {
+			continue
+		}
+
+		iface := NetworkIface{
+			Name:        c.Name,
+			BytesSent:   c.BytesSent,
+			BytesRecv:   c.BytesRecv,
+			PacketsSent: c.PacketsSent,
+			PacketsRecv: c.PacketsRecv,
+			ErrorsIn:    c.Errin,
+			ErrorsOut:   c.Errout,
+			DropsIn:     c.Dropin,
+			DropsOut:    c.Dropout,
+		}
+		if prev, ok := r.prevNet[c.Name]; ok {
+			netRates(&iface, prev.stat, c, now.Sub(prev.timestamp))
+		}
+		// First reading: rates stay 0
+
+		r.prevNet[c.Name] = prevNetCounters{stat: c, timestamp: now}
+
+		if d, ok := details[c.Name]; ok {
+			iface.MTU = d.MTU
+			iface.MAC = d.HardwareAddr
+			for _, a := range d.Addrs {
+				iface.Addrs = append(iface.Addrs, a.Addr)
+			}
+		}
+		r.readLinkState(&iface)
+
+		s.Networks = append(s.Networks, iface)
+	}
+	return nil
+}
+
+// netRates fills the per-second rates of iface from two counter readings.
+func netRates(iface *NetworkIface, prev, cur net.IOCountersStat, elapsed time.Duration) {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return
+	}
+	rate := func(cur, prev uint64) float64 { return float64(counterDelta(cur, prev)) / secs }
+
+	iface.BytesSentPS = uint64(rate(cur.BytesSent, prev.BytesSent))
+	iface.BytesRecvPS = uint64(rate(cur.BytesRecv, prev.BytesRecv))
+	iface.PacketsSentPS = rate(cur.PacketsSent, prev.PacketsSent)
+	iface.PacketsRecvPS = rate(cur.PacketsRecv, prev.PacketsRecv)
+	iface.ErrorsInPS = rate(cur.Errin, prev.Errin)
+	iface.ErrorsOutPS = rate(cur.Errout, prev.Errout)
+	iface.DropsInPS = rate(cur.Dropin, prev.Dropin)
+	iface.DropsOutPS = rate(cur.Dropout, prev.Dropout)
+}
+
+// readLinkState reads operstate, carrier and speed from <sysNetDir>/<name>.
+// These files only exist on Linux; elsewhere the fields stay empty.
+func (r *SystemReader) readLinkState(iface *NetworkIface) {
+	dir := filepath.Join(r.sysNetDir, iface.Name)
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	iface.OperState = read("operstate")
+	// Reading carrier fails with EINVAL while the interface is down.
+	iface.Carrier = read("carrier") == "1"
+	// speed is -1 or unreadable when unknown (wireless, virtual, no link).
+	if speed, err := strconv.Atoi(read("speed")); err == nil && speed > 0 {
+		iface.SpeedMbps = speed
+	}
+}
+
+// SetNetFilter replaces the rules deciding which network interfaces are
+// reported. It must be called before the reader is used.
+func (r *SystemReader) SetNetFilter(f NetFilter) {
+	r.netFilter = f
+}
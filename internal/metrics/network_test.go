@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetFilter_Defaults(t *testing.T) {
+	f := DefaultNetFilter()
+
+	assert.True(t, f.Keep("eth0"))
+	assert.True(t, f.Keep("wlan0"))
+	assert.False(t, f.Keep("lo"))
+	assert.False(t, f.Keep("veth3f2a1b"))
+	assert.False(t, f.Keep("docker0"))
+	assert.False(t, f.Keep("br-5c1e0d9a7f21"))
+}
+
+func TestNetFilter_Include(t *testing.T) {
+	f := NetFilter{Include: []string{"eth*", "wlan0"}, Exclude: []string{"eth1"}}
+
+	assert.True(t, f.Keep("eth0"))
+	assert.True(t, f.Keep("wlan0"))
+	assert.False(t, f.Keep("eth1"), "excludes win over includes")
+	assert.False(t, f.Keep("wg0"))
+}
+
+func TestNetRates(t *testing.T) {
+	prev := net.IOCountersStat{BytesSent: 1000, BytesRecv: 2000, PacketsSent: 10, PacketsRecv: 20, Errin: 1, Dropout: 5}
+	cur := net.IOCountersStat{BytesSent: 3000, BytesRecv: 6000, PacketsSent: 30, PacketsRecv: 60, Errin: 11, Errout: 4, Dropout: 9}
+
+	var iface NetworkIface
+	netRates(&iface, prev, cur, 2*time.Second)
+	assert.Equal(t, uint64(1000), iface.BytesSentPS)
+	assert.Equal(t, uint64(2000), iface.BytesRecvPS)
+	assert.Equal(t, 10.0, iface.PacketsSentPS)
+	assert.Equal(t, 20.0, iface.PacketsRecvPS)
+	assert.Equal(t, 5.0, iface.ErrorsInPS)
+	assert.Equal(t, 2.0, iface.ErrorsOutPS)
+	assert.Equal(t, 0.0, iface.DropsInPS)
+	assert.Equal(t, 2.0, iface.DropsOutPS)
+}
+
+func TestNetRates_CounterReset(t *testing.T) {
+	prev := net.IOCountersStat{BytesSent: 5000, Errin: 100}
+	cur := net.IOCountersStat{BytesSent: 10, Errin: 0}
+
+	var iface NetworkIface
+	netRates(&iface, prev, cur, time.Second)
+	assert.Equal(t, uint64(0), iface.BytesSentPS)
+	assert.Equal(t, 0.0, iface.ErrorsInPS)
+}
+
+func writeLinkState(t *testing.T, dir, name string, files map[string]string) {
+	t.Helper()
+	ifDir := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(ifDir, 0755))
+	for file, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(ifDir, file), []byte(content), 0644))
+	}
+}
+
+func TestReadLinkState(t *testing.T) {
+	r := NewSystemReader()
+	r.sysNetDir = t.TempDir()
+	writeLinkState(t, r.sysNetDir, "eth0", map[string]string{"operstate": "up\n", "carrier": "1\n", "speed": "1000\n"})
+	writeLinkState(t, r.sysNetDir, "wlan0", map[string]string{"operstate": "down\n", "speed": "-1\n"})
+
+	eth := NetworkIface{Name: "eth0"}
+	r.readLinkState(&eth)
+	assert.Equal(t, "up", eth.OperState)
+	assert.True(t, eth.Carrier)
+	assert.Equal(t, 1000, eth.SpeedMbps)
+
+	wlan := NetworkIface{Name: "wlan0"}
+	r.readLinkState(&wlan)
+	assert.Equal(t, "down", wlan.OperState)
+	assert.False(t, wlan.Carrier, "carrier is unreadable while down")
+	assert.Equal(t, 0, wlan.SpeedMbps)
+
+	missing := NetworkIface{Name: "eth9"}
+	r.readLinkState(&missing)
+	assert.Equal(t, "", missing.OperState)
+}
+
+func TestSystemReader_ReadNetworkDetails(t *testing.T) {
+	r := NewSystemReader()
+	r.SetNetFilter(NetFilter{})
+	s := &Snapshot{}
+	r.readNetwork(context.Background(), s, time.Now())
+
+	var lo *NetworkIface
+	for i := range s.Networks {
+		if s.Networks[i].Name == "lo" {
+			lo = &s.Networks[i]
+		}
+	}
+	if lo == nil {
+		t.Skip("no loopback interface")
+	}
+	assert.Greater(t, lo.MTU, 0)
+	assert.NotEmpty(t, lo.Addrs)
+}
+
+func TestSystemReader_ReadNetworkSkipsLoopback(t *testing.T) {
+	r := NewSystemReader()
+	s := &Snapshot{}
+	r.readNetwork(context.Background(), s, time.Now())
+	for _, n := range s.Networks {
+		assert.NotEqual(t, "lo", n.Name)
+	}
+}
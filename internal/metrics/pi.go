@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Bits of the firmware throttled mask reported by `vcgencmd get_throttled`.
+const (
+	throttledUnderVoltage    = 1 << 0
+	throttledFreqCapped      = 1 << 1
+	throttledThrottled       = 1 << 2
+	throttledSoftTempLimit   = 1 << 3
+	throttledUnderVoltageOcc = 1 << 16
+	throttledFreqCappedOcc   = 1 << 17
+	throttledThrottledOcc    = 1 << 18
+	throttledSoftTempOcc     = 1 << 19
+)
+
+// PiSource reads Raspberry Pi firmware state.
+type PiSource interface {
+	Read(ctx context.Context) (*PiStatus, error)
+}
+
+// VcgencmdSource implements PiSource using the vcgencmd firmware tool.
+type VcgencmdSource struct {
+	path string
+}
+
+// NewVcgencmdSource returns a source backed by vcgencmd, or nil if the tool
+// is not installed (i.e. the host is not a Raspberry Pi).
+func NewVcgencmdSource() *VcgencmdSource {
+	path, err := exec.LookPath("vcgencmd")
+	if err != nil {
+		return nil
+	}
+	return &VcgencmdSource{path: path}
+}
+
+// Read queries the throttled mask, ARM clock, and core voltage.
+func (v *VcgencmdSource) Read(ctx context.Context) (*PiStatus, error) {
+	out, err := v.run(ctx, "get_throttled")
+	if err != nil {
+		return nil, err
+	}
+	mask, err := parseThrottled(out)
+	if err != nil {
+		return nil, err
+	}
+	status := decodeThrottled(mask)
+
+	// Clock and voltage are best effort; the throttled mask is what matters.
+	if out, err := v.run(ctx, "measure_clock", "arm"); err == nil {
+		status.ARMClockHz, _ = parseClock(out)
+	}
+	if out, err := v.run(ctx, "measure_volts", "core"); err == nil {
+		status.CoreVolts, _ = parseVolts(out)
+	}
+	return status, nil
+}
+
+func (v *VcgencmdSource) run(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, v.path, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("vcgencmd %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// decodeThrottled expands the firmware throttled bitmask.
+func decodeThrottled(mask uint32) *PiStatus {
+	return &PiStatus{
+		ThrottledMask:          mask,
+		UnderVoltage:           mask&throttledUnderVoltage != 0,
+		FreqCapped:             mask&throttledFreqCapped != 0,
+		Throttled:              mask&throttledThrottled != 0,
+		SoftTempLimit:          mask&throttledSoftTempLimit != 0,
+		UnderVoltageSinceBoot:  mask&throttledUnderVoltageOcc != 0,
+		FreqCappedSinceBoot:    mask&throttledFreqCappedOcc != 0,
+		ThrottledSinceBoot:     mask&throttledThrottledOcc != 0,
+		SoftTempLimitSinceBoot: mask&throttledSoftTempOcc != 0,
+	}
+}
+
+// parseThrottled parses "throttled=0x50005".
+func parseThrottled(out string) (uint32, error) {
+	v, err := vcgencmdValue(out, "throttled")
+	if err != nil {
+		return 0, err
+	}
+	mask, err := strconv.ParseUint(strings.TrimPrefix(v, "0x"), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid throttled value %q: %w", v, err)
+	}
+	return uint32(mask), nil
+}
+
+// parseClock parses "frequency(48)=1500398464" into Hz.
+func parseClock(out string) (uint64, error) {
+	out = strings.TrimSpace(out)
+	key, hz, ok := strings.Cut(out, "=")
+	if !ok || !strings.HasPrefix(key, "frequency(") {
+		return 0, fmt.Errorf("unexpected vcgencmd output %q", out)
+	}
+	return strconv.ParseUint(hz, 10, 64)
+}
+
+// parseVolts parses "volt=0.8600V".
+func parseVolts(out string) (float64, error) {
+	v, err := vcgencmdValue(out, "volt")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSuffix(v, "V"), 64)
+}
+
+// vcgencmdValue returns the value of a "key=value" line.
+func vcgencmdValue(out, key string) (string, error) {
+	out = strings.TrimSpace(out)
+	k, v, ok := strings.Cut(out, "=")
+	if !ok || k != key {
+		return "", fmt.Errorf("unexpected vcgencmd output %q", out)
+	}
+	return v, nil
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePiSource returns a fixed status so Pi handling can be tested anywhere.
+type fakePiSource struct {
+	status *PiStatus
+	err    error
+}
+
+func (f *fakePiSource) Read(_ context.Context) (*PiStatus, error) {
+	return f.status, f.err
+}
+
+func TestDecodeThrottled_Clean(t *testing.T) {
+	s := decodeThrottled(0)
+	assert.Equal(t, PiStatus{}, *s)
+}
+
+func TestDecodeThrottled_UnderVoltageNowAndSinceBoot(t *testing.T) {
+	// 0x50005: under-voltage and throttled now, both also since boot
+	s := decodeThrottled(0x50005)
+	assert.Equal(t, uint32(0x50005), s.ThrottledMask)
+	assert.True(t, s.UnderVoltage)
+	assert.False(t, s.FreqCapped)
+	assert.True(t, s.Throttled)
+	assert.False(t, s.SoftTempLimit)
+	assert.True(t, s.UnderVoltageSinceBoot)
+	assert.False(t, s.FreqCappedSinceBoot)
+	assert.True(t, s.ThrottledSinceBoot)
+	assert.False(t, s.SoftTempLimitSinceBoot)
+}
+
+func TestDecodeThrottled_SinceBootOnly(t *testing.T) {
+	// 0xa0000: frequency capped and soft temp limit occurred, nothing active now
+	s := decodeThrottled(0xa0000)
+	assert.False(t, s.UnderVoltage)
+	assert.False(t, s.FreqCapped)
+	assert.True(t, s.FreqCappedSinceBoot)
+	assert.True(t, s.SoftTempLimitSinceBoot)
+}
+
+func TestParseThrottled(t *testing.T) {
+	mask, err := parseThrottled("throttled=0x50005\n")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x50005), mask)
+
+	mask, err = parseThrottled("throttled=0x0")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), mask)
+
+	_, err = parseThrottled("VCHI initialization failed")
+	assert.Error(t, err)
+
+	_, err = parseThrottled("throttled=0xzz")
+	assert.Error(t, err)
+}
+
+func TestParseClock(t *testing.T) {
+	hz, err := parseClock("frequency(48)=1500398464\n")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1500398464), hz)
+
+	_, err = parseClock("volt=0.8600V")
+	assert.Error(t, err)
+}
+
+func TestParseVolts(t *testing.T) {
+	v, err := parseVolts("volt=0.8600V\n")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.86, v, 0.0001)
+
+	_, err = parseVolts("throttled=0x0")
+	assert.Error(t, err)
+}
+
+func TestSystemReader_ReadPi(t *testing.T) {
+	r := NewSystemReader()
+	r.pi = &fakePiSource{status: &PiStatus{UnderVoltage: true, ARMClockHz: 600000000, CoreVolts: 0.85}}
+
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, s.Pi)
+	assert.True(t, s.Pi.UnderVoltage)
+	assert.Equal(t, uint64(600000000), s.Pi.ARMClockHz)
+}
+
+func TestSystemReader_ReadPiError(t *testing.T) {
+	r := NewSystemReader()
+	r.pi = &fakePiSource{err: errors.New("vcgencmd failed")}
+
+	s, err := r.Read(context.Background())
+	require.NoError(t, err, "Pi failures must not fail the whole read")
+	assert.Nil(t, s.Pi)
+}
+
+func TestSystemReader_NoPiSource(t *testing.T) {
+	r := NewSystemReader()
+	r.pi = nil
+
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, s.Pi)
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// defaultTopProcesses is the number of processes kept in Snapshot.TopCPU and Snapshot.TopRAM.
+const defaultTopProcesses = 5
+
+// prevProcTimes stores the previous CPU time of a process for usage calculation.
+type prevProcTimes struct {
+	total     float64 // user + system seconds
+	timestamp time.Time
+}
+
+// procSample is the cheap per-process data gathered for every process before
+// the top N are picked.
+type procSample struct {
+	proc       *process.Process
+	cpuPercent float64
+	rss        uint64
+}
+
+func (r *SystemReader) readProcesses(ctx context.Context, s *Snapshot, now time.Time) error {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list processes: %w", err)
+	}
+
+	s.System.Processes = len(procs)
+
+	r.prevProcMu.Lock()
+	seen := make(map[int32]prevProcTimes, len(procs))
+	samples := make([]procSample, 0, len(procs))
+	for _, p := range procs {
+		// Processes may exit while we iterate; skip them silently.
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		sample := procSample{proc: p}
+		total := times.User + times.System
+		if prev, ok := r.prevProc[p.Pid]; ok {
+			elapsed := now.Sub(prev.timestamp).Seconds()
+			if elapsed > 0 && total >= prev.total {
+				sample.cpuPercent = (total - prev.total) / elapsed * 100
+			}
+		}
+		// First reading of a process: CPU stays 0
+		seen[p.Pid] = prevProcTimes{total: total, timestamp: now}
+
+		if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil {
+			sample.rss = memInfo.RSS
+		}
+		samples = append(samples, sample)
+	}
+	// Replacing the map drops processes that have exited.
+	r.prevProc = seen
+	r.prevProcMu.Unlock()
+
+	s.TopCPU = topProcesses(ctx, samples, defaultTopProcesses, func(a, b procSample) bool {
+		return a.cpuPercent > b.cpuPercent
+	})
+	s.TopRAM = topProcesses(ctx, samples, defaultTopProcesses, func(a, b procSample) bool {
+		return a.rss > b.rss
+	})
+	return nil
+}
+
+// topProcesses returns the first n samples ordered by less, resolving name,
+// command line and user only for the processes that are kept.
+func topProcesses(ctx context.Context, samples []procSample, n int, less func(a, b procSample) bool) []ProcessInfo {
+	sorted := make([]procSample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	result := make([]ProcessInfo, 0, len(sorted))
+	for _, sample := range sorted {
+		info := ProcessInfo{
+			PID:        sample.proc.Pid,
+			CPUPercent: sample.cpuPercent,
+			RSS:        sample.rss,
+		}
+		info.Name, _ = sample.proc.NameWithContext(ctx)
+		info.Cmdline, _ = sample.proc.CmdlineWithContext(ctx)
+		info.User, _ = sample.proc.UsernameWithContext(ctx)
+		result = append(result, info)
+	}
+	return result
+}
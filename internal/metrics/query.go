@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Point is one time bucket of an aggregated series.
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	Min       float64   `json:"min"`
+	Avg       float64   `json:"avg"`
+	Max       float64   `json:"max"`
+}
+
+// SeriesData is a named series of aggregated points.
+type SeriesData struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
+}
+
+// bucketAcc accumulates values falling into a single bucket.
+type bucketAcc struct {
+	min, max, sum float64
+	count         int
+}
+
+// Query returns the series matching fields over [from, to), bucketed by step
+// with min/avg/max per bucket. Recent data comes from the ring buffer and
+// older data from the persisted tiers, if persistence is enabled.
+//
+// A field matches a series by exact name ("disk:/") or as the prefix of a
+// per-device series ("disk" matches every "disk:..." series).
+func (c *Collector) Query(from, to time.Time, step time.Duration, fields []string) []SeriesData {
+	if step <= 0 || !to.After(from) {
+		return nil
+	}
+
+	buckets := make(map[string]map[int64]*bucketAcc)
+	add := func(name string, ts time.Time, min, avg, max float64) {
+		if ts.Before(from) || !ts.Before(to) || !matchesAnyField(name, fields) {
+			return
+		}
+		series, ok := buckets[name]
+		if !ok {
+			series = make(map[int64]*bucketAcc)
+			buckets[name] = series
+		}
+		idx := int64(ts.Sub(from) / step)
+		acc, ok := series[idx]
+		if !ok {
+			acc = &bucketAcc{min: math.Inf(1), max: math.Inf(-1)}
+			series[idx] = acc
+		}
+		acc.min = math.Min(acc.min, min)
+		acc.max = math.Max(acc.max, max)
+		acc.sum += avg
+		acc.count++
+	}
+
+	recent := c.buffer.All()
+	// Each series is read from the database up to the first row the ring
+	// buffer kept for it, which is later for custom series.
+	persistedUntil := to
+	keptFrom := make(map[string]time.Time)
+	if recent.Len() > 0 && recent.Time(0).Before(to) {
+		persistedUntil = recent.Time(0)
+		for _, name := range recent.Names() {
+			if start := recent.Start(name); start > 0 {
+				keptFrom[name] = recent.Time(start)
+				if keptFrom[name].After(persistedUntil) {
+					persistedUntil = keptFrom[name]
+				}
+			}
+		}
+	}
+	if c.store != nil && persistedUntil.After(from) {
+		for _, ms := range c.persistedSamples(from, persistedUntil) {
+			if recent.Len() > 0 {
+				until, ok := keptFrom[ms.Series]
+				if !ok {
+					until = recent.Time(0)
+				}
+				if !ms.Timestamp.Before(until) {
+					continue
+				}
+			}
+			add(ms.Series, ms.Timestamp, ms.Min, ms.Avg, ms.Max)
+		}
+	}
+	for _, name := range recent.Names() {
+		for i, v := range recent.Column(name) {
+			if !math.IsNaN(float64(v)) {
+				add(name, recent.Time(i), float64(v), float64(v), float64(v))
+			}
+		}
+	}
+
+	return flattenBuckets(buckets, from, step)
+}
+
+func flattenBuckets(buckets map[string]map[int64]*bucketAcc, from time.Time, step time.Duration) []SeriesData {
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]SeriesData, 0, len(names))
+	for _, name := range names {
+		series := buckets[name]
+		idxs := make([]int64, 0, len(series))
+		for idx := range series {
+			idxs = append(idxs, idx)
+		}
+		sort.Slice(idxs, func(i, j int) bool { return idxs[i] < idxs[j] })
+
+		sd := SeriesData{Name: name, Points: make([]Point, 0, len(idxs))}
+		for _, idx := range idxs {
+			acc := series[idx]
+			sd.Points = append(sd.Points, Point{
+				Timestamp: from.Add(time.Duration(idx) * step),
+				Min:       acc.min,
+				Avg:       acc.sum / float64(acc.count),
+				Max:       acc.max,
+			})
+		}
+		result = append(result, sd)
+	}
+	return result
+}
+
+// matchesAnyField reports whether a series name is selected by fields.
+// An empty field list selects everything.
+func matchesAnyField(name string, fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if name == f || strings.HasPrefix(name, f+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_QueryBucketsRingBuffer(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	reader := &clockReader{now: start, step: 15 * time.Second, cpu: 10}
+	c := NewCollector(reader, 15*time.Second, time.Hour)
+
+	// cpu: 10, 20, 30, 40 in the first minute; 50, 60 in the second
+	for i := 0; i < 6; i++ {
+		c.collect(context.Background())
+	}
+
+	series := c.Query(start, start.Add(2*time.Minute), time.Minute, []string{"cpu"})
+	require.Len(t, series, 1)
+	assert.Equal(t, "cpu", series[0].Name)
+	require.Len(t, series[0].Points, 2)
+
+	p := series[0].Points[0]
+	assert.Equal(t, start, p.Timestamp)
+	assert.Equal(t, 10.0, p.Min)
+	assert.Equal(t, 25.0, p.Avg)
+	assert.Equal(t, 40.0, p.Max)
+
+	p = series[0].Points[1]
+	assert.Equal(t, start.Add(time.Minute), p.Timestamp)
+	assert.Equal(t, 55.0, p.Avg)
+}
+
+func TestCollector_QueryFieldSelection(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	c := NewCollector(&clockReader{now: start, step: time.Second}, time.Second, time.Hour)
+	c.collect(context.Background())
+
+	series := c.Query(start, start.Add(time.Minute), time.Minute, []string{"disk", "net_rx:eth0"})
+	require.Len(t, series, 2)
+	assert.Equal(t, "disk:/", series[0].Name)
+	assert.Equal(t, "net_rx:eth0", series[1].Name)
+	assert.Equal(t, 200.0, series[1].Points[0].Avg)
+}
+
+func TestCollector_QueryMergesPersisted(t *testing.T) {
+	db := setupHistoryDB(t)
+	start := time.Unix(1700000040, 0)
+
+	// Older data that only exists as a 1-minute rollup
+	require.NoError(t, db.InsertMetricSamples(start, map[string]float64{"cpu": 5}))
+	require.NoError(t, db.InsertMetricSamples(start.Add(30*time.Second), map[string]float64{"cpu": 15}))
+	require.NoError(t, db.RollupMetricSamples(0, time.Minute, start, start.Add(time.Minute)))
+	_, err := db.PruneMetricSamples(0, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	c := NewCollector(&clockReader{now: start.Add(time.Minute), step: time.Second, cpu: 70}, time.Second, time.Hour)
+	c.EnablePersistence(db, DefaultTiers)
+	c.buffer.Add(Snapshot{Timestamp: start.Add(time.Minute), CPU: CPUMetrics{TotalPercent: 70}})
+
+	series := c.Query(start, start.Add(2*time.Minute), time.Minute, []string{"cpu"})
+	require.Len(t, series, 1)
+	require.Len(t, series[0].Points, 2)
+	assert.Equal(t, 5.0, series[0].Points[0].Min)
+	assert.Equal(t, 10.0, series[0].Points[0].Avg)
+	assert.Equal(t, 15.0, series[0].Points[0].Max)
+	assert.Equal(t, 70.0, series[0].Points[1].Avg)
+}
+
+func TestCollector_QueryReadsOldCustomValuesFromDatabase(t *testing.T) {
+	db := setupHistoryDB(t)
+	start := time.Unix(1700000040, 0)
+	c := NewCollector(&mockReader{}, time.Second, time.Hour)
+	c.EnablePersistence(db, nil)
+
+	// The database holds other values than the ring buffer, to show which
+	// one each point came from.
+	rows := customColumnRows + 60
+	for i := 0; i < rows; i++ {
+		ts := start.Add(time.Duration(i) * time.Second)
+		require.NoError(t, db.InsertMetricSamples(ts, map[string]float64{"cpu": 50, "custom:queue": 100}))
+		c.buffer.Add(Snapshot{Timestamp: ts, CPU: CPUMetrics{TotalPercent: 1}, Custom: []CustomMetric{{Name: "queue", Value: 2}}})
+	}
+
+	series := c.Query(start, start.Add(time.Duration(rows)*time.Second), time.Minute, []string{"cpu", "custom"})
+	require.Len(t, series, 2)
+	cpu, queue := series[0], series[1]
+	require.Len(t, cpu.Points, rows/60)
+	require.Len(t, queue.Points, rows/60)
+	for i := range cpu.Points {
+		assert.Equal(t, 1.0, cpu.Points[i].Max, "cpu is kept in memory for every row")
+	}
+	assert.Equal(t, 100.0, queue.Points[0].Avg, "the first minute is only in the database")
+	for i := 1; i < len(queue.Points); i++ {
+		assert.Equal(t, 2.0, queue.Points[i].Max, "later minutes come from memory only")
+	}
+}
+
+func TestCollector_QueryInvalidRange(t *testing.T) {
+	c := NewCollector(&mockReader{}, time.Second, time.Hour)
+	now := time.Now()
+	assert.Nil(t, c.Query(now, now.Add(-time.Minute), time.Second, nil))
+	assert.Nil(t, c.Query(now, now.Add(time.Minute), 0, nil))
+}
+
+func TestMatchesAnyField(t *testing.T) {
+	assert.True(t, matchesAnyField("cpu", nil))
+	assert.True(t, matchesAnyField("cpu", []string{"cpu"}))
+	assert.True(t, matchesAnyField("disk:/data", []string{"disk"}))
+	assert.True(t, matchesAnyField("disk:/", []string{"disk:/"}))
+	assert.False(t, matchesAnyField("disk:/data", []string{"disk:/"}))
+	assert.False(t, matchesAnyField("cpu", []string{"ram"}))
+}
@@ -2,8 +2,13 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -12,7 +17,6 @@ import (
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/mem"
-	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/sensors"
 )
 
@@ -21,47 +25,223 @@ type Reader interface {
 	Read(ctx context.Context) (*Snapshot, error)
 }
 
-// prevNetCounters stores the previous network I/O counters for rate calculation.
-type prevNetCounters struct {
-	bytesSent uint64
-	bytesRecv uint64
+// prevDiskCounters stores the previous block device I/O counters for rate calculation.
+type prevDiskCounters struct {
+	stat      disk.IOCountersStat
 	timestamp time.Time
 }
 
+// DefaultReaderTimeout is the deadline of each section of SystemReader.Read.
+const DefaultReaderTimeout = 3 * time.Second
+
 // SystemReader implements Reader using gopsutil.
 type SystemReader struct {
 	prevNet      map[string]prevNetCounters
 	prevNetMu    sync.Mutex
+	prevDisk     map[string]prevDiskCounters
+	prevDiskMu   sync.Mutex
+	prevProc     map[int32]prevProcTimes
+	prevProcMu   sync.Mutex
+	prevSystem   *prevSystemCounters
+	prevSystemMu sync.Mutex
+	pi           PiSource // nil when not running on a Raspberry Pi
+	primaryTemp  string   // label of the sensor reported as Snapshot.Temperature
+	diskFilter   DiskFilter
+	netFilter    NetFilter
+	thermalDir   string
+	procDir      string
+	sysBlockDir  string
+	sysNetDir    string
+	hwmonTemps   func(ctx context.Context) ([]sensors.TemperatureStat, error)
 	tempWarnOnce sync.Once
+
+	sections  []readerSection
+	timeout   time.Duration        // per-section deadline
+	running   map[string]time.Time // section -> start of the read still in flight
+	runningMu sync.Mutex
+	lastSnap  map[string]*Snapshot // section -> scratch of its last successful read
+	lastOK    map[string]time.Time // section -> time of its last successful read
+	lastMu    sync.Mutex
 }
 
-// NewSystemReader creates a new system metrics reader.
+// NewSystemReader creates a new system metrics reader. Raspberry Pi firmware
+// state is read when vcgencmd is available.
 func NewSystemReader() *SystemReader {
-	return &SystemReader{
-		prevNet: make(map[string]prevNetCounters),
+	r := &SystemReader{
+		prevNet:  make(map[string]prevNetCounters),
+		prevDisk: make(map[string]prevDiskCounters),
+		prevProc: make(map[int32]prevProcTimes),
+
+		diskFilter:  DefaultDiskFilter(),
+		netFilter:   DefaultNetFilter(),
+		thermalDir:  "/sys/class/thermal",
+		procDir:     "/proc",
+		sysBlockDir: "/sys/block",
+		sysNetDir:   "/sys/class/net",
+		hwmonTemps:  sensors.TemperaturesWithContext,
+
+		timeout:  DefaultReaderTimeout,
+		running:  make(map[string]time.Time),
+		lastSnap: make(map[string]*Snapshot),
+		lastOK:   make(map[string]time.Time),
+	}
+	r.sections = r.defaultSections()
+	if src := NewVcgencmdSource(); src != nil {
+		r.pi = src
 	}
+	return r
+}
+
+// readerSection is one independently timed part of a Read. read fills a
+// scratch snapshot; merge copies the fields it owns into the result.
+type readerSection struct {
+	name  string
+	read  func(ctx context.Context, s *Snapshot, now time.Time) error
+	merge func(dst, src *Snapshot)
+}
+
+// sectionResult is the outcome of running one section.
+type sectionResult struct {
+	snap     *Snapshot
+	err      error
+	timedOut bool
+	duration time.Duration
 }
 
-// Read collects all system metrics. Individual metric failures don't stop collection.
+// defaultSections lists the sub-readers in merge order. system must come
+// before processes, which overwrites System.Processes.
+func (r *SystemReader) defaultSections() []readerSection {
+	noTime := func(read func(context.Context, *Snapshot) error) func(context.Context, *Snapshot, time.Time) error {
+		return func(ctx context.Context, s *Snapshot, _ time.Time) error { return read(ctx, s) }
+	}
+	return []readerSection{
+		{"cpu", noTime(r.readCPU), func(dst, src *Snapshot) {
+			dst.CPU.TotalPercent = src.CPU.TotalPercent
+			dst.CPU.PerCore = src.CPU.PerCore
+		}},
+		{"load", noTime(r.readLoad), func(dst, src *Snapshot) {
+			dst.CPU.Load1, dst.CPU.Load5, dst.CPU.Load15 = src.CPU.Load1, src.CPU.Load5, src.CPU.Load15
+		}},
+		{"system", r.readSystem, func(dst, src *Snapshot) {
+			dst.CPU.Times = src.CPU.Times
+			dst.System = src.System
+		}},
+		{"ram", noTime(r.readRAM), func(dst, src *Snapshot) { dst.RAM = src.RAM }},
+		{"disks", noTime(r.readDisks), func(dst, src *Snapshot) { dst.Disks = src.Disks }},
+		{"disk_io", r.readDiskIO, func(dst, src *Snapshot) { dst.DiskIO = src.DiskIO }},
+		{"network", r.readNetwork, func(dst, src *Snapshot) { dst.Networks = src.Networks }},
+		{"temperature", noTime(r.readTemperature), func(dst, src *Snapshot) {
+			dst.Temperature = src.Temperature
+			dst.PrimaryTemp = src.PrimaryTemp
+			dst.Temperatures = src.Temperatures
+		}},
+		{"pi", noTime(r.readPi), func(dst, src *Snapshot) { dst.Pi = src.Pi }},
+		{"processes", r.readProcesses, func(dst, src *Snapshot) {
+			dst.System.Processes = src.System.Processes
+			dst.TopCPU = src.TopCPU
+			dst.TopRAM = src.TopRAM
+		}},
+	}
+}
+
+// Read collects all system metrics. Sections run concurrently, each with its
+// own deadline, so one hung reader (e.g. a stale NFS mount) cannot stall the
+// rest. A failed or timed-out section keeps its last good values and is
+// reported in Snapshot.Errors.
 func (r *SystemReader) Read(ctx context.Context) (*Snapshot, error) {
 	now := time.Now()
 	s := &Snapshot{Timestamp: now}
 
-	r.readCPU(ctx, s)
-	r.readRAM(ctx, s)
-	r.readDisks(ctx, s)
-	r.readNetwork(ctx, s, now)
-	r.readTemperature(ctx, s)
+	results := make([]sectionResult, len(r.sections))
+	var wg sync.WaitGroup
+	for i, sec := range r.sections {
+		wg.Add(1)
+		go func(i int, sec readerSection) {
+			defer wg.Done()
+			results[i] = r.runSection(ctx, sec, now)
+		}(i, sec)
+	}
+	wg.Wait()
+
+	r.lastMu.Lock()
+	defer r.lastMu.Unlock()
+
+	for i, sec := range r.sections {
+		res := results[i]
+		if res.err == nil {
+			sec.merge(s, res.snap)
+			r.lastSnap[sec.name] = res.snap
+			r.lastOK[sec.name] = now
+			continue
+		}
+
+		log.Printf("metrics: %s reader failed after %v: %v", sec.name, res.duration.Round(time.Millisecond), res.err)
+		re := ReaderError{Reader: sec.name, Error: res.err.Error(), Duration: res.duration, TimedOut: res.timedOut}
+		if last, ok := r.lastOK[sec.name]; ok {
+			sec.merge(s, r.lastSnap[sec.name])
+			re.Stale = true
+			re.LastSuccess = last
+		}
+		s.Errors = append(s.Errors, re)
+	}
 
 	return s, nil
 }
 
-func (r *SystemReader) readCPU(ctx context.Context, s *Snapshot) {
+// runSection runs one section against a scratch snapshot under the reader
+// timeout. On timeout the section keeps running in the background, but its
+// results are discarded and it is not started again until it returns.
+func (r *SystemReader) runSection(ctx context.Context, sec readerSection, now time.Time) sectionResult {
+	r.runningMu.Lock()
+	if started, busy := r.running[sec.name]; busy {
+		r.runningMu.Unlock()
+		return sectionResult{
+			err:      fmt.Errorf("previous read still running after %v", time.Since(started).Round(time.Second)),
+			timedOut: true,
+		}
+	}
+	start := time.Now()
+	r.running[sec.name] = start
+	r.runningMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	scratch := &Snapshot{Timestamp: now}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			r.runningMu.Lock()
+			delete(r.running, sec.name)
+			r.runningMu.Unlock()
+		}()
+		done <- sec.read(ctx, scratch, now)
+	}()
+
+	select {
+	case err := <-done:
+		return sectionResult{snap: scratch, err: err, duration: time.Since(start)}
+	case <-ctx.Done():
+		res := sectionResult{err: ctx.Err(), duration: time.Since(start)}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res.err = fmt.Errorf("timed out after %v", r.timeout)
+			res.timedOut = true
+		}
+		return res
+	}
+}
+
+// SetReaderTimeout sets the deadline of each section of Read. It must be
+// called before the reader is used.
+func (r *SystemReader) SetReaderTimeout(d time.Duration) {
+	r.timeout = d
+}
+
+func (r *SystemReader) readCPU(ctx context.Context, s *Snapshot) error {
 	// Total CPU percent (all cores combined)
 	totals, err := cpu.PercentWithContext(ctx, 0, false)
 	if err != nil {
-		log.Printf("metrics: failed to read CPU total: %v", err)
-		return
+		return fmt.Errorf("cannot read CPU total: %w", err)
 	}
 	if len(totals) > 0 {
 		s.CPU.TotalPercent = totals[0]
@@ -70,116 +250,268 @@ func (r *SystemReader) readCPU(ctx context.Context, s *Snapshot) {
 	// Per-core
 	perCore, err := cpu.PercentWithContext(ctx, 0, true)
 	if err != nil {
-		log.Printf("metrics: failed to read CPU per-core: %v", err)
-		return
+		return fmt.Errorf("cannot read CPU per-core: %w", err)
 	}
 	s.CPU.PerCore = perCore
+	return nil
 }
 
-func (r *SystemReader) readRAM(ctx context.Context, s *Snapshot) {
+func (r *SystemReader) readRAM(ctx context.Context, s *Snapshot) error {
 	vm, err := mem.VirtualMemoryWithContext(ctx)
 	if err != nil {
-		log.Printf("metrics: failed to read RAM: %v", err)
-		return
+		return fmt.Errorf("cannot read RAM: %w", err)
 	}
 	s.RAM = RAMMetrics{
 		Total:     vm.Total,
 		Used:      vm.Used,
 		Available: vm.Available,
 		Percent:   vm.UsedPercent,
+		Buffers:   vm.Buffers,
+		Cached:    vm.Cached,
+		Dirty:     vm.Dirty,
+		Writeback: vm.WriteBack,
+	}
+
+	if sw, err := mem.SwapMemoryWithContext(ctx); err == nil {
+		s.RAM.SwapTotal = sw.Total
+		s.RAM.SwapUsed = sw.Used
+		s.RAM.SwapPercent = sw.UsedPercent
+	} else {
+		log.Printf("metrics: failed to read swap: %v", err)
 	}
+
+	s.RAM.Zram = readZram(r.sysBlockDir)
+	// PSI is absent on older kernels and when disabled at boot (psi=0).
+	if p, err := readMemoryPressure(r.procDir); err == nil {
+		s.RAM.Pressure = p
+	}
+	return nil
 }
 
-func (r *SystemReader) readDisks(ctx context.Context, s *Snapshot) {
+func (r *SystemReader) readDisks(ctx context.Context, s *Snapshot) error {
 	partitions, err := disk.PartitionsWithContext(ctx, false)
 	if err != nil {
-		log.Printf("metrics: failed to read disk partitions: %v", err)
-		return
+		return fmt.Errorf("cannot read disk partitions: %w", err)
 	}
 
 	for _, p := range partitions {
+		if !r.diskFilter.Keep(p.Fstype, p.Mountpoint) {
+			continue
+		}
 		usage, err := disk.UsageWithContext(ctx, p.Mountpoint)
 		if err != nil {
 			log.Printf("metrics: failed to read disk usage for %s: %v", p.Mountpoint, err)
 			continue
 		}
 		s.Disks = append(s.Disks, DiskPartition{
-			Path:    p.Mountpoint,
-			Total:   usage.Total,
-			Used:    usage.Used,
-			Free:    usage.Free,
-			Percent: usage.UsedPercent,
+			Path:          p.Mountpoint,
+			Device:        p.Device,
+			Fstype:        p.Fstype,
+			Total:         usage.Total,
+			Used:          usage.Used,
+			Free:          usage.Free,
+			Percent:       usage.UsedPercent,
+			InodesTotal:   usage.InodesTotal,
+			InodesUsed:    usage.InodesUsed,
+			InodesFree:    usage.InodesFree,
+			InodesPercent: usage.InodesUsedPercent,
 		})
 	}
+	return nil
+}
+
+// SetDiskFilter replaces the rules deciding which filesystems are reported.
+// It must be called before the reader is used.
+func (r *SystemReader) SetDiskFilter(f DiskFilter) {
+	r.diskFilter = f
 }
 
-func (r *SystemReader) readNetwork(ctx context.Context, s *Snapshot, now time.Time) {
-	counters, err := net.IOCountersWithContext(ctx, true)
+func (r *SystemReader) readDiskIO(ctx context.Context, s *Snapshot, now time.Time) error {
+	counters, err := disk.IOCountersWithContext(ctx)
 	if err != nil {
-		log.Printf("metrics: failed to read network: %v", err)
-		return
+		return fmt.Errorf("cannot read disk I/O: %w", err)
 	}
 
-	r.prevNetMu.Lock()
-	defer r.prevNetMu.Unlock()
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		if isBlockDevice(r.sysBlockDir, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
 
-	for _, c := range counters {
-		iface := NetworkIface{Name: c.Name}
+	r.prevDiskMu.Lock()
+	defer r.prevDiskMu.Unlock()
 
-		if prev, ok := r.prevNet[c.Name]; ok {
-			elapsed := now.Sub(prev.timestamp).Seconds()
-			if elapsed > 0 {
-				iface.BytesSentPS = uint64(float64(c.BytesSent-prev.bytesSent) / elapsed)
-				iface.BytesRecvPS = uint64(float64(c.BytesRecv-prev.bytesRecv) / elapsed)
-			}
+	for _, name := range names {
+		c := counters[name]
+		dev := DiskIO{Device: name}
+
+		if prev, ok := r.prevDisk[name]; ok {
+			dev = diskIORates(name, prev.stat, c, now.Sub(prev.timestamp))
 		}
 		// First reading: rates stay 0
 
-		r.prevNet[c.Name] = prevNetCounters{
-			bytesSent: c.BytesSent,
-			bytesRecv: c.BytesRecv,
-			timestamp: now,
-		}
+		r.prevDisk[name] = prevDiskCounters{stat: c, timestamp: now}
 
-		s.Networks = append(s.Networks, iface)
+		s.DiskIO = append(s.DiskIO, dev)
 	}
+	return nil
 }
 
-func (r *SystemReader) readTemperature(_ context.Context, s *Snapshot) {
-	temps, err := sensors.SensorsTemperatures()
-	if err == nil {
-		for _, t := range temps {
-			if t.Temperature > 0 {
-				temp := t.Temperature
-				s.Temperature = &temp
-				return
-			}
+// diskIORates computes per-second rates between two counter readings taken
+// elapsed apart. Counters that went backwards (device reset) count as zero.
+func diskIORates(name string, prev, cur disk.IOCountersStat, elapsed time.Duration) DiskIO {
+	dev := DiskIO{Device: name}
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return dev
+	}
+
+	reads := counterDelta(cur.ReadCount, prev.ReadCount)
+	writes := counterDelta(cur.WriteCount, prev.WriteCount)
+
+	dev.ReadBytesPS = uint64(float64(counterDelta(cur.ReadBytes, prev.ReadBytes)) / secs)
+	dev.WriteBytesPS = uint64(float64(counterDelta(cur.WriteBytes, prev.WriteBytes)) / secs)
+	dev.ReadIOPS = float64(reads) / secs
+	dev.WriteIOPS = float64(writes) / secs
+
+	// IoTime, ReadTime and WriteTime are in milliseconds
+	dev.BusyPercent = math.Min(float64(counterDelta(cur.IoTime, prev.IoTime))/(secs*1000)*100, 100)
+	if ops := reads + writes; ops > 0 {
+		waited := counterDelta(cur.ReadTime, prev.ReadTime) + counterDelta(cur.WriteTime, prev.WriteTime)
+		dev.AwaitMs = float64(waited) / float64(ops)
+	}
+	return dev
+}
+
+func counterDelta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// isBlockDevice reports whether name is a whole disk worth reporting. On Linux
+// partitions (sda1, mmcblk0p2) are skipped because they are not listed in
+// sysBlockDir and are already counted in their parent device, as are loop and
+// ram devices. Without sysBlockDir every device is kept.
+func isBlockDevice(sysBlockDir, name string) bool {
+	if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+		return false
+	}
+	if _, err := os.Stat(sysBlockDir); err != nil {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(sysBlockDir, name))
+	return err == nil
+}
+
+func (r *SystemReader) readTemperature(ctx context.Context, s *Snapshot) error {
+	seen := make(map[string]bool)
+	add := func(label string, celsius float64) {
+		// Absent or disconnected sensors commonly report 0 or negative values
+		key := sensorIdentity(label)
+		if celsius <= 0 || seen[key] {
+			return
+		}
+		seen[key] = true
+		s.Temperatures = append(s.Temperatures, TempSensor{Label: label, Celsius: celsius})
+	}
+
+	// hwmon sensors. Errors may be partial warnings, so keep whatever was read.
+	temps, err := r.hwmonTemps(ctx)
+	for _, t := range temps {
+		add(t.SensorKey, t.Temperature)
+	}
+
+	// Every thermal zone. A zone backed by an hwmon device is already listed
+	// under its hwmon name, which differs only in punctuation: the Pi's CPU is
+	// "cpu_thermal" in hwmon and "cpu-thermal" as thermal_zone0. gopsutil also
+	// falls back to the zones when there is no hwmon. Both duplicates are
+	// skipped by add.
+	for _, z := range readThermalZones(r.thermalDir) {
+		add(z.Label, z.Celsius)
+	}
+
+	if len(s.Temperatures) == 0 {
+		if err != nil {
+			return fmt.Errorf("cannot read temperature sensors: %w", err)
 		}
+		// Sensor unavailable — log once, leave Temperature nil
+		r.tempWarnOnce.Do(func() {
+			log.Println("metrics: temperature sensor not available, reporting null")
+		})
+		return nil
 	}
 
-	// Fallback: read /sys/class/thermal (Linux ARM)
-	temp, err := readThermalZone()
-	if err == nil {
-		s.Temperature = &temp
-		return
+	primary := s.Temperatures[0]
+	for _, t := range s.Temperatures {
+		if t.Label == r.primaryTemp {
+			primary = t
+			break
+		}
 	}
+	s.PrimaryTemp = primary.Label
+	s.Temperature = &primary.Celsius
+	return nil
+}
 
-	// Sensor unavailable — log once, leave Temperature nil
-	r.tempWarnOnce.Do(func() {
-		log.Println("metrics: temperature sensor not available, reporting null")
-	})
+// sensorIdentity returns the key under which sensor labels are deduplicated.
+// hwmon names use "_" where thermal zone types use "-".
+func sensorIdentity(label string) string {
+	return strings.ReplaceAll(label, "-", "_")
 }
 
-// readThermalZone reads CPU temperature from sysfs (Linux).
-func readThermalZone() (float64, error) {
-	data, err := os.ReadFile("/sys/class/thermal/thermal_zone0/temp")
-	if err != nil {
-		return 0, err
+// SetPrimaryTempSensor selects the sensor label reported as Snapshot.Temperature
+// and used by temp alert rules without a target. When empty or not found, the
+// first available sensor is used.
+func (r *SystemReader) SetPrimaryTempSensor(label string) {
+	r.primaryTemp = label
+}
+
+func (r *SystemReader) readPi(ctx context.Context, s *Snapshot) error {
+	if r.pi == nil {
+		return nil
 	}
-	raw := strings.TrimSpace(string(data))
-	milliC, err := strconv.ParseFloat(raw, 64)
+	status, err := r.pi.Read(ctx)
 	if err != nil {
-		return 0, err
+		return fmt.Errorf("cannot read Pi firmware state: %w", err)
+	}
+	s.Pi = status
+	return nil
+}
+
+// readThermalZones reads every thermal_zone* under dir (Linux sysfs), labeled
+// by zone type. Zones sharing a type are told apart by their zone name.
+func readThermalZones(dir string) []TempSensor {
+	zones, _ := filepath.Glob(filepath.Join(dir, "thermal_zone*"))
+	sort.Strings(zones)
+
+	types := make(map[string]int)
+	var result []TempSensor
+	for _, zone := range zones {
+		data, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		milliC, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+
+		label := filepath.Base(zone)
+		if typ, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			if t := strings.TrimSpace(string(typ)); t != "" {
+				label = t
+			}
+		}
+		types[label]++
+		if types[label] > 1 {
+			label += "/" + filepath.Base(zone)
+		}
+
+		result = append(result, TempSensor{Label: label, Celsius: milliC / 1000.0})
 	}
-	return milliC / 1000.0, nil
+	return result
 }
@@ -2,8 +2,12 @@ package metrics
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -117,3 +121,92 @@ func TestSystemReader_SecondReadDoesNotError(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, s2)
 }
+
+func TestSystemReader_ReadProcesses(t *testing.T) {
+	r := NewSystemReader()
+	ctx := context.Background()
+
+	s, err := r.Read(ctx)
+	require.NoError(t, err)
+
+	// The test process itself is always running
+	require.NotEmpty(t, s.TopRAM)
+	assert.LessOrEqual(t, len(s.TopRAM), defaultTopProcesses)
+	for i, p := range s.TopRAM {
+		assert.Greater(t, p.PID, int32(0))
+		if i > 0 {
+			assert.GreaterOrEqual(t, s.TopRAM[i-1].RSS, p.RSS, "TopRAM should be sorted by RSS")
+		}
+	}
+
+	// First reading: CPU usage stays 0
+	for _, p := range s.TopCPU {
+		assert.Equal(t, 0.0, p.CPUPercent)
+	}
+
+	s2, err := r.Read(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, s2.TopCPU)
+	for i := 1; i < len(s2.TopCPU); i++ {
+		assert.GreaterOrEqual(t, s2.TopCPU[i-1].CPUPercent, s2.TopCPU[i].CPUPercent, "TopCPU should be sorted by CPU")
+	}
+}
+
+func TestSystemReader_ReadDiskIO(t *testing.T) {
+	r := NewSystemReader()
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+
+	// First reading: rates should be 0 (the device list may be empty in containers)
+	for _, d := range s.DiskIO {
+		assert.NotEmpty(t, d.Device)
+		assert.Equal(t, uint64(0), d.ReadBytesPS)
+		assert.Equal(t, uint64(0), d.WriteBytesPS)
+		assert.Equal(t, 0.0, d.BusyPercent)
+	}
+}
+
+func TestDiskIORates(t *testing.T) {
+	prev := disk.IOCountersStat{ReadCount: 100, WriteCount: 50, ReadBytes: 1 << 20, WriteBytes: 2 << 20, ReadTime: 400, WriteTime: 600, IoTime: 1000}
+	cur := disk.IOCountersStat{ReadCount: 120, WriteCount: 80, ReadBytes: 3 << 20, WriteBytes: 6 << 20, ReadTime: 500, WriteTime: 900, IoTime: 2000}
+
+	d := diskIORates("sda", prev, cur, 2*time.Second)
+	assert.Equal(t, "sda", d.Device)
+	assert.Equal(t, uint64(1<<20), d.ReadBytesPS)
+	assert.Equal(t, uint64(2<<20), d.WriteBytesPS)
+	assert.InDelta(t, 10.0, d.ReadIOPS, 0.001)
+	assert.InDelta(t, 15.0, d.WriteIOPS, 0.001)
+	assert.InDelta(t, 50.0, d.BusyPercent, 0.001) // 1000ms busy over 2s
+	assert.InDelta(t, 8.0, d.AwaitMs, 0.001)      // 400ms waited over 50 requests
+}
+
+func TestDiskIORates_CounterReset(t *testing.T) {
+	prev := disk.IOCountersStat{ReadCount: 100, ReadBytes: 1 << 20, IoTime: 5000}
+	cur := disk.IOCountersStat{ReadCount: 10, ReadBytes: 4096, IoTime: 100}
+
+	d := diskIORates("sda", prev, cur, time.Second)
+	assert.Equal(t, uint64(0), d.ReadBytesPS)
+	assert.Equal(t, 0.0, d.ReadIOPS)
+	assert.Equal(t, 0.0, d.BusyPercent)
+	assert.Equal(t, 0.0, d.AwaitMs)
+}
+
+func TestDiskIORates_BusyCapped(t *testing.T) {
+	// IoTime can advance slightly faster than wall time between samples
+	d := diskIORates("sda", disk.IOCountersStat{IoTime: 0}, disk.IOCountersStat{IoTime: 1100}, time.Second)
+	assert.Equal(t, 100.0, d.BusyPercent)
+}
+
+func TestIsBlockDevice(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"mmcblk0", "sda", "loop0"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+	}
+
+	assert.True(t, isBlockDevice(dir, "mmcblk0"))
+	assert.True(t, isBlockDevice(dir, "sda"))
+	assert.False(t, isBlockDevice(dir, "mmcblk0p2"), "partitions are not listed")
+	assert.False(t, isBlockDevice(dir, "loop0"))
+	assert.False(t, isBlockDevice(dir, "ram0"))
+	assert.True(t, isBlockDevice(filepath.Join(dir, "missing"), "disk0"), "without the directory every device is kept")
+}
@@ -1,21 +1,62 @@
 package metrics
 
-import "sync"
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
-// RingBuffer is a thread-safe fixed-size circular buffer for Snapshot storage.
+// RingBuffer is a thread-safe fixed-size circular buffer of metrics history.
+//
+// Only the most recent snapshot is kept whole. Every reading is stored column
+// by column instead: one timestamp per row and one float32 column per series
+// (see Snapshot.Series), with each series name stored once. A day at a 1s
+// interval then costs a few megabytes rather than 86,400 snapshots with their
+// per-core, disk and interface slices.
+//
+// Custom and pushed series (see Snapshot.Custom) keep only their last
+// customColumnRows values. There can be hundreds of them, and full-length
+// columns would outgrow the rest of the buffer many times over; their older
+// values are read from the database (see Collector.Query).
 type RingBuffer struct {
 	mu       sync.RWMutex
-	data     []Snapshot
 	capacity int
 	writeIdx int
 	count    int
+	written  uint64 // rows added so far; the sequence number of the newest row
+
+	latest  Snapshot
+	times   []int64 // unix nanoseconds
+	columns map[seriesKey]*column
+}
+
+// seriesKey identifies a column by the halves eachSeries passes, so looking one
+// up does not build the full series name.
+type seriesKey struct {
+	prefix, name string
+}
+
+// column holds one series for every row of the buffer.
+type column struct {
+	name     string    // full series name, e.g. "disk:/"
+	values   []float32 // NaN where the series had no value
+	lastSeen uint64    // sequence number of the newest row with a value
 }
 
+// customColumnRows is the length of custom series columns: an hour at the
+// default 5s interval, or 1.4MB for 500 pushed series.
+const customColumnRows = 720
+
+var nan32 = float32(math.NaN())
+
 // NewRingBuffer creates a ring buffer with the given capacity.
 func NewRingBuffer(capacity int) *RingBuffer {
 	return &RingBuffer{
-		data:     make([]Snapshot, capacity),
 		capacity: capacity,
+		times:    make([]int64, capacity),
+		columns:  make(map[seriesKey]*column),
 	}
 }
 
@@ -24,13 +65,58 @@ func (rb *RingBuffer) Add(s Snapshot) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
-	rb.data[rb.writeIdx] = s
+	rb.written++
+	seq := rb.written
+
+	rb.times[rb.writeIdx] = s.Timestamp.UnixNano()
+	s.eachSeries(func(prefix, name string, v float64) {
+		col, ok := rb.columns[seriesKey{prefix, name}]
+		if !ok {
+			col = rb.addColumn(prefix, name)
+		}
+		col.values[col.slot(seq)] = float32(v)
+		col.lastSeen = seq
+	})
+
+	for key, col := range rb.columns {
+		if col.lastSeen == seq {
+			continue
+		}
+		// Clear the value left by the row being overwritten, and forget
+		// series (unplugged disks, removed interfaces) with no rows left.
+		col.values[col.slot(seq)] = nan32
+		if seq-col.lastSeen >= uint64(len(col.values)) {
+			delete(rb.columns, key)
+		}
+	}
+
+	rb.latest = s
 	rb.writeIdx = (rb.writeIdx + 1) % rb.capacity
 	if rb.count < rb.capacity {
 		rb.count++
 	}
 }
 
+// addColumn creates the NaN-filled column for a series seen for the first
+// time. The name is copied so the column does not pin the snapshot it came
+// from.
+func (rb *RingBuffer) addColumn(prefix, name string) *column {
+	rows := rb.capacity
+	if prefix == seriesCustomPrefix {
+		rows = min(rows, customColumnRows)
+	}
+	full := strings.Clone(prefix + name)
+	col := &column{name: full, values: nanColumn(rows)}
+	rb.columns[seriesKey{prefix, full[len(prefix):]}] = col
+	return col
+}
+
+// slot returns the index of row seq in the column. Rows are numbered from 1,
+// so for full-length columns this is the buffer's write index.
+func (col *column) slot(seq uint64) int {
+	return int((seq - 1) % uint64(len(col.values)))
+}
+
 // Latest returns the most recent snapshot, or nil if empty.
 func (rb *RingBuffer) Latest() *Snapshot {
 	rb.mu.RLock()
@@ -39,37 +125,73 @@ func (rb *RingBuffer) Latest() *Snapshot {
 	if rb.count == 0 {
 		return nil
 	}
-	idx := (rb.writeIdx - 1 + rb.capacity) % rb.capacity
-	s := rb.data[idx]
+	s := rb.latest
 	return &s
 }
 
-// History returns the last n entries in chronological order (oldest first).
-func (rb *RingBuffer) History(n int) []Snapshot {
+// History returns the last n entries in chronological order (oldest first),
+// or nil if the buffer is empty.
+func (rb *RingBuffer) History(n int) *History {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	return rb.history(n)
+}
+
+// All returns all stored entries in chronological order.
+func (rb *RingBuffer) All() *History {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 
+	return rb.history(rb.count)
+}
+
+func (rb *RingBuffer) history(n int) *History {
 	if n > rb.count {
 		n = rb.count
 	}
-	if n == 0 {
+	if n <= 0 {
 		return nil
 	}
 
-	result := make([]Snapshot, n)
 	start := (rb.writeIdx - n + rb.capacity) % rb.capacity
-	for i := 0; i < n; i++ {
-		result[i] = rb.data[(start+i)%rb.capacity]
+	h := &History{
+		times:   copyRing(rb.times, start, n),
+		columns: make(map[string][]float32),
+	}
+	oldest := rb.written - uint64(n) + 1
+	for _, col := range rb.columns {
+		if col.lastSeen < oldest {
+			continue
+		}
+		if len(col.values) == rb.capacity {
+			h.columns[col.name] = copyRing(col.values, start, n)
+			continue
+		}
+		// A short column holds only the newest rows; older ones are unknown.
+		kept := min(n, len(col.values))
+		values := nanColumn(n)
+		first := rb.written - uint64(kept) + 1
+		copy(values[n-kept:], copyRing(col.values, col.slot(first), kept))
+		h.columns[col.name] = values
+		if kept < n {
+			if h.starts == nil {
+				h.starts = make(map[string]int)
+			}
+			h.starts[col.name] = n - kept
+		}
 	}
-	return result
+	return h
 }
 
-// All returns all stored entries in chronological order.
-func (rb *RingBuffer) All() []Snapshot {
-	rb.mu.RLock()
-	defer rb.mu.RUnlock()
-
-	return rb.History(rb.count)
+// copyRing copies n elements of a circular slice starting at start.
+func copyRing[T any](ring []T, start, n int) []T {
+	out := make([]T, n)
+	copied := copy(out, ring[start:])
+	if copied < n {
+		copy(out[copied:], ring)
+	}
+	return out
 }
 
 // Len returns the number of stored entries.
@@ -79,3 +201,94 @@ func (rb *RingBuffer) Len() int {
 
 	return rb.count
 }
+
+// History is a columnar slice of metrics history, oldest row first. Each
+// column holds one series (see Snapshot.Series) for every row, NaN where the
+// series had no value. Methods are safe to call on a nil History.
+type History struct {
+	times   []int64 // unix nanoseconds
+	columns map[string][]float32
+	starts  map[string]int // first row of columns not kept for every row
+}
+
+// Len returns the number of rows.
+func (h *History) Len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.times)
+}
+
+// Time returns the timestamp of row i.
+func (h *History) Time(i int) time.Time {
+	return time.Unix(0, h.times[i])
+}
+
+// Column returns the values of the named series, or nil if no row has one.
+// The slice must not be modified.
+func (h *History) Column(name string) []float32 {
+	if h == nil {
+		return nil
+	}
+	return h.columns[name]
+}
+
+// Start returns the first row the named column was kept for. Earlier rows
+// are NaN because the ring buffer did not keep them, not because the series
+// had no value (see customColumnRows).
+func (h *History) Start(name string) int {
+	if h == nil {
+		return 0
+	}
+	return h.starts[name]
+}
+
+// Names returns the names of the series with at least one value, sorted.
+func (h *History) Names() []string {
+	if h == nil {
+		return nil
+	}
+	names := make([]string, 0, len(h.columns))
+	for name := range h.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// concatHistory returns the rows of a followed by the rows of b.
+func concatHistory(a, b *History) *History {
+	if a.Len() == 0 {
+		return b
+	}
+	if b.Len() == 0 {
+		return a
+	}
+
+	h := &History{
+		times:   append(append(make([]int64, 0, a.Len()+b.Len()), a.times...), b.times...),
+		columns: make(map[string][]float32),
+	}
+	part := func(src *History, name string) []float32 {
+		if col, ok := src.columns[name]; ok {
+			return col
+		}
+		return nanColumn(src.Len())
+	}
+	for _, src := range []*History{a, b} {
+		for name := range src.columns {
+			if _, done := h.columns[name]; !done {
+				h.columns[name] = append(append(make([]float32, 0, h.Len()), part(a, name)...), part(b, name)...)
+			}
+		}
+	}
+	return h
+}
+
+func nanColumn(n int) []float32 {
+	col := make([]float32, n)
+	for i := range col {
+		col[i] = nan32
+	}
+	return col
+}
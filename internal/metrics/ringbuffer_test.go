@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"fmt"
+	"math"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -16,6 +19,15 @@ func makeSnapshot(i int) Snapshot {
 	}
 }
 
+// cpuValues returns the cpu column of h as float64s.
+func cpuValues(h *History) []float64 {
+	var values []float64
+	for _, v := range h.Column("cpu") {
+		values = append(values, float64(v))
+	}
+	return values
+}
+
 func TestRingBuffer_EmptyBuffer(t *testing.T) {
 	rb := NewRingBuffer(10)
 
@@ -55,10 +67,8 @@ func TestRingBuffer_AddAtCapacity(t *testing.T) {
 
 	// All should be [3,4,5,6,7] — oldest evicted
 	all := rb.All()
-	require.Len(t, all, 5)
-	for i, s := range all {
-		assert.Equal(t, float64(i+3), s.CPU.TotalPercent)
-	}
+	require.Equal(t, 5, all.Len())
+	assert.Equal(t, []float64{3, 4, 5, 6, 7}, cpuValues(all))
 }
 
 func TestRingBuffer_HistoryOrder(t *testing.T) {
@@ -69,11 +79,10 @@ func TestRingBuffer_HistoryOrder(t *testing.T) {
 	}
 
 	history := rb.History(3)
-	require.Len(t, history, 3)
+	require.Equal(t, 3, history.Len())
 	// Should be [2, 3, 4] — last 3, oldest first
-	assert.Equal(t, float64(2), history[0].CPU.TotalPercent)
-	assert.Equal(t, float64(3), history[1].CPU.TotalPercent)
-	assert.Equal(t, float64(4), history[2].CPU.TotalPercent)
+	assert.Equal(t, []float64{2, 3, 4}, cpuValues(history))
+	assert.True(t, history.Time(0).Before(history.Time(2)))
 }
 
 func TestRingBuffer_HistoryMoreThanAvailable(t *testing.T) {
@@ -84,7 +93,7 @@ func TestRingBuffer_HistoryMoreThanAvailable(t *testing.T) {
 	}
 
 	history := rb.History(100)
-	require.Len(t, history, 3)
+	require.Equal(t, 3, history.Len())
 }
 
 func TestRingBuffer_WrapAroundOrder(t *testing.T) {
@@ -96,11 +105,9 @@ func TestRingBuffer_WrapAroundOrder(t *testing.T) {
 	}
 
 	all := rb.All()
-	require.Len(t, all, 3)
+	require.Equal(t, 3, all.Len())
 	// Should be [2, 3, 4]
-	assert.Equal(t, float64(2), all[0].CPU.TotalPercent)
-	assert.Equal(t, float64(3), all[1].CPU.TotalPercent)
-	assert.Equal(t, float64(4), all[2].CPU.TotalPercent)
+	assert.Equal(t, []float64{2, 3, 4}, cpuValues(all))
 }
 
 func TestRingBuffer_ConcurrentAccess(t *testing.T) {
@@ -145,6 +152,223 @@ func TestRingBuffer_SingleEntry(t *testing.T) {
 	assert.Equal(t, float64(42), rb.Latest().CPU.TotalPercent)
 
 	all := rb.All()
-	require.Len(t, all, 1)
-	assert.Equal(t, float64(42), all[0].CPU.TotalPercent)
+	require.Equal(t, 1, all.Len())
+	assert.Equal(t, []float64{42}, cpuValues(all))
+}
+
+func TestRingBuffer_PerDeviceColumns(t *testing.T) {
+	rb := NewRingBuffer(3)
+
+	usb := makeSnapshot(0)
+	usb.Disks = []DiskPartition{{Path: "/", Percent: 50}, {Path: "/media/usb", Percent: 10}}
+	rb.Add(usb)
+
+	// The USB disk is unplugged
+	root := makeSnapshot(1)
+	root.Disks = []DiskPartition{{Path: "/", Percent: 51}}
+	rb.Add(root)
+
+	h := rb.All()
+	assert.Equal(t, []float32{50, 51}, h.Column("disk:/"))
+	col := h.Column("disk:/media/usb")
+	require.Len(t, col, 2)
+	assert.Equal(t, float32(10), col[0])
+	assert.True(t, math.IsNaN(float64(col[1])), "missing values are NaN")
+
+	// Once the last row with a value is overwritten the series is gone
+	rb.Add(root)
+	rb.Add(root)
+	assert.Nil(t, rb.All().Column("disk:/media/usb"))
+	assert.NotContains(t, rb.All().Names(), "disk:/media/usb")
+	assert.Len(t, rb.columns, len(root.Series()), "the column is freed")
+}
+
+func TestRingBuffer_OverwrittenRowsCleared(t *testing.T) {
+	rb := NewRingBuffer(2)
+
+	withSwap := makeSnapshot(0)
+	withSwap.RAM.SwapTotal = 1024
+	withSwap.RAM.SwapPercent = 30
+	rb.Add(withSwap)
+	rb.Add(withSwap)
+	rb.Add(makeSnapshot(2)) // overwrites the first row, which had swap
+
+	col := rb.All().Column("swap")
+	require.Len(t, col, 2)
+	assert.Equal(t, float32(30), col[0])
+	assert.True(t, math.IsNaN(float64(col[1])))
+}
+
+func TestRingBuffer_HistoryIsACopy(t *testing.T) {
+	rb := NewRingBuffer(2)
+	rb.Add(makeSnapshot(1))
+	h := rb.All()
+
+	rb.Add(makeSnapshot(2))
+	rb.Add(makeSnapshot(3))
+	assert.Equal(t, []float64{1}, cpuValues(h))
+}
+
+func TestRingBuffer_LatestKeepsFullSnapshot(t *testing.T) {
+	rb := NewRingBuffer(2)
+	s := makeSnapshot(1)
+	s.CPU.PerCore = []float64{10, 20}
+	s.TopCPU = []ProcessInfo{{PID: 1, Name: "init"}}
+	rb.Add(s)
+
+	latest := rb.Latest()
+	require.NotNil(t, latest)
+	assert.Equal(t, []float64{10, 20}, latest.CPU.PerCore)
+	assert.Len(t, latest.TopCPU, 1)
+	assert.True(t, s.Timestamp.Equal(rb.All().Time(0)))
+}
+
+func TestRingBuffer_CustomColumnsAreShort(t *testing.T) {
+	rb := NewRingBuffer(customColumnRows * 2)
+	rows := customColumnRows + 10
+	for i := 0; i < rows; i++ {
+		rb.Add(Snapshot{
+			Timestamp: time.Unix(int64(i), 0),
+			CPU:       CPUMetrics{TotalPercent: float64(i)},
+			Custom:    []CustomMetric{{Name: "queue", Value: float64(i)}},
+		})
+	}
+	assert.Len(t, rb.columns[seriesKey{seriesCustomPrefix, "queue"}].values, customColumnRows)
+	assert.Len(t, rb.columns[seriesKey{"cpu", ""}].values, customColumnRows*2)
+
+	h := rb.All()
+	require.Equal(t, rows, h.Len())
+	cpu, queue := h.Column("cpu"), h.Column("custom:queue")
+	assert.Equal(t, float32(0), cpu[0])
+	assert.Zero(t, h.Start("cpu"))
+	assert.Equal(t, 10, h.Start("custom:queue"))
+	assert.True(t, math.IsNaN(float64(queue[9])), "not kept in memory")
+	assert.Equal(t, float32(10), queue[10])
+	assert.Equal(t, float32(rows-1), queue[rows-1])
+
+	h = rb.History(5)
+	assert.Zero(t, h.Start("custom:queue"))
+	assert.Equal(t, []float32{float32(rows - 5), float32(rows - 4), float32(rows - 3), float32(rows - 2), float32(rows - 1)}, h.Column("custom:queue"))
+}
+
+func TestConcatHistory(t *testing.T) {
+	older := &History{times: []int64{1, 2}, columns: map[string][]float32{"cpu": {1, 2}, "temp": {40, 41}}}
+	newer := &History{times: []int64{3}, columns: map[string][]float32{"cpu": {3}, "disk:/": {70}}}
+
+	h := concatHistory(older, newer)
+	require.Equal(t, 3, h.Len())
+	assert.Equal(t, []string{"cpu", "disk:/", "temp"}, h.Names())
+	assert.Equal(t, []float32{1, 2, 3}, h.Column("cpu"))
+	assert.Equal(t, float32(70), h.Column("disk:/")[2])
+	assert.True(t, math.IsNaN(float64(h.Column("disk:/")[0])))
+	assert.True(t, math.IsNaN(float64(h.Column("temp")[2])))
+
+	assert.Same(t, newer, concatHistory(nil, newer))
+	assert.Nil(t, concatHistory(nil, nil))
+}
+
+// benchSnapshot is a reading from a typical small server: 4 cores, three
+// filesystems, two block devices, two interfaces and three sensors.
+func benchSnapshot(i int) Snapshot {
+	temp := 48.5
+	return Snapshot{
+		Timestamp: time.Unix(1700000000+int64(i), 0),
+		CPU: CPUMetrics{
+			TotalPercent: float64(i % 100), PerCore: []float64{10, 20, 30, 40},
+			Load1: 0.5, Load5: 0.4, Load15: 0.3, Times: CPUTimes{User: 5, System: 2, IOWait: 1},
+		},
+		RAM:    RAMMetrics{Total: 8 << 30, Used: 3 << 30, Percent: 37.5, SwapTotal: 1 << 30, SwapPercent: 2},
+		Disks:  []DiskPartition{{Path: "/", Device: "/dev/mmcblk0p2", Fstype: "ext4", Percent: 61}, {Path: "/boot/firmware", Device: "/dev/mmcblk0p1", Fstype: "vfat", Percent: 12}, {Path: "/data", Device: "/dev/sda1", Fstype: "ext4", Percent: 80}},
+		DiskIO: []DiskIO{{Device: "mmcblk0", ReadBytesPS: 4096, WriteBytesPS: 8192, BusyPercent: 3}, {Device: "sda", ReadBytesPS: 1 << 20, BusyPercent: 20}},
+		Networks: []NetworkIface{
+			{Name: "eth0", BytesSentPS: 12000, BytesRecvPS: 34000, OperState: "up", MAC: "dc:a6:32:00:00:01", Addrs: []string{"192.168.1.10/24", "fe80::1/64"}},
+			{Name: "wlan0", OperState: "down", MAC: "dc:a6:32:00:00:02"},
+		},
+		Temperature:  &temp,
+		Temperatures: []TempSensor{{Label: "cpu_thermal", Celsius: 48.5}, {Label: "nvme_composite", Celsius: 39}, {Label: "rp1_adc", Celsius: 51}},
+	}
+}
+
+// heapInUse returns the live heap after a full collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// BenchmarkRingBuffer_MemoryPerHour reports the heap held by an hour of
+// history at a 1s interval, with built-in series only and with the maximum
+// number of pushed series. Multiply the built-in figure by 24 for the default
+// retention; custom series columns don't grow past customColumnRows.
+func BenchmarkRingBuffer_MemoryPerHour(b *testing.B) {
+	b.Run("builtin", func(b *testing.B) { benchRingBufferMemory(b, 0) })
+	b.Run("max_ingest_series", func(b *testing.B) { benchRingBufferMemory(b, maxIngestSeries) })
+}
+
+func benchRingBufferMemory(b *testing.B, customSeries int) {
+	const rows = 3600
+	custom := make([]CustomMetric, customSeries)
+	for i := range custom {
+		custom[i] = CustomMetric{Name: fmt.Sprintf("app.requests{route=r%d}", i), Collector: "statsd", Value: 1}
+	}
+	snaps := make([]Snapshot, rows)
+	for i := range snaps {
+		snaps[i] = benchSnapshot(i)
+		snaps[i].Custom = custom
+	}
+
+	var perHour uint64
+	for n := 0; n < b.N; n++ {
+		before := heapInUse()
+		rb := NewRingBuffer(rows)
+		for i := range snaps {
+			rb.Add(snaps[i])
+		}
+		perHour = heapInUse() - before
+		runtime.KeepAlive(rb)
+	}
+	b.ReportMetric(float64(perHour), "bytes/hour")
+	b.ReportMetric(float64(perHour)/rows, "bytes/row")
+}
+
+// BenchmarkSnapshotSlice_MemoryPerHour is the baseline the columnar buffer
+// replaced: an hour of whole snapshots, each with its own slices.
+func BenchmarkSnapshotSlice_MemoryPerHour(b *testing.B) {
+	const rows = 3600
+
+	var perHour uint64
+	for n := 0; n < b.N; n++ {
+		before := heapInUse()
+		snaps := make([]Snapshot, rows)
+		for i := range snaps {
+			snaps[i] = benchSnapshot(i)
+		}
+		perHour = heapInUse() - before
+		runtime.KeepAlive(snaps)
+	}
+	b.ReportMetric(float64(perHour), "bytes/hour")
+	b.ReportMetric(float64(perHour)/rows, "bytes/row")
+}
+
+func BenchmarkRingBuffer_Add(b *testing.B) {
+	rb := NewRingBuffer(86400)
+	s := benchSnapshot(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.Add(s)
+	}
+}
+
+func BenchmarkRingBuffer_History(b *testing.B) {
+	rb := NewRingBuffer(86400)
+	for i := 0; i < 86400; i++ {
+		rb.Add(benchSnapshot(i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.History(720)
+	}
 }
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSections builds a reader whose Read runs only the given sections.
+func fakeSections(timeout time.Duration, sections ...readerSection) *SystemReader {
+	r := NewSystemReader()
+	r.sections = sections
+	r.SetReaderTimeout(timeout)
+	return r
+}
+
+func ramSection(read func(ctx context.Context, s *Snapshot) error) readerSection {
+	return readerSection{
+		name:  "ram",
+		read:  func(ctx context.Context, s *Snapshot, _ time.Time) error { return read(ctx, s) },
+		merge: func(dst, src *Snapshot) { dst.RAM = src.RAM },
+	}
+}
+
+func diskSection(read func(ctx context.Context, s *Snapshot) error) readerSection {
+	return readerSection{
+		name:  "disks",
+		read:  func(ctx context.Context, s *Snapshot, _ time.Time) error { return read(ctx, s) },
+		merge: func(dst, src *Snapshot) { dst.Disks = src.Disks },
+	}
+}
+
+func TestRead_SectionsMerged(t *testing.T) {
+	r := fakeSections(time.Second,
+		ramSection(func(_ context.Context, s *Snapshot) error { s.RAM.Percent = 42; return nil }),
+		diskSection(func(_ context.Context, s *Snapshot) error {
+			s.Disks = []DiskPartition{{Path: "/"}}
+			s.RAM.Percent = 99 // not owned by this section, must not leak
+			return nil
+		}),
+	)
+
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 42.0, s.RAM.Percent)
+	assert.Len(t, s.Disks, 1)
+	assert.Empty(t, s.Errors)
+}
+
+func TestRead_HungSectionTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	r := fakeSections(50*time.Millisecond,
+		ramSection(func(_ context.Context, s *Snapshot) error { s.RAM.Percent = 42; return nil }),
+		// Ignores its context, like a statfs on a hung NFS mount
+		diskSection(func(_ context.Context, s *Snapshot) error { <-release; return nil }),
+	)
+
+	start := time.Now()
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second, "a hung section must not stall the reading")
+
+	assert.Equal(t, 42.0, s.RAM.Percent)
+	re := s.ErrorFor("disks")
+	require.NotNil(t, re)
+	assert.True(t, re.TimedOut)
+	assert.Contains(t, re.Error, "timed out")
+	assert.GreaterOrEqual(t, re.Duration, 50*time.Millisecond)
+	assert.False(t, re.Stale, "never succeeded, so nothing to carry over")
+	assert.Nil(t, s.ErrorFor("ram"))
+
+	// The hung read is still in flight: it is not started a second time.
+	s2, err := r.Read(context.Background())
+	require.NoError(t, err)
+	re = s2.ErrorFor("disks")
+	require.NotNil(t, re)
+	assert.Contains(t, re.Error, "still running")
+}
+
+func TestRead_FailedSectionKeepsStaleValues(t *testing.T) {
+	fail := false
+	r := fakeSections(time.Second,
+		diskSection(func(_ context.Context, s *Snapshot) error {
+			if fail {
+				return errors.New("cannot read disk partitions: boom")
+			}
+			s.Disks = []DiskPartition{{Path: "/", Percent: 71}}
+			return nil
+		}),
+	)
+
+	first, err := r.Read(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, first.Errors)
+
+	fail = true
+	for i := 0; i < 2; i++ {
+		s, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Len(t, s.Disks, 1, "stale values are kept across consecutive failures")
+		assert.Equal(t, 71.0, s.Disks[0].Percent)
+
+		re := s.ErrorFor("disks")
+		require.NotNil(t, re)
+		assert.True(t, re.Stale)
+		assert.False(t, re.TimedOut)
+		assert.Equal(t, first.Timestamp, re.LastSuccess)
+		assert.Contains(t, re.Error, "boom")
+	}
+}
+
+func TestRead_FailedAppendingSectionKeepsOnlyItsOwnValues(t *testing.T) {
+	// The custom and ingest sections both append to Custom and Errors.
+	appending := func(name string, fail *bool) readerSection {
+		return readerSection{
+			name: name,
+			read: func(_ context.Context, s *Snapshot, _ time.Time) error {
+				if *fail {
+					return errors.New("boom")
+				}
+				s.Custom = []CustomMetric{{Name: name + ".value", Value: 1}}
+				s.Errors = []ReaderError{{Reader: name + ":script", Error: "exit status 2"}}
+				return nil
+			},
+			merge: func(dst, src *Snapshot) {
+				dst.Custom = append(dst.Custom, src.Custom...)
+				dst.Errors = append(dst.Errors, src.Errors...)
+			},
+		}
+	}
+	var customFails, ingestFails bool
+	r := fakeSections(time.Second, appending("custom", &customFails), appending("ingest", &ingestFails))
+
+	_, err := r.Read(context.Background())
+	require.NoError(t, err)
+
+	customFails = true
+	for i := 0; i < 3; i++ {
+		s, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Len(t, s.Custom, 2, "each section's values appear once")
+		assert.Equal(t, "custom.value", s.Custom[0].Name)
+		assert.Equal(t, "ingest.value", s.Custom[1].Name)
+		assert.Len(t, s.Errors, 3, "old errors do not pile up")
+		re := s.ErrorFor("custom")
+		require.NotNil(t, re)
+		assert.True(t, re.Stale)
+	}
+}
+
+func TestRead_DefaultSectionsNoErrors(t *testing.T) {
+	r := NewSystemReader()
+	r.pi = nil
+
+	s, err := r.Read(context.Background())
+	require.NoError(t, err)
+	for _, e := range s.Errors {
+		assert.Fail(t, "unexpected reader error", "%s: %s", e.Reader, e.Error)
+	}
+}
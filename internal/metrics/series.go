@@ -0,0 +1,65 @@
+package metrics
+
+// Series name prefixes for per-device values. A full series name is the
+// prefix followed by the mountpoint or interface name, e.g. "disk:/".
+const (
+	seriesDiskPrefix      = "disk:"
+	seriesDiskReadPrefix  = "disk_read:"
+	seriesDiskWritePrefix = "disk_write:"
+	seriesDiskBusyPrefix  = "disk_busy:"
+	seriesNetTxPrefix     = "net_tx:"
+	seriesNetRxPrefix     = "net_rx:"
+	seriesTempPrefix      = "temp:"
+	seriesCustomPrefix    = "custom:"
+)
+
+// Series flattens a snapshot into named scalar values, the form used for
+// persistence and range queries.
+func (s *Snapshot) Series() map[string]float64 {
+	values := make(map[string]float64)
+	s.eachSeries(func(prefix, name string, v float64) {
+		values[prefix+name] = v
+	})
+	return values
+}
+
+// eachSeries calls fn for every value Series emits. The series name is prefix
+// followed by name; name is the device, mountpoint, interface or sensor for
+// per-device series and empty otherwise. Keeping the two apart lets the ring
+// buffer look up columns without building a string per value.
+func (s *Snapshot) eachSeries(fn func(prefix, name string, v float64)) {
+	fn("cpu", "", s.CPU.TotalPercent)
+	fn("ram", "", s.RAM.Percent)
+	fn("load1", "", s.CPU.Load1)
+	fn("load5", "", s.CPU.Load5)
+	fn("load15", "", s.CPU.Load15)
+	fn("iowait", "", s.CPU.Times.IOWait)
+	fn("steal", "", s.CPU.Times.Steal)
+	if s.RAM.SwapTotal > 0 {
+		fn("swap", "", s.RAM.SwapPercent)
+	}
+	if s.RAM.Pressure != nil {
+		fn("mem_pressure", "", s.RAM.Pressure.SomeAvg10)
+	}
+	for _, d := range s.Disks {
+		fn(seriesDiskPrefix, d.Path, d.Percent)
+	}
+	for _, d := range s.DiskIO {
+		fn(seriesDiskReadPrefix, d.Device, float64(d.ReadBytesPS))
+		fn(seriesDiskWritePrefix, d.Device, float64(d.WriteBytesPS))
+		fn(seriesDiskBusyPrefix, d.Device, d.BusyPercent)
+	}
+	for _, n := range s.Networks {
+		fn(seriesNetTxPrefix, n.Name, float64(n.BytesSentPS))
+		fn(seriesNetRxPrefix, n.Name, float64(n.BytesRecvPS))
+	}
+	if s.Temperature != nil {
+		fn("temp", "", *s.Temperature)
+	}
+	for _, t := range s.Temperatures {
+		fn(seriesTempPrefix, t.Label, t.Celsius)
+	}
+	for _, m := range s.Custom {
+		fn(seriesCustomPrefix, m.Name, m.Value)
+	}
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// prevSystemCounters stores the previous CPU times and kernel counters for
+// rate calculation.
+type prevSystemCounters struct {
+	times      cpu.TimesStat
+	ctxt       uint64
+	interrupts uint64
+	timestamp  time.Time
+}
+
+// procStat holds the counters of interest from /proc/stat.
+type procStat struct {
+	ctxt         uint64
+	interrupts   uint64
+	procsRunning int
+	procsBlocked int
+}
+
+func (r *SystemReader) readLoad(ctx context.Context, s *Snapshot) error {
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read load average: %w", err)
+	}
+	s.CPU.Load1 = avg.Load1
+	s.CPU.Load5 = avg.Load5
+	s.CPU.Load15 = avg.Load15
+	return nil
+}
+
+func (r *SystemReader) readSystem(ctx context.Context, s *Snapshot, now time.Time) error {
+	uptime, err := host.UptimeWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read uptime: %w", err)
+	}
+	s.System.UptimeSeconds = uptime
+
+	if threads, err := readThreadCount(r.procDir); err == nil {
+		s.System.Threads = threads
+	}
+
+	var cur prevSystemCounters
+	cur.timestamp = now
+
+	times, err := cpu.TimesWithContext(ctx, false)
+	if err != nil {
+		return fmt.Errorf("cannot read CPU times: %w", err)
+	}
+	if len(times) > 0 {
+		cur.times = times[0]
+	}
+
+	// /proc/stat only exists on Linux; elsewhere the counters stay 0.
+	stat, statErr := readProcStat(r.procDir)
+	if statErr == nil {
+		cur.ctxt = stat.ctxt
+		cur.interrupts = stat.interrupts
+		s.System.ProcsRunning = stat.procsRunning
+		s.System.ProcsBlocked = stat.procsBlocked
+	}
+
+	r.prevSystemMu.Lock()
+	defer r.prevSystemMu.Unlock()
+
+	if prev := r.prevSystem; prev != nil {
+		s.CPU.Times = cpuTimesPercent(prev.times, cur.times)
+		if elapsed := now.Sub(prev.timestamp).Seconds(); elapsed > 0 && statErr == nil {
+			s.System.ContextSwitchesPS = float64(counterDelta(cur.ctxt, prev.ctxt)) / elapsed
+			s.System.InterruptsPS = float64(counterDelta(cur.interrupts, prev.interrupts)) / elapsed
+		}
+	}
+	// First reading: breakdown and rates stay 0
+
+	r.prevSystem = &cur
+	return nil
+}
+
+// cpuTimesPercent converts the difference between two cumulative CPU time
+// readings into the percentage of time spent in each mode.
+func cpuTimesPercent(prev, cur cpu.TimesStat) CPUTimes {
+	d := CPUTimes{
+		User:    cur.User - prev.User,
+		Nice:    cur.Nice - prev.Nice,
+		System:  cur.System - prev.System,
+		Idle:    cur.Idle - prev.Idle,
+		IOWait:  cur.Iowait - prev.Iowait,
+		IRQ:     cur.Irq - prev.Irq,
+		SoftIRQ: cur.Softirq - prev.Softirq,
+		Steal:   cur.Steal - prev.Steal,
+	}
+	// Guest time is already included in User on Linux, so it is not added.
+	total := d.User + d.Nice + d.System + d.Idle + d.IOWait + d.IRQ + d.SoftIRQ + d.Steal
+	if total <= 0 {
+		return CPUTimes{}
+	}
+	pct := func(v float64) float64 { return v / total * 100 }
+	return CPUTimes{
+		User:    pct(d.User),
+		Nice:    pct(d.Nice),
+		System:  pct(d.System),
+		Idle:    pct(d.Idle),
+		IOWait:  pct(d.IOWait),
+		IRQ:     pct(d.IRQ),
+		SoftIRQ: pct(d.SoftIRQ),
+		Steal:   pct(d.Steal),
+	}
+}
+
+// readProcStat parses context switches, interrupts and runnable/blocked
+// process counts from <procDir>/stat.
+func readProcStat(procDir string) (procStat, error) {
+	var st procStat
+	f, err := os.Open(filepath.Join(procDir, "stat"))
+	if err != nil {
+		return st, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// The intr line lists every IRQ and can be long on big machines.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "ctxt":
+			st.ctxt, _ = strconv.ParseUint(fields[1], 10, 64)
+		case "intr":
+			st.interrupts, _ = strconv.ParseUint(fields[1], 10, 64)
+		case "procs_running":
+			st.procsRunning, _ = strconv.Atoi(fields[1])
+		case "procs_blocked":
+			st.procsBlocked, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return st, scanner.Err()
+}
+
+// readThreadCount returns the number of kernel scheduling entities (threads)
+// from the fourth field of <procDir>/loadavg, e.g. "1/523".
+func readThreadCount(procDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(procDir, "loadavg"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("unexpected loadavg format %q", strings.TrimSpace(string(data)))
+	}
+	_, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return 0, fmt.Errorf("unexpected loadavg format %q", strings.TrimSpace(string(data)))
+	}
+	return strconv.Atoi(total)
+}
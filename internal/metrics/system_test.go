@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleProcStat = `cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+intr 1462898 29 0 0 0 0 0 0 0 1 0 0 0 0 0 0
+ctxt 3318462
+btime 1700000000
+processes 47001
+procs_running 3
+procs_blocked 2
+softirq 2001 0 1 2 3
+`
+
+func writeProcFiles(t *testing.T, stat, loadavg string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "loadavg"), []byte(loadavg), 0644))
+	return dir
+}
+
+func TestReadProcStat(t *testing.T) {
+	dir := writeProcFiles(t, sampleProcStat, "")
+
+	st, err := readProcStat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3318462), st.ctxt)
+	assert.Equal(t, uint64(1462898), st.interrupts)
+	assert.Equal(t, 3, st.procsRunning)
+	assert.Equal(t, 2, st.procsBlocked)
+}
+
+func TestReadProcStat_Missing(t *testing.T) {
+	_, err := readProcStat(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestReadThreadCount(t *testing.T) {
+	dir := writeProcFiles(t, "", "0.52 0.58 0.59 2/523 12345\n")
+
+	threads, err := readThreadCount(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 523, threads)
+}
+
+func TestReadThreadCount_Malformed(t *testing.T) {
+	dir := writeProcFiles(t, "", "0.52 0.58\n")
+	_, err := readThreadCount(dir)
+	assert.Error(t, err)
+}
+
+func TestCPUTimesPercent(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 10, Steal: 0, Softirq: 5}
+	cur := cpu.TimesStat{User: 120, System: 60, Idle: 850, Iowait: 40, Steal: 10, Softirq: 15, Guest: 99}
+
+	pct := cpuTimesPercent(prev, cur)
+	// Deltas: user 20, system 10, idle 50, iowait 30, steal 10, softirq 10 = 130
+	assert.InDelta(t, 20.0/130*100, pct.User, 0.001)
+	assert.InDelta(t, 10.0/130*100, pct.System, 0.001)
+	assert.InDelta(t, 30.0/130*100, pct.IOWait, 0.001)
+	assert.InDelta(t, 10.0/130*100, pct.Steal, 0.001)
+	assert.InDelta(t, 10.0/130*100, pct.SoftIRQ, 0.001)
+	assert.InDelta(t, 100.0, pct.User+pct.System+pct.Idle+pct.IOWait+pct.Steal+pct.SoftIRQ, 0.001)
+}
+
+func TestCPUTimesPercent_NoElapsedTime(t *testing.T) {
+	times := cpu.TimesStat{User: 100, Idle: 800}
+	assert.Equal(t, CPUTimes{}, cpuTimesPercent(times, times))
+}
+
+func TestSystemReader_ReadSystem(t *testing.T) {
+	r := NewSystemReader()
+	ctx := context.Background()
+
+	s, err := r.Read(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, s.System.UptimeSeconds, uint64(0))
+	assert.Greater(t, s.System.Processes, 0)
+	assert.GreaterOrEqual(t, s.CPU.Load1, 0.0)
+
+	// First reading: rates and breakdown stay 0
+	assert.Equal(t, 0.0, s.System.ContextSwitchesPS)
+	assert.Equal(t, CPUTimes{}, s.CPU.Times)
+
+	s2, err := r.Read(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, s2.CPU.Times.IOWait, 0.0)
+	assert.LessOrEqual(t, s2.CPU.Times.IOWait, 100.0)
+}
+
+func TestSystemReader_CounterRates(t *testing.T) {
+	r := NewSystemReader()
+	r.procDir = writeProcFiles(t, "ctxt 1000\nintr 500\nprocs_running 1\nprocs_blocked 4\n", "0 0 0 1/300 1\n")
+	ctx := context.Background()
+
+	s1 := &Snapshot{}
+	start := time.Now()
+	r.readSystem(ctx, s1, start)
+	assert.Equal(t, 300, s1.System.Threads)
+	assert.Equal(t, 4, s1.System.ProcsBlocked)
+	assert.Equal(t, 0.0, s1.System.ContextSwitchesPS)
+
+	require.NoError(t, os.WriteFile(filepath.Join(r.procDir, "stat"), []byte("ctxt 3000\nintr 1500\n"), 0644))
+	s2 := &Snapshot{}
+	r.readSystem(ctx, s2, start.Add(2*time.Second))
+	assert.InDelta(t, 1000.0, s2.System.ContextSwitchesPS, 0.001)
+	assert.InDelta(t, 500.0, s2.System.InterruptsPS, 0.001)
+}
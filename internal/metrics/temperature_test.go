@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeThermalZone creates a fake sysfs thermal zone under dir.
+func writeThermalZone(t *testing.T, dir, zone, typ, milliC string) {
+	t.Helper()
+	path := filepath.Join(dir, zone)
+	require.NoError(t, os.MkdirAll(path, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "type"), []byte(typ+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "temp"), []byte(milliC+"\n"), 0644))
+}
+
+// newTempReader returns a reader whose sensors come from fixed hwmon readings
+// and a fake thermal directory.
+func newTempReader(t *testing.T, hwmon []sensors.TemperatureStat) (*SystemReader, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r := NewSystemReader()
+	r.thermalDir = dir
+	r.hwmonTemps = func(context.Context) ([]sensors.TemperatureStat, error) { return hwmon, nil }
+	return r, dir
+}
+
+func TestReadThermalZones(t *testing.T) {
+	dir := t.TempDir()
+	writeThermalZone(t, dir, "thermal_zone0", "cpu-thermal", "52100")
+	writeThermalZone(t, dir, "thermal_zone1", "acpitz", "40000")
+	writeThermalZone(t, dir, "thermal_zone2", "acpitz", "41000")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cooling_device0"), 0755))
+
+	zones := readThermalZones(dir)
+	assert.Equal(t, []TempSensor{
+		{Label: "cpu-thermal", Celsius: 52.1},
+		{Label: "acpitz", Celsius: 40},
+		{Label: "acpitz/thermal_zone2", Celsius: 41},
+	}, zones)
+}
+
+func TestReadThermalZones_Missing(t *testing.T) {
+	assert.Empty(t, readThermalZones(filepath.Join(t.TempDir(), "nope")))
+}
+
+func TestReadTemperature_AllSensorsLabeled(t *testing.T) {
+	r, dir := newTempReader(t, []sensors.TemperatureStat{
+		{SensorKey: "coretemp_package_id_0", Temperature: 55},
+		{SensorKey: "nvme_composite", Temperature: 41},
+		{SensorKey: "disconnected", Temperature: 0},
+	})
+	writeThermalZone(t, dir, "thermal_zone0", "x86_pkg_temp", "56000")
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	assert.Equal(t, []TempSensor{
+		{Label: "coretemp_package_id_0", Celsius: 55},
+		{Label: "nvme_composite", Celsius: 41},
+		{Label: "x86_pkg_temp", Celsius: 56},
+	}, s.Temperatures)
+
+	// Without configuration the first sensor is primary
+	require.NotNil(t, s.Temperature)
+	assert.Equal(t, 55.0, *s.Temperature)
+	assert.Equal(t, "coretemp_package_id_0", s.PrimaryTemp)
+}
+
+func TestReadTemperature_ConfiguredPrimary(t *testing.T) {
+	r, dir := newTempReader(t, []sensors.TemperatureStat{{SensorKey: "cpu_thermal", Temperature: 60}})
+	writeThermalZone(t, dir, "thermal_zone0", "pmic", "48000")
+	r.SetPrimaryTempSensor("pmic")
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	require.NotNil(t, s.Temperature)
+	assert.Equal(t, 48.0, *s.Temperature)
+	assert.Equal(t, "pmic", s.PrimaryTemp)
+}
+
+func TestReadTemperature_UnknownPrimaryFallsBack(t *testing.T) {
+	r, _ := newTempReader(t, []sensors.TemperatureStat{{SensorKey: "cpu_thermal", Temperature: 60}})
+	r.SetPrimaryTempSensor("does-not-exist")
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	require.NotNil(t, s.Temperature)
+	assert.Equal(t, 60.0, *s.Temperature)
+	assert.Equal(t, "cpu_thermal", s.PrimaryTemp)
+}
+
+func TestReadTemperature_ZoneFallbackNotDuplicated(t *testing.T) {
+	// Without hwmon, gopsutil itself reports the thermal zones by type
+	r, dir := newTempReader(t, []sensors.TemperatureStat{{SensorKey: "cpu-thermal", Temperature: 52.1}})
+	writeThermalZone(t, dir, "thermal_zone0", "cpu-thermal", "52100")
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	assert.Len(t, s.Temperatures, 1)
+}
+
+func TestReadTemperature_PiZoneNotDuplicated(t *testing.T) {
+	// A Raspberry Pi reports its CPU as hwmon "cpu_thermal" and as
+	// thermal_zone0 of type "cpu-thermal".
+	r, dir := newTempReader(t, []sensors.TemperatureStat{
+		{SensorKey: "cpu_thermal", Temperature: 52.1},
+		{SensorKey: "rp1_adc", Temperature: 47.3},
+	})
+	writeThermalZone(t, dir, "thermal_zone0", "cpu-thermal", "52100")
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	assert.Equal(t, []TempSensor{
+		{Label: "cpu_thermal", Celsius: 52.1},
+		{Label: "rp1_adc", Celsius: 47.3},
+	}, s.Temperatures)
+	assert.Equal(t, "cpu_thermal", s.PrimaryTemp)
+}
+
+func TestReadTemperature_PartialWarnings(t *testing.T) {
+	r, _ := newTempReader(t, nil)
+	r.hwmonTemps = func(context.Context) ([]sensors.TemperatureStat, error) {
+		return []sensors.TemperatureStat{{SensorKey: "cpu_thermal", Temperature: 50}}, errors.New("some sensors unreadable")
+	}
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	require.Len(t, s.Temperatures, 1)
+	assert.Equal(t, "cpu_thermal", s.Temperatures[0].Label)
+}
+
+func TestReadTemperature_NoSensors(t *testing.T) {
+	r, _ := newTempReader(t, nil)
+
+	s := &Snapshot{}
+	r.readTemperature(context.Background(), s)
+
+	assert.Nil(t, s.Temperature)
+	assert.Empty(t, s.Temperatures)
+	assert.Empty(t, s.PrimaryTemp)
+}
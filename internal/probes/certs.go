@@ -0,0 +1,168 @@
+package probes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+// CertInfo describes a certificate found by a tls or cert probe.
+type CertInfo struct {
+	Source    string    `json:"source"` // host:port or file the certificate came from
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// DaysLeft returns the days until the certificate expires, negative once it has.
+func (c CertInfo) DaysLeft(now time.Time) float64 {
+	return c.NotAfter.Sub(now).Hours() / 24
+}
+
+// Name is a short label for the certificate: its first SAN, or its subject.
+func (c CertInfo) Name() string {
+	if len(c.SANs) > 0 {
+		return c.SANs[0]
+	}
+	return c.Subject
+}
+
+func certInfo(source string, cert *x509.Certificate) CertInfo {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	issuer := cert.Issuer.CommonName
+	if issuer == "" && len(cert.Issuer.Organization) > 0 {
+		issuer = cert.Issuer.Organization[0]
+	}
+	return CertInfo{
+		Source:    source,
+		Subject:   cert.Subject.CommonName,
+		Issuer:    issuer,
+		SANs:      sans,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
+
+// checkTLS performs a TLS handshake with p.Target and returns the leaf
+// certificate. The chain is not verified, so self-signed certificates on the
+// LAN are watched too; only their dates matter here.
+func (c *checker) checkTLS(ctx context.Context, p database.Probe) ([]CertInfo, error) {
+	host, _, err := net.SplitHostPort(p.Target)
+	if err != nil {
+		return nil, err
+	}
+	d := tls.Dialer{Config: &tls.Config{ServerName: host, InsecureSkipVerify: true}}
+	conn, err := d.DialContext(ctx, "tcp", p.Target)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("no certificate presented")
+	}
+	info := certInfo(p.Target, state.PeerCertificates[0])
+	return []CertInfo{info}, checkValidity(info, time.Now())
+}
+
+// checkCertFiles reads the first certificate of every PEM file matching the
+// p.Target pattern, e.g. /etc/letsencrypt/live/*/fullchain.pem.
+func (c *checker) checkCertFiles(p database.Probe) ([]CertInfo, error) {
+	paths, err := filepath.Glob(p.Target)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %s", p.Target)
+	}
+	sort.Strings(paths)
+
+	now := time.Now()
+	var certs []CertInfo
+	var errs []error
+	for _, path := range paths {
+		cert, err := readCertFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		info := certInfo(path, cert)
+		certs = append(certs, info)
+		if err := checkValidity(info, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return certs, errors.Join(errs...)
+}
+
+func readCertFile(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no certificate found", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return cert, nil
+		}
+	}
+}
+
+// checkValidity fails for a certificate that has expired or is not valid yet.
+func checkValidity(c CertInfo, now time.Time) error {
+	if days := c.DaysLeft(now); days < 0 {
+		return fmt.Errorf("certificate for %s expired %.0f days ago", c.Name(), math.Ceil(-days))
+	}
+	if now.Before(c.NotBefore) {
+		return fmt.Errorf("certificate for %s is not valid until %s", c.Name(), c.NotBefore.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// encodeCerts stores certificates in a ProbeResult detail.
+func encodeCerts(certs []CertInfo) string {
+	if len(certs) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(certs)
+	return string(b)
+}
+
+// decodeCerts reads the certificates stored by encodeCerts.
+func decodeCerts(detail string) []CertInfo {
+	if !strings.HasPrefix(detail, "[") {
+		return nil
+	}
+	var certs []CertInfo
+	if err := json.Unmarshal([]byte(detail), &certs); err != nil {
+		return nil
+	}
+	return certs
+}
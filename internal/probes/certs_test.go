@@ -0,0 +1,121 @@
+package probes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+// writeCert writes a self-signed PEM certificate for name valid from
+// notBefore to notAfter to dir/name/fullchain.pem.
+func writeCert(t *testing.T, dir, name string, notBefore, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		Issuer:       pkix.Name{CommonName: "Test CA"},
+		DNSNames:     []string{name, "www." + name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name, "fullchain.pem")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	return path
+}
+
+func TestCheckCertFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeCert(t, dir, "cloud.example.com", now.Add(-80*24*time.Hour), now.Add(10*24*time.Hour+time.Hour))
+	writeCert(t, dir, "git.example.com", now.Add(-30*24*time.Hour), now.Add(60*24*time.Hour))
+
+	r := newChecker(nil).check(context.Background(), database.Probe{Type: TypeCert, Target: filepath.Join(dir, "*", "fullchain.pem"), TimeoutSeconds: 5})
+	require.True(t, r.Up, r.Error)
+
+	certs := decodeCerts(r.Detail)
+	require.Len(t, certs, 2)
+	assert.Equal(t, "cloud.example.com", certs[0].Subject)
+	assert.Equal(t, "cloud.example.com", certs[0].Name())
+	assert.Equal(t, []string{"cloud.example.com", "www.cloud.example.com"}, certs[0].SANs)
+	assert.Equal(t, filepath.Join(dir, "cloud.example.com", "fullchain.pem"), certs[0].Source)
+	assert.Equal(t, 10, int(certs[0].DaysLeft(now)))
+	assert.Equal(t, "git.example.com", certs[1].Subject)
+}
+
+func TestCheckCertFiles_Expired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeCert(t, dir, "old.example.com", now.Add(-90*24*time.Hour), now.Add(-2*24*time.Hour-time.Hour))
+	writeCert(t, dir, "ok.example.com", now.Add(-time.Hour), now.Add(60*24*time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "junk.pem"), []byte("not a certificate"), 0644))
+
+	r := newChecker(nil).check(context.Background(), database.Probe{Type: TypeCert, Target: filepath.Join(dir, "*", "fullchain.pem"), TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.Equal(t, "certificate for old.example.com expired 3 days ago", r.Error)
+	assert.Len(t, decodeCerts(r.Detail), 2, "details are kept for every certificate read")
+
+	r = newChecker(nil).check(context.Background(), database.Probe{Type: TypeCert, Target: filepath.Join(dir, "junk.pem"), TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, "no certificate found")
+
+	r = newChecker(nil).check(context.Background(), database.Probe{Type: TypeCert, Target: filepath.Join(dir, "missing", "*.pem"), TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, "no files match")
+}
+
+func TestCheckTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	leaf := srv.Certificate()
+
+	target := srv.Listener.Addr().String()
+	r := newChecker(nil).check(context.Background(), database.Probe{Type: TypeTLS, Target: target, TimeoutSeconds: 5})
+	require.True(t, r.Up, r.Error)
+
+	certs := decodeCerts(r.Detail)
+	require.Len(t, certs, 1)
+	assert.Equal(t, target, certs[0].Source)
+	assert.Equal(t, leaf.NotAfter.Unix(), certs[0].NotAfter.Unix())
+	assert.Contains(t, certs[0].SANs, "127.0.0.1")
+	assert.NotEmpty(t, certs[0].Issuer)
+}
+
+func TestCheckTLS_Refused(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	target := srv.Listener.Addr().String()
+	srv.Close()
+
+	r := newChecker(nil).check(context.Background(), database.Probe{Type: TypeTLS, Target: target, TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.Empty(t, r.Detail)
+}
+
+func TestDecodeCerts(t *testing.T) {
+	assert.Nil(t, decodeCerts(""))
+	assert.Nil(t, decodeCerts("{}"))
+	assert.Nil(t, decodeCerts("[broken"))
+	certs := []CertInfo{{Source: "a:443", Subject: "a", NotAfter: time.Unix(1700000000, 0).UTC()}}
+	assert.Equal(t, certs, decodeCerts(encodeCerts(certs)))
+}
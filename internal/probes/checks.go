@@ -0,0 +1,191 @@
+package probes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/execrunner"
+)
+
+// Probe types.
+const (
+	TypeHTTP = "http"
+	TypeTCP  = "tcp"
+	TypeDNS  = "dns"
+	TypeICMP = "icmp"
+	TypeTLS  = "tls"
+	TypeCert = "cert"
+)
+
+// bodyLimit caps how much of an HTTP response is searched for Expect.
+const bodyLimit = 1 << 20
+
+// pingTime matches the round-trip time in ping output, e.g. "time=0.045 ms".
+var pingTime = regexp.MustCompile(`time[=<]([0-9.]+) ?ms`)
+
+// checker runs a single probe.
+type checker struct {
+	client   *http.Client
+	resolver *net.Resolver
+	runner   execrunner.Runner
+}
+
+func newChecker(runner execrunner.Runner) *checker {
+	return &checker{
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DisableKeepAlives: true, // measure a full connection every time
+			},
+		},
+		resolver: net.DefaultResolver,
+		runner:   runner,
+	}
+}
+
+// check runs p once under its timeout. A check that succeeds but exceeds
+// p.MaxLatencyMs counts as down.
+func (c *checker) check(ctx context.Context, p database.Probe) database.ProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var (
+		latency time.Duration
+		certs   []CertInfo
+		err     error
+	)
+	switch p.Type {
+	case TypeHTTP:
+		err = c.checkHTTP(ctx, p)
+	case TypeTCP:
+		err = c.checkTCP(ctx, p)
+	case TypeDNS:
+		err = c.checkDNS(ctx, p)
+	case TypeICMP:
+		latency, err = c.checkICMP(ctx, p)
+	case TypeTLS:
+		certs, err = c.checkTLS(ctx, p)
+	case TypeCert:
+		certs, err = c.checkCertFiles(p)
+	default:
+		err = fmt.Errorf("unknown probe type %q", p.Type)
+	}
+	if latency == 0 {
+		latency = time.Since(start)
+	}
+
+	result := database.ProbeResult{
+		ProbeID:   p.ID,
+		Timestamp: start,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		Detail:    encodeCerts(certs),
+	}
+	if err == nil && p.MaxLatencyMs > 0 && result.LatencyMs > float64(p.MaxLatencyMs) {
+		err = fmt.Errorf("too slow: %.0f ms > %d ms", result.LatencyMs, p.MaxLatencyMs)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && err != nil {
+		err = fmt.Errorf("timed out after %ds", p.TimeoutSeconds)
+	}
+
+	result.Up = err == nil
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkHTTP requests p.Target and checks the status code and body.
+func (c *checker) checkHTTP(ctx context.Context, p database.Probe) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ultron-ap-probe")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if p.ExpectStatus != 0 && resp.StatusCode != p.ExpectStatus {
+		return fmt.Errorf("status %d, want %d", resp.StatusCode, p.ExpectStatus)
+	}
+	if p.ExpectStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400) {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, bodyLimit))
+	if err != nil {
+		return fmt.Errorf("cannot read body: %w", err)
+	}
+	if p.Expect != "" && !strings.Contains(string(body), p.Expect) {
+		return fmt.Errorf("body does not contain %q", p.Expect)
+	}
+	return nil
+}
+
+// checkTCP opens and closes a connection to p.Target.
+func (c *checker) checkTCP(ctx context.Context, p database.Probe) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkDNS resolves p.Target and, if set, looks for p.Expect in the answer.
+func (c *checker) checkDNS(ctx context.Context, p database.Probe) error {
+	addrs, err := c.resolver.LookupHost(ctx, p.Target)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("no addresses for %s", p.Target)
+	}
+	if p.Expect != "" && !slices.Contains(addrs, p.Expect) {
+		return fmt.Errorf("resolved to %s, want %s", strings.Join(addrs, ", "), p.Expect)
+	}
+	return nil
+}
+
+// checkICMP sends one echo request with the system ping, which has the
+// privileges raw sockets need, and returns the round-trip time it reports.
+func (c *checker) checkICMP(ctx context.Context, p database.Probe) (time.Duration, error) {
+	out, err := c.runner.Run(ctx, "ping", "-c", "1", "-W", strconv.Itoa(p.TimeoutSeconds), p.Target)
+	if err != nil {
+		if line := lastLine(out); line != "" {
+			return 0, fmt.Errorf("%w: %s", err, line)
+		}
+		return 0, err
+	}
+	m := pingTime.FindSubmatch(out)
+	if m == nil {
+		return 0, nil // up, but latency falls back to the command's run time
+	}
+	ms, err := strconv.ParseFloat(string(m[1]), 64)
+	if err != nil {
+		return 0, nil
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+func lastLine(b []byte) string {
+	s := strings.TrimSpace(string(b))
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package probes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+// --- Mock Command Runner ---
+
+type mockRunner struct {
+	output []byte
+	err    error
+	args   []string
+}
+
+func (m *mockRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	m.args = append([]string{name}, args...)
+	return m.output, m.err
+}
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckHTTP(t *testing.T) {
+	srv := testServer(t)
+	c := newChecker(nil)
+
+	r := c.check(context.Background(), database.Probe{ID: 1, Type: TypeHTTP, Target: srv.URL + "/health", TimeoutSeconds: 5, ExpectStatus: 200, Expect: `"ok"`})
+	assert.True(t, r.Up, r.Error)
+	assert.Equal(t, int64(1), r.ProbeID)
+	assert.Greater(t, r.LatencyMs, 0.0)
+
+	r = c.check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL + "/health", TimeoutSeconds: 5, Expect: "healthy"})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, `body does not contain "healthy"`)
+
+	r = c.check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL + "/broken", TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.Equal(t, "status 503", r.Error)
+
+	r = c.check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL + "/broken", TimeoutSeconds: 5, ExpectStatus: 503})
+	assert.True(t, r.Up, "an expected error status is up")
+}
+
+func TestCheckHTTP_Latency(t *testing.T) {
+	srv := testServer(t)
+	c := newChecker(nil)
+
+	r := c.check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL + "/slow", TimeoutSeconds: 5, MaxLatencyMs: 50})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, "too slow")
+	assert.GreaterOrEqual(t, r.LatencyMs, 100.0)
+
+	r = c.check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL + "/slow", TimeoutSeconds: 5, MaxLatencyMs: 5000})
+	assert.True(t, r.Up, r.Error)
+}
+
+func TestCheckTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	c := newChecker(nil)
+
+	r := c.check(context.Background(), database.Probe{Type: TypeTCP, Target: addr, TimeoutSeconds: 5})
+	assert.True(t, r.Up, r.Error)
+
+	ln.Close()
+	r = c.check(context.Background(), database.Probe{Type: TypeTCP, Target: addr, TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+	assert.NotEmpty(t, r.Error)
+}
+
+func TestCheckDNS(t *testing.T) {
+	c := newChecker(nil)
+
+	r := c.check(context.Background(), database.Probe{Type: TypeDNS, Target: "localhost", TimeoutSeconds: 5})
+	assert.True(t, r.Up, r.Error)
+
+	r = c.check(context.Background(), database.Probe{Type: TypeDNS, Target: "localhost", TimeoutSeconds: 5, Expect: "192.0.2.1"})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, "want 192.0.2.1")
+
+	r = c.check(context.Background(), database.Probe{Type: TypeDNS, Target: "nonexistent.invalid", TimeoutSeconds: 5})
+	assert.False(t, r.Up)
+}
+
+func TestCheckICMP(t *testing.T) {
+	runner := &mockRunner{output: []byte("PING 192.168.1.1 (192.168.1.1) 56(84) bytes of data.\n64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=2.35 ms\n")}
+	c := newChecker(runner)
+
+	r := c.check(context.Background(), database.Probe{Type: TypeICMP, Target: "192.168.1.1", TimeoutSeconds: 3})
+	assert.True(t, r.Up, r.Error)
+	assert.Equal(t, 2.35, r.LatencyMs)
+	assert.Equal(t, []string{"ping", "-c", "1", "-W", "3", "192.168.1.1"}, runner.args)
+
+	runner.output = []byte("PING 10.0.0.9 (10.0.0.9) 56(84) bytes of data.\n\n--- 10.0.0.9 ping statistics ---\n1 packets transmitted, 0 received, 100% packet loss, time 0ms\n")
+	runner.err = errors.New("exit status 1")
+	r = c.check(context.Background(), database.Probe{Type: TypeICMP, Target: "10.0.0.9", TimeoutSeconds: 3})
+	assert.False(t, r.Up)
+	assert.Contains(t, r.Error, "100% packet loss")
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	start := time.Now()
+	r := newChecker(nil).check(context.Background(), database.Probe{Type: TypeHTTP, Target: srv.URL, TimeoutSeconds: 1})
+	assert.False(t, r.Up)
+	assert.Equal(t, "timed out after 1s", r.Error)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
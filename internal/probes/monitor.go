@@ -0,0 +1,253 @@
+package probes
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/execrunner"
+)
+
+const (
+	// scheduleInterval is how often probe configs are reloaded and due
+	// probes started.
+	scheduleInterval = 5 * time.Second
+	// historySize is the number of recent results kept in memory per probe.
+	historySize = 120
+	// retention is how long results are kept in the database.
+	retention = 7 * 24 * time.Hour
+)
+
+// Status is the current state of a probe and its recent history.
+type Status struct {
+	Probe     database.Probe
+	Checked   bool // false until the first result is in
+	Up        bool
+	LatencyMs float64
+	Error     string
+	LastCheck time.Time
+	Since     time.Time  // when the probe last changed between up and down
+	Uptime    float64    // percent of recent results that were up
+	Latencies []float64  // recent latencies in ms, oldest first
+	Certs     []CertInfo // tls and cert probes: the certificates of the last check
+}
+
+// state is what the monitor tracks per probe.
+type state struct {
+	probe   database.Probe
+	history []database.ProbeResult // oldest first, at most historySize
+	since   time.Time
+	running bool
+}
+
+// Monitor runs the enabled probes stored in the database, each on its own
+// interval, and records their results.
+type Monitor struct {
+	db      *database.DB
+	checker *checker
+	mu      sync.RWMutex
+	states  map[int64]*state // by probe ID
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewMonitor creates a probe monitor backed by db.
+func NewMonitor(db *database.DB) *Monitor {
+	return newMonitorWithRunner(db, execrunner.Combined{})
+}
+
+// newMonitorWithRunner creates a monitor that runs ping through runner, which
+// tests replace with canned ping output.
+func newMonitorWithRunner(db *database.DB, runner execrunner.Runner) *Monitor {
+	return &Monitor{
+		db:      db,
+		checker: newChecker(runner),
+		states:  make(map[int64]*state),
+	}
+}
+
+// Start begins running probes in the background.
+func (m *Monitor) Start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(ctx)
+	}()
+
+	log.Printf("Probe monitor started (interval=%v)", scheduleInterval)
+}
+
+// Stop cancels the monitor and waits for running checks to finish.
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+	log.Println("Probe monitor stopped")
+}
+
+// Statuses returns the state of every enabled probe, ordered by probe ID.
+func (m *Monitor) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(m.states))
+	for _, st := range m.states {
+		statuses = append(statuses, st.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Probe.ID < statuses[j].Probe.ID })
+	return statuses
+}
+
+func (st *state) status() Status {
+	s := Status{Probe: st.probe, Since: st.since}
+	if len(st.history) == 0 {
+		return s
+	}
+
+	last := st.history[len(st.history)-1]
+	s.Checked = true
+	s.Up = last.Up
+	s.LatencyMs = last.LatencyMs
+	s.Error = last.Error
+	s.LastCheck = last.Timestamp
+	s.Certs = decodeCerts(last.Detail)
+
+	up := 0
+	s.Latencies = make([]float64, len(st.history))
+	for i, r := range st.history {
+		if r.Up {
+			up++
+		}
+		s.Latencies[i] = r.LatencyMs
+	}
+	s.Uptime = float64(up) / float64(len(st.history)) * 100
+	return s
+}
+
+func (m *Monitor) run(ctx context.Context) {
+	m.schedule(ctx, time.Now())
+	m.prune(time.Now())
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.schedule(ctx, now)
+			if now.Sub(lastPrune) >= time.Hour {
+				m.prune(now)
+				lastPrune = now
+			}
+		}
+	}
+}
+
+// schedule reloads the enabled probes and starts those that are due.
+func (m *Monitor) schedule(ctx context.Context, now time.Time) {
+	probes, err := m.db.ListEnabledProbes()
+	if err != nil {
+		log.Printf("probes: failed to load probes: %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enabled := make(map[int64]bool, len(probes))
+	for _, p := range probes {
+		enabled[p.ID] = true
+		st, ok := m.states[p.ID]
+		if !ok {
+			history := m.loadHistory(p.ID)
+			st = &state{history: history, since: changedAt(history)}
+			m.states[p.ID] = st
+		}
+		st.probe = p
+
+		if st.running || !st.due(now) {
+			continue
+		}
+		st.running = true
+		m.wg.Add(1)
+		go func(p database.Probe) {
+			defer m.wg.Done()
+			m.record(p, m.checker.check(ctx, p))
+		}(p)
+	}
+
+	for id := range m.states {
+		if !enabled[id] {
+			delete(m.states, id)
+		}
+	}
+}
+
+// due reports whether the probe should run at now.
+func (st *state) due(now time.Time) bool {
+	if len(st.history) == 0 {
+		return true
+	}
+	last := st.history[len(st.history)-1].Timestamp
+	return now.Sub(last) >= time.Duration(st.probe.IntervalSeconds)*time.Second
+}
+
+// loadHistory seeds a probe's in-memory history from the database.
+func (m *Monitor) loadHistory(id int64) []database.ProbeResult {
+	results, err := m.db.ListProbeResults(id, historySize)
+	if err != nil {
+		log.Printf("probes: failed to load history of probe %d: %v", id, err)
+	}
+	return results
+}
+
+// changedAt returns when the last result's up/down state began.
+func changedAt(history []database.ProbeResult) time.Time {
+	if len(history) == 0 {
+		return time.Time{}
+	}
+	i := len(history) - 1
+	for i > 0 && history[i-1].Up == history[i].Up {
+		i--
+	}
+	return history[i].Timestamp
+}
+
+// record stores a result and appends it to the probe's history.
+func (m *Monitor) record(p database.Probe, r database.ProbeResult) {
+	m.mu.Lock()
+	st, ok := m.states[p.ID]
+	if ok {
+		st.running = false
+		if len(st.history) == 0 || st.history[len(st.history)-1].Up != r.Up {
+			st.since = r.Timestamp
+		}
+		st.history = append(st.history, r)
+		if len(st.history) > historySize {
+			st.history = st.history[len(st.history)-historySize:]
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return // disabled or deleted while running
+	}
+	if err := m.db.InsertProbeResult(&r); err != nil {
+		log.Printf("probes: %v", err)
+	}
+}
+
+func (m *Monitor) prune(now time.Time) {
+	if _, err := m.db.PruneProbeResults(now.Add(-retention)); err != nil {
+		log.Printf("probes: %v", err)
+	}
+}
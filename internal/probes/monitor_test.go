@@ -0,0 +1,118 @@
+package probes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// waitForResults schedules m until the probe has n results in memory.
+func waitForResults(t *testing.T, m *Monitor, id int64, n int, now func() time.Time) Status {
+	t.Helper()
+	var status Status
+	require.Eventually(t, func() bool {
+		m.schedule(context.Background(), now())
+		for _, s := range m.Statuses() {
+			if s.Probe.ID == id && len(s.Latencies) >= n {
+				status = s
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	return status
+}
+
+func TestMonitor_RecordsTransitions(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	db := setupTestDB(t)
+	p := &database.Probe{Name: "web", Type: TypeHTTP, Target: srv.URL, IntervalSeconds: 60, TimeoutSeconds: 5, Enabled: true}
+	require.NoError(t, db.CreateProbe(p))
+
+	m := NewMonitor(db)
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	status := waitForResults(t, m, p.ID, 1, clock)
+	assert.True(t, status.Checked)
+	assert.True(t, status.Up)
+	assert.Equal(t, 100.0, status.Uptime)
+	first := status.Since
+
+	// Not due again within the interval.
+	m.schedule(context.Background(), now.Add(30*time.Second))
+	m.wg.Wait()
+	assert.Len(t, m.Statuses()[0].Latencies, 1)
+
+	healthy.Store(false)
+	now = now.Add(2 * time.Minute)
+	status = waitForResults(t, m, p.ID, 2, clock)
+	assert.False(t, status.Up)
+	assert.Equal(t, "status 500", status.Error)
+	assert.Equal(t, 50.0, status.Uptime)
+	assert.True(t, status.Since.After(first), "since moves on a transition")
+
+	results, err := db.ListProbeResults(p.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 2, "results are persisted")
+}
+
+func TestMonitor_LoadsHistoryAndDropsDisabled(t *testing.T) {
+	db := setupTestDB(t)
+	p := &database.Probe{Name: "router", Type: TypeICMP, Target: "192.168.1.1", IntervalSeconds: 60, TimeoutSeconds: 5, Enabled: true}
+	require.NoError(t, db.CreateProbe(p))
+
+	last := time.Now().Add(-10 * time.Second)
+	for i, up := range []bool{false, true, true} {
+		require.NoError(t, db.InsertProbeResult(&database.ProbeResult{ProbeID: p.ID, Timestamp: last.Add(time.Duration(i-2) * time.Minute), Up: up, LatencyMs: 3}))
+	}
+
+	runner := &mockRunner{}
+	m := newMonitorWithRunner(db, runner)
+	m.schedule(context.Background(), time.Now())
+	m.wg.Wait()
+
+	statuses := m.Statuses()
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Up)
+	assert.Len(t, statuses[0].Latencies, 3, "history is loaded from the database")
+	assert.Equal(t, last.Add(-time.Minute).UnixMilli(), statuses[0].Since.UnixMilli())
+	assert.Nil(t, runner.args, "the last result is recent, so the probe is not due")
+
+	require.NoError(t, db.ToggleProbe(p.ID))
+	m.schedule(context.Background(), time.Now())
+	assert.Empty(t, m.Statuses(), "disabled probes are dropped")
+}
+
+func TestMonitor_StartStop(t *testing.T) {
+	db := setupTestDB(t)
+	m := NewMonitor(db)
+	m.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	m.Stop()
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/docker"
+)
+
+// dockerActionTimeout bounds a lifecycle action. Stop and restart wait for
+// the container's grace period (10s by default) before killing it.
+const dockerActionTimeout = 45 * time.Second
+
+const (
+	// defaultLogTail is the number of log lines shown when none is asked for.
+	defaultLogTail = 100
+	// maxLogTail bounds the lines sent at once, which the browser has to hold.
+	maxLogTail = 5000
+)
+
+var errDockerUnavailable = errors.New("docker not available")
+
+// dockerDetailData is the data of the container detail panel.
+type dockerDetailData struct {
+	*docker.ContainerDetail
+	Notice string // outcome of the action just taken
+	Error  string
+}
+
+func (s *Server) handleDockerDetail(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" || s.docker == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	detail, err := s.docker.ContainerDetail(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Container not found", http.StatusNotFound)
+		return
+	}
+
+	s.renderDockerDetail(w, dockerDetailData{ContainerDetail: detail})
+}
+
+// handleDockerAction handles POST /api/docker/{id}/{action}, where action is
+// start, stop, restart, pause, unpause or kill. Stop, restart and kill must
+// be confirmed with confirm=yes. Every attempt is recorded in the action log.
+func (s *Server) handleDockerAction(w http.ResponseWriter, r *http.Request) {
+	if !s.validateCSRF(w, r) {
+		return
+	}
+
+	id, action := r.PathValue("id"), r.PathValue("action")
+	if !docker.IsAction(action) {
+		http.Error(w, "Unknown action", http.StatusNotFound)
+		return
+	}
+	if docker.IsDestructive(action) && r.FormValue("confirm") != "yes" {
+		http.Error(w, "Confirmation required", http.StatusPreconditionRequired)
+		return
+	}
+
+	target := id
+	err := errDockerUnavailable
+	if s.docker != nil && s.docker.Available() {
+		target = s.containerName(id)
+		ctx, cancel := context.WithTimeout(r.Context(), dockerActionTimeout)
+		err = s.docker.ContainerAction(ctx, id, action)
+		cancel()
+	}
+
+	entry := &database.ActionLog{Action: "docker." + action, Target: target, Result: database.ActionResultSuccess}
+	if userID, ok := UserIDFromContext(r.Context()); ok {
+		entry.UserID = &userID
+	}
+	if err != nil {
+		entry.Result, entry.Details = database.ActionResultError, err.Error()
+	}
+	if lerr := s.db.LogAction(entry); lerr != nil {
+		log.Printf("docker: failed to log action: %v", lerr)
+	}
+
+	if err == errDockerUnavailable {
+		http.Error(w, "Docker not available", http.StatusServiceUnavailable)
+		return
+	}
+	data := dockerDetailData{Notice: fmt.Sprintf("%s %s: done", action, target)}
+	if err != nil {
+		log.Printf("docker: %v", err)
+		data.Notice, data.Error = "", fmt.Sprintf("%s %s failed: %v", action, target, err)
+	}
+
+	data.ContainerDetail, err = s.docker.ContainerDetail(r.Context(), id)
+	if err != nil {
+		data.ContainerDetail = &docker.ContainerDetail{ID: id, Name: target}
+	}
+	s.renderDockerDetail(w, data)
+}
+
+// containerName returns the name of a cached container, or its ID.
+func (s *Server) containerName(id string) string {
+	for _, c := range s.docker.Containers() {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return id
+}
+
+func (s *Server) renderDockerDetail(w http.ResponseWriter, data dockerDetailData) {
+	html := s.renderPartial("partials/docker-detail.html", data)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// dockerLogPanelData is the data of the log viewer: its form values and the
+// URL of the log stream they select.
+type dockerLogPanelData struct {
+	ID, Name                   string
+	Tail, Since, Until, Stream string
+	Filter                     string
+	Regex, Follow              bool
+	StreamURL                  string
+}
+
+// handleDockerLogPanel handles GET /api/docker/{id}/logs/panel. It renders the
+// log viewer, which streams from /api/docker/{id}/logs with the same query.
+func (s *Server) handleDockerLogPanel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	q := r.URL.Query()
+	if len(q) == 0 {
+		q = url.Values{"tail": {strconv.Itoa(defaultLogTail)}, "follow": {"1"}}
+	}
+	if _, _, err := parseLogQuery(q, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := dockerLogPanelData{
+		ID:        id,
+		Name:      id,
+		Tail:      q.Get("tail"),
+		Since:     q.Get("since"),
+		Until:     q.Get("until"),
+		Stream:    q.Get("stream"),
+		Filter:    q.Get("filter"),
+		Regex:     formBool(q.Get("regex")),
+		Follow:    formBool(q.Get("follow")),
+		StreamURL: "/api/docker/" + url.PathEscape(id) + "/logs?" + q.Encode(),
+	}
+	if s.docker != nil {
+		data.Name = s.containerName(id)
+	}
+
+	html := s.renderPartial("partials/docker-logs.html", data)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// handleDockerLogs handles GET /api/docker/{id}/logs. It streams log lines as
+// SSE "log" events, each with the line's time in nanoseconds as its ID, and
+// sends "end" when the log ends. Query parameters: tail (lines, default 100),
+// since and until (like /api/metrics/history), stream (stdout, stderr or
+// both), follow, filter (case-insensitive substring) and regex (filter is a
+// regular expression).
+func (s *Server) handleDockerLogs(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	opts, match, err := parseLogQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A reconnecting EventSource resumes after the last line it received.
+	if last, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		opts.Since, opts.Tail = time.Unix(0, last+1), 0
+	}
+	if s.docker == nil || !s.docker.Available() {
+		http.Error(w, "Docker not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	var buf bytes.Buffer
+	err = s.docker.Logs(r.Context(), r.PathValue("id"), opts, func(l docker.LogLine) error {
+		l.Text = stripANSI(l.Text)
+		if match != nil && !match(l.Text) {
+			return nil
+		}
+		buf.Reset()
+		if !l.Time.IsZero() {
+			fmt.Fprintf(&buf, "id: %d\n", l.Time.UnixNano())
+		}
+		writeSSEEvent(&buf, "log", logLineHTML(l))
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+
+	buf.Reset()
+	if err != nil && r.Context().Err() == nil {
+		log.Printf("docker: %v", err)
+		writeSSEEvent(&buf, "log", fmt.Sprintf(`<div class="text-danger">%s</div>`, template.HTMLEscapeString(err.Error())))
+	}
+	writeSSEEvent(&buf, "end", "")
+	w.Write(buf.Bytes())
+	flusher.Flush()
+}
+
+// parseLogQuery reads the log options and the line filter of a log request.
+// The filter is nil when every line matches.
+func parseLogQuery(q url.Values, now time.Time) (docker.LogOptions, func(string) bool, error) {
+	opts := docker.LogOptions{Tail: defaultLogTail, Follow: formBool(q.Get("follow"))}
+
+	if v := q.Get("tail"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLogTail {
+			return opts, nil, fmt.Errorf("tail must be between 1 and %d", maxLogTail)
+		}
+		opts.Tail = n
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &opts.Since}, {"until", &opts.Until}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := parseTimeParam(v, now)
+			if err != nil {
+				return opts, nil, fmt.Errorf("invalid %s: %q", p.name, v)
+			}
+			*p.dst = t
+		}
+	}
+
+	switch q.Get("stream") {
+	case "", "both":
+		opts.Stdout, opts.Stderr = true, true
+	case "stdout":
+		opts.Stdout = true
+	case "stderr":
+		opts.Stderr = true
+	default:
+		return opts, nil, fmt.Errorf("stream must be stdout, stderr or both")
+	}
+
+	filter := q.Get("filter")
+	if filter == "" {
+		return opts, nil, nil
+	}
+	if formBool(q.Get("regex")) {
+		re, err := regexp.Compile(filter)
+		if err != nil {
+			return opts, nil, fmt.Errorf("invalid filter: %v", err)
+		}
+		return opts, re.MatchString, nil
+	}
+	lower := strings.ToLower(filter)
+	return opts, func(text string) bool { return strings.Contains(strings.ToLower(text), lower) }, nil
+}
+
+// formBool reads a checkbox or flag value.
+func formBool(v string) bool {
+	return v == "1" || v == "on" || v == "true"
+}
+
+// ansiEscape matches terminal color and cursor sequences, common in the logs
+// of containers with a TTY.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func stripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// logLineHTML renders one log line for the log viewer.
+func logLineHTML(l docker.LogLine) string {
+	class := "text-text"
+	if l.Stream == "stderr" {
+		class = "text-danger"
+	}
+	ts := ""
+	if !l.Time.IsZero() {
+		ts = l.Time.Local().Format("Jan 2 15:04:05")
+	}
+	return fmt.Sprintf(`<div class="whitespace-pre-wrap break-all %s"><span class="text-text-muted">%s</span> %s</div>`,
+		class, ts, template.HTMLEscapeString(l.Text))
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/docker"
+)
+
+func postDockerAction(t *testing.T, srv *Server, session *database.Session, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDockerAction_RequiresCSRF(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := postDockerAction(t, srv, session, "/api/docker/abc/start", url.Values{})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	logs, err := srv.db.ListActionLogs(10)
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+}
+
+func TestDockerAction_RequiresAuth(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/docker/abc/start", nil)
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestDockerAction_UnknownAction(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := postDockerAction(t, srv, session, "/api/docker/abc/remove", url.Values{"csrf_token": {session.CSRFToken}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestDockerAction_DestructiveNeedsConfirmation(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	for _, action := range []string{"stop", "restart", "kill"} {
+		rec := postDockerAction(t, srv, session, "/api/docker/abc/"+action, url.Values{"csrf_token": {session.CSRFToken}})
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code, action)
+	}
+	logs, err := srv.db.ListActionLogs(10)
+	require.NoError(t, err)
+	assert.Empty(t, logs, "unconfirmed actions are not attempted")
+}
+
+func TestDockerAction_LogsFailedAttempt(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := postDockerAction(t, srv, session, "/api/docker/abc123/kill", url.Values{
+		"csrf_token": {session.CSRFToken},
+		"confirm":    {"yes"},
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	logs, err := srv.db.ListActionLogs(10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "docker.kill", logs[0].Action)
+	assert.Equal(t, "abc123", logs[0].Target)
+	assert.Equal(t, "admin", logs[0].Username)
+	assert.Equal(t, database.ActionResultError, logs[0].Result)
+	assert.Equal(t, "docker not available", logs[0].Details)
+}
+
+func TestRenderDockerDetail_Controls(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	render := func(state string) string {
+		return srv.renderPartial("partials/docker-detail.html", dockerDetailData{
+			ContainerDetail: &docker.ContainerDetail{ID: "abc", Name: "web", State: state},
+		})
+	}
+
+	html := render("running")
+	assert.Contains(t, html, `hx-post="/api/docker/abc/restart"`)
+	assert.Contains(t, html, `hx-post="/api/docker/abc/pause"`)
+	assert.Contains(t, html, `hx-confirm="Stop container web?"`)
+	assert.Contains(t, html, `hx-post="/api/docker/abc/kill"`)
+	assert.NotContains(t, html, `/api/docker/abc/start"`)
+
+	html = render("exited")
+	assert.Contains(t, html, `hx-post="/api/docker/abc/start"`)
+	assert.NotContains(t, html, `/api/docker/abc/stop"`)
+
+	html = render("paused")
+	assert.Contains(t, html, `hx-post="/api/docker/abc/unpause"`)
+
+	html = srv.renderPartial("partials/docker-detail.html", dockerDetailData{
+		ContainerDetail: &docker.ContainerDetail{ID: "abc", Name: "web", State: "running"},
+		Error:           "stop web failed: timeout",
+	})
+	assert.Contains(t, html, "stop web failed: timeout")
+}
+
+func getDockerLogs(t *testing.T, srv *Server, session *database.Session, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseLogQuery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	opts, match, err := parseLogQuery(url.Values{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, docker.LogOptions{Tail: defaultLogTail, Stdout: true, Stderr: true}, opts)
+	assert.Nil(t, match)
+
+	opts, match, err = parseLogQuery(url.Values{
+		"tail":   {"20"},
+		"since":  {"-1h"},
+		"until":  {"2026-03-01T11:30:00Z"},
+		"stream": {"stderr"},
+		"follow": {"1"},
+		"filter": {"ERROR"},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 20, opts.Tail)
+	assert.Equal(t, now.Add(-time.Hour), opts.Since)
+	assert.Equal(t, now.Add(-30*time.Minute), opts.Until)
+	assert.False(t, opts.Stdout)
+	assert.True(t, opts.Stderr)
+	assert.True(t, opts.Follow)
+	require.NotNil(t, match)
+	assert.True(t, match("level=error msg=boom"), "substring filter ignores case")
+	assert.False(t, match("level=info"))
+
+	_, match, err = parseLogQuery(url.Values{"filter": {`^GET /api/\w+`}, "regex": {"on"}}, now)
+	require.NoError(t, err)
+	assert.True(t, match("GET /api/health 200"))
+	assert.False(t, match("POST /api/health 200"))
+
+	for _, q := range []url.Values{
+		{"tail": {"0"}},
+		{"tail": {"10000"}},
+		{"since": {"yesterday"}},
+		{"stream": {"stdin"}},
+		{"filter": {"("}, "regex": {"1"}},
+	} {
+		_, _, err := parseLogQuery(q, now)
+		assert.Error(t, err, q.Encode())
+	}
+}
+
+func TestLogLineHTML(t *testing.T) {
+	html := logLineHTML(docker.LogLine{Stream: "stderr", Text: "<script>alert(1)</script>"})
+	assert.Contains(t, html, "text-danger")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.NotContains(t, html, "<script>")
+
+	// A CR inside a line must not end the data field and let the container
+	// write SSE fields of its own.
+	var buf bytes.Buffer
+	writeSSEEvent(&buf, "log", logLineHTML(docker.LogLine{Stream: "stdout", Text: "progress 50%\rid: 999\revent: end"}))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "event: log", lines[0])
+	for _, line := range lines[1:] {
+		assert.True(t, strings.HasPrefix(line, "data: "), line)
+	}
+
+	assert.Equal(t, "ready in 3ms", stripANSI("\x1b[32mready\x1b[0m in \x1b[1;33m3ms\x1b[0m"))
+}
+
+func TestDockerLogPanel(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := getDockerLogs(t, srv, session, "/api/docker/abc123/logs/panel")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `sse-connect="/api/docker/abc123/logs?follow=1&amp;tail=100"`)
+	assert.Contains(t, body, `sse-close="end"`)
+
+	rec = getDockerLogs(t, srv, session, "/api/docker/abc123/logs/panel?tail=50&stream=stdout&filter=warn")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body = rec.Body.String()
+	assert.Contains(t, body, "filter=warn")
+	assert.Contains(t, body, `value="50"`)
+	assert.Contains(t, body, `<option value="stdout" selected>`)
+	assert.NotContains(t, body, "follow=1", "follow is off when the form leaves it unchecked")
+
+	rec = getDockerLogs(t, srv, session, "/api/docker/abc123/logs/panel?stream=stdin")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDockerLogs_Errors(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := getDockerLogs(t, srv, session, "/api/docker/abc123/logs?filter=(&regex=1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = getDockerLogs(t, srv, session, "/api/docker/abc123/logs")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/docker/abc123/logs", nil)
+	rec = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
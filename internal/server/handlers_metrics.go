@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+)
+
+const (
+	defaultHistoryRange  = time.Hour
+	targetHistoryBuckets = 500   // used to pick a step when none is given
+	maxHistoryBuckets    = 10000 // upper bound on buckets per series
+)
+
+var defaultHistoryFields = []string{"cpu", "ram"}
+
+type metricsHistoryResponse struct {
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	StepSeconds float64              `json:"step_seconds"`
+	Series      []metrics.SeriesData `json:"series"`
+}
+
+// handleMetricsHistory handles GET /api/metrics/history?from=&to=&step=&fields=&format=
+func (s *Server) handleMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	if s.collector == nil {
+		http.Error(w, "Metrics not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	now := time.Now()
+
+	format := strings.ToLower(q.Get("format"))
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	if format != "" && format != "csv" && format != "json" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	to := now
+	if v := q.Get("to"); v != "" {
+		t, err := parseTimeParam(v, now)
+		if err != nil {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultHistoryRange)
+	if v := q.Get("from"); v != "" {
+		t, err := parseTimeParam(v, now)
+		if err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+
+	if !to.After(from) {
+		http.Error(w, "Invalid range: from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	span := to.Sub(from)
+	step := time.Duration(math.Ceil(span.Seconds()/targetHistoryBuckets)) * time.Second
+	if v := q.Get("step"); v != "" {
+		d, err := parseStepParam(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "Invalid step", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+	if span/step > maxHistoryBuckets {
+		http.Error(w, "Step too small for range", http.StatusBadRequest)
+		return
+	}
+
+	fields := defaultHistoryFields
+	if v := q.Get("fields"); v != "" {
+		fields = nil
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	series := s.collector.Query(from, to, step, fields)
+
+	if format == "csv" {
+		writeHistoryCSV(w, series)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metricsHistoryResponse{
+		From:        from,
+		To:          to,
+		StepSeconds: step.Seconds(),
+		Series:      series,
+	})
+}
+
+// writeHistoryCSV writes series in long format: one row per series and bucket.
+func writeHistoryCSV(w http.ResponseWriter, series []metrics.SeriesData) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "series", "min", "avg", "max"})
+	for _, sd := range series {
+		for _, p := range sd.Points {
+			cw.Write([]string{
+				p.Timestamp.UTC().Format(time.RFC3339),
+				sd.Name,
+				strconv.FormatFloat(p.Min, 'f', -1, 64),
+				strconv.FormatFloat(p.Avg, 'f', -1, 64),
+				strconv.FormatFloat(p.Max, 'f', -1, 64),
+			})
+		}
+	}
+	cw.Flush()
+}
+
+// parseTimeParam accepts RFC3339, unix seconds, or a negative duration
+// relative to now (e.g. "-6h").
+func parseTimeParam(v string, now time.Time) (time.Time, error) {
+	if strings.HasPrefix(v, "-") {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			return now.Add(d), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// parseStepParam accepts a Go duration ("5m") or a number of seconds ("300").
+func parseStepParam(v string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(v)
+}
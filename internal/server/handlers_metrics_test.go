@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+)
+
+// stubReader returns a fixed snapshot for handler tests.
+type stubReader struct{}
+
+func (stubReader) Read(_ context.Context) (*metrics.Snapshot, error) {
+	return &metrics.Snapshot{
+		Timestamp: time.Now(),
+		CPU:       metrics.CPUMetrics{TotalPercent: 42},
+		RAM:       metrics.RAMMetrics{Percent: 60},
+		Disks:     []metrics.DiskPartition{{Path: "/", Percent: 70}, {Path: "/boot", Percent: 20}},
+	}, nil
+}
+
+func setupMetricsTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	srv, session := setupSSETestServer(t)
+
+	c := metrics.NewCollector(stubReader{}, time.Hour, time.Hour)
+	c.Start(context.Background())
+	t.Cleanup(c.Stop)
+	require.Eventually(t, func() bool { return c.Len() > 0 }, time.Second, 10*time.Millisecond)
+
+	srv.collector = c
+	return srv, session.ID
+}
+
+func getMetricsHistory(srv *Server, sessionID, query, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/metrics/history"+query, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: sessionID})
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMetricsHistory_RequiresAuth(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/metrics/history", nil)
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestMetricsHistory_NoCollector(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := getMetricsHistory(srv, session.ID, "", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestMetricsHistory_JSONDefaults(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+
+	rec := getMetricsHistory(srv, sid, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var resp metricsHistoryResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, time.Hour, resp.To.Sub(resp.From))
+	assert.Equal(t, 8.0, resp.StepSeconds) // 1h / 500 buckets, rounded up
+	require.Len(t, resp.Series, 2)
+	assert.Equal(t, "cpu", resp.Series[0].Name)
+	assert.Equal(t, 42.0, resp.Series[0].Points[0].Avg)
+	assert.Equal(t, "ram", resp.Series[1].Name)
+}
+
+func TestMetricsHistory_FieldsAndStep(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+
+	rec := getMetricsHistory(srv, sid, "?from=-10m&step=1m&fields=disk:/", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp metricsHistoryResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, 60.0, resp.StepSeconds)
+	require.Len(t, resp.Series, 1)
+	assert.Equal(t, "disk:/", resp.Series[0].Name)
+	assert.Equal(t, 70.0, resp.Series[0].Points[0].Max)
+}
+
+func TestMetricsHistory_CSVViaFormatParam(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+
+	rec := getMetricsHistory(srv, sid, "?format=csv&fields=cpu", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "timestamp,series,min,avg,max", lines[0])
+	assert.Contains(t, lines[1], ",cpu,42,42,42")
+}
+
+func TestMetricsHistory_CSVViaAcceptHeader(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+
+	rec := getMetricsHistory(srv, sid, "", "text/csv")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+}
+
+func TestMetricsHistory_BadRequests(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+
+	for _, q := range []string{
+		"?format=xml",
+		"?from=yesterday",
+		"?to=not-a-time",
+		"?from=2000&to=1000",
+		"?step=-5s",
+		"?step=abc",
+		"?from=-720h&step=1s",
+	} {
+		t.Run(q, func(t *testing.T) {
+			rec := getMetricsHistory(srv, sid, q, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestParseTimeParam(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	got, err := parseTimeParam("1699990000", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1699990000, 0), got)
+
+	got, err = parseTimeParam("-1h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), got)
+
+	got, err = parseTimeParam("2023-11-14T22:13:20Z", now)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(now))
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+)
+
+// minProbeInterval keeps probes from hammering the services they check.
+const minProbeInterval = 10
+
+// handleProbeCreate handles POST /api/probes
+func (s *Server) handleProbeCreate(w http.ResponseWriter, r *http.Request) {
+	if !s.validateCSRF(w, r) {
+		return
+	}
+
+	p := &database.Probe{
+		Name:            strings.TrimSpace(r.FormValue("name")),
+		Type:            r.FormValue("type"),
+		Target:          strings.TrimSpace(r.FormValue("target")),
+		IntervalSeconds: formInt(r, "interval", 60),
+		TimeoutSeconds:  formInt(r, "timeout", 10),
+		ExpectStatus:    formInt(r, "expect_status", 0),
+		Expect:          strings.TrimSpace(r.FormValue("expect")),
+		MaxLatencyMs:    formInt(r, "max_latency", 0),
+		WarnDays:        formInt(r, "warn_days", 21),
+		CritDays:        formInt(r, "crit_days", 7),
+		Enabled:         true,
+	}
+	if err := validateProbe(p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.Name == "" {
+		p.Name = p.Type + " " + p.Target
+	}
+
+	if err := s.db.CreateProbe(p); err != nil {
+		log.Printf("settings: failed to create probe: %v", err)
+		http.Error(w, "Failed to create probe", http.StatusInternalServerError)
+		return
+	}
+
+	s.renderProbesTable(w)
+}
+
+// handleProbeToggle handles POST /api/probes/{id}/toggle
+func (s *Server) handleProbeToggle(w http.ResponseWriter, r *http.Request) {
+	if !s.validateCSRF(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.ToggleProbe(id); err != nil {
+		log.Printf("settings: failed to toggle probe: %v", err)
+		http.Error(w, "Failed to toggle probe", http.StatusInternalServerError)
+		return
+	}
+
+	s.renderProbesTable(w)
+}
+
+// handleProbeDelete handles DELETE /api/probes/{id}
+func (s *Server) handleProbeDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.validateCSRF(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteProbe(id); err != nil {
+		log.Printf("settings: failed to delete probe: %v", err)
+		http.Error(w, "Failed to delete probe", http.StatusInternalServerError)
+		return
+	}
+
+	s.renderProbesTable(w)
+}
+
+func (s *Server) renderProbesTable(w http.ResponseWriter) {
+	list, _ := s.db.ListProbes()
+
+	tmpl, err := template.ParseFS(s.templates, "templates/partials/probes-table.html")
+	if err != nil {
+		log.Printf("settings: parse error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "probes-table", list); err != nil {
+		log.Printf("settings: render error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// formInt returns the integer form value key, or def if it is empty.
+// Unparsable values become -1 so validation rejects them.
+func formInt(r *http.Request, key string, def int) int {
+	v := strings.TrimSpace(r.FormValue(key))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// validateProbe checks a probe submitted from the settings page.
+func validateProbe(p *database.Probe) error {
+	if p.Target == "" {
+		return errors.New("Target is required")
+	}
+	switch p.Type {
+	case probes.TypeHTTP:
+		u, err := url.Parse(p.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("HTTP target must be an http:// or https:// URL")
+		}
+	case probes.TypeTCP, probes.TypeTLS:
+		host, port, err := net.SplitHostPort(p.Target)
+		if n, perr := strconv.Atoi(port); err != nil || host == "" || perr != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("%s target must be host:port", strings.ToUpper(p.Type))
+		}
+	case probes.TypeCert:
+		if _, err := filepath.Match(p.Target, ""); err != nil || !filepath.IsAbs(p.Target) {
+			return errors.New("Certificate target must be an absolute file path or pattern")
+		}
+	case probes.TypeDNS, probes.TypeICMP:
+		if strings.ContainsAny(p.Target, " /:") || strings.HasPrefix(p.Target, "-") {
+			return fmt.Errorf("%s target must be a hostname or IPv4 address", strings.ToUpper(p.Type))
+		}
+	default:
+		return errors.New("Invalid probe type")
+	}
+
+	if p.IntervalSeconds < minProbeInterval {
+		return fmt.Errorf("Interval must be at least %ds", minProbeInterval)
+	}
+	if p.TimeoutSeconds < 1 || p.TimeoutSeconds > p.IntervalSeconds {
+		return errors.New("Timeout must be between 1s and the interval")
+	}
+	if p.ExpectStatus != 0 && (p.ExpectStatus < 100 || p.ExpectStatus > 599) {
+		return errors.New("Invalid expected status")
+	}
+	if p.MaxLatencyMs < 0 {
+		return errors.New("Invalid latency limit")
+	}
+	if p.CritDays < 0 || p.WarnDays < p.CritDays {
+		return errors.New("Expiry days must satisfy 0 <= critical <= warning")
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+)
+
+func postProbe(t *testing.T, srv *Server, session *database.Session, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	form.Set("csrf_token", session.CSRFToken)
+	req := httptest.NewRequest(http.MethodPost, "/api/probes", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProbeCreate(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := postProbe(t, srv, session, url.Values{
+		"type":          {"http"},
+		"target":        {" https://cloud.local/status.php "},
+		"interval":      {"30"},
+		"timeout":       {"5"},
+		"expect_status": {"200"},
+		"expect":        {`"installed":true`},
+	})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "https://cloud.local/status.php")
+
+	list, err := srv.db.ListProbes()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "http https://cloud.local/status.php", list[0].Name, "named after the check by default")
+	assert.Equal(t, 30, list[0].IntervalSeconds)
+	assert.Equal(t, 200, list[0].ExpectStatus)
+	assert.True(t, list[0].Enabled)
+}
+
+func TestProbeCreate_Invalid(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	for _, form := range []url.Values{
+		{"type": {"http"}, "target": {"cloud.local"}},
+		{"type": {"tcp"}, "target": {"localhost"}},
+		{"type": {"tcp"}, "target": {"localhost:99999"}},
+		{"type": {"icmp"}, "target": {"-f 192.168.1.1"}},
+		{"type": {"smtp"}, "target": {"mail.local:25"}},
+		{"type": {"dns"}, "target": {"pi.hole"}, "interval": {"5"}},
+		{"type": {"dns"}, "target": {"pi.hole"}, "interval": {"30"}, "timeout": {"60"}},
+		{"type": {"http"}, "target": {"http://x.local"}, "expect_status": {"700"}},
+		{"type": {"http"}, "target": {"http://x.local"}, "max_latency": {"fast"}},
+	} {
+		rec := postProbe(t, srv, session, form)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%v", form)
+	}
+
+	list, _ := srv.db.ListProbes()
+	assert.Empty(t, list)
+}
+
+func TestProbeToggleAndDelete(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+	p := &database.Probe{Name: "ssh", Type: "tcp", Target: "localhost:22", IntervalSeconds: 60, TimeoutSeconds: 5, Enabled: true}
+	require.NoError(t, srv.db.CreateProbe(p))
+
+	form := url.Values{"csrf_token": {session.CSRFToken}}
+	req := httptest.NewRequest(http.MethodPost, "/api/probes/1/toggle", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	got, _ := srv.db.GetProbe(1)
+	assert.False(t, got.Enabled)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/probes/1", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "CSRF token required")
+
+	req.Header.Set("X-CSRF-Token", session.CSRFToken)
+	rec = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "No probes configured")
+	got, _ = srv.db.GetProbe(1)
+	assert.Nil(t, got)
+}
+
+func TestSettings_RendersProbes(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+	require.NoError(t, srv.db.CreateProbe(&database.Probe{Name: "Pi-hole", Type: "dns", Target: "pi.hole", IntervalSeconds: 60, TimeoutSeconds: 5, Enabled: true}))
+
+	req := httptest.NewRequest(http.MethodGet, "/settings", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Synthetic Checks")
+	assert.Contains(t, rec.Body.String(), "Pi-hole")
+}
+
+func TestSSEProbesPartial(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+
+	html := srv.renderPartial("partials/sse-probes.html", DashboardData{})
+	assert.Contains(t, html, "No probes configured")
+
+	html = srv.renderPartial("partials/sse-probes.html", DashboardData{Probes: []probes.Status{
+		{Probe: database.Probe{Name: "web", Type: "http"}, Checked: true, Up: true, LatencyMs: 42, Uptime: 99.2, Latencies: []float64{40, 42}},
+		{Probe: database.Probe{Name: "nas", Type: "icmp"}, Checked: true, Error: "100% packet loss", Since: time.Now().Add(-5 * time.Minute)},
+		{Probe: database.Probe{Name: "new", Type: "tcp"}},
+	}})
+	assert.Contains(t, html, "42 ms · 99.2% up")
+	assert.Contains(t, html, "<polyline")
+	assert.Contains(t, html, "Down: 100% packet loss for 5m")
+	assert.Contains(t, html, "Waiting for first check")
+}
+
+func TestProbeCreate_Certificates(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	rec := postProbe(t, srv, session, url.Values{"type": {"tls"}, "target": {"cloud.local:443"}, "interval": {"3600"}, "warn_days": {"30"}, "crit_days": {"10"}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "warn 30d, critical 10d")
+	rec = postProbe(t, srv, session, url.Values{"type": {"cert"}, "target": {"/etc/letsencrypt/live/*/fullchain.pem"}, "interval": {"3600"}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	list, _ := srv.db.ListProbes()
+	require.Len(t, list, 2)
+	assert.Equal(t, 30, list[0].WarnDays)
+	assert.Equal(t, 21, list[1].WarnDays, "default thresholds")
+	assert.Equal(t, 7, list[1].CritDays)
+
+	for _, form := range []url.Values{
+		{"type": {"tls"}, "target": {"cloud.local"}},
+		{"type": {"cert"}, "target": {"certs/*.pem"}},
+		{"type": {"cert"}, "target": {"/etc/ssl/[.pem"}},
+		{"type": {"cert"}, "target": {"/etc/ssl/a.pem"}, "warn_days": {"5"}, "crit_days": {"10"}},
+	} {
+		rec := postProbe(t, srv, session, form)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%v", form)
+	}
+}
+
+func TestSSEProbesPartial_Certificates(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	now := time.Now()
+
+	html := srv.renderPartial("partials/sse-probes.html", DashboardData{Probes: []probes.Status{{
+		Probe:   database.Probe{Name: "letsencrypt", Type: "cert", WarnDays: 21, CritDays: 7},
+		Checked: true, Up: true, Latencies: []float64{1, 2},
+		Certs: []probes.CertInfo{
+			{Source: "/etc/letsencrypt/live/cloud/fullchain.pem", SANs: []string{"cloud.example.com", "www.cloud.example.com"}, Issuer: "R11", NotAfter: now.Add(5*24*time.Hour + time.Hour)},
+			{Source: "/etc/letsencrypt/live/git/fullchain.pem", SANs: []string{"git.example.com"}, Issuer: "R10", NotAfter: now.Add(80*24*time.Hour + time.Hour)},
+		},
+	}}})
+	assert.Contains(t, html, "cloud.example.com")
+	assert.Contains(t, html, "+1")
+	assert.Contains(t, html, `text-danger">5d left · R11`)
+	assert.Contains(t, html, `text-text-muted">80d left · R10`)
+	assert.NotContains(t, html, "<polyline", "no latency chart for certificate files")
+}
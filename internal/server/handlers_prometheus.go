@@ -0,0 +1,414 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/docker"
+	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
+	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
+)
+
+// dockerStates are the container states exported by ultron_container_state,
+// so that every state appears (as 0 or 1) for every container.
+var dockerStates = []string{"created", "running", "paused", "restarting", "removing", "exited", "dead"}
+
+// driveHealths are the drive health values exported by ultron_drive_health.
+var driveHealths = []smart.Health{smart.HealthPassed, smart.HealthWarning, smart.HealthFailing, smart.HealthUnknown}
+
+// promWriter writes metrics in the Prometheus text exposition format (0.0.4).
+type promWriter struct {
+	buf bytes.Buffer
+}
+
+// family writes the HELP and TYPE header for a metric.
+func (p *promWriter) family(name, typ, help string) {
+	fmt.Fprintf(&p.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one sample; labels are given as alternating name/value pairs.
+func (p *promWriter) sample(name string, value float64, labels ...string) {
+	p.buf.WriteString(name)
+	if len(labels) > 0 {
+		p.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				p.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&p.buf, `%s="%s"`, labels[i], escapeLabelValue(labels[i+1]))
+		}
+		p.buf.WriteByte('}')
+	}
+	p.buf.WriteByte(' ')
+	p.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	p.buf.WriteByte('\n')
+}
+
+// gauge writes a single-sample gauge family.
+func (p *promWriter) gauge(name, help string, value float64, labels ...string) {
+	p.family(name, "gauge", help)
+	p.sample(name, value, labels...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// handlePrometheus handles GET /metrics
+func (s *Server) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	p := &promWriter{}
+
+	if s.collector != nil {
+		if snap := s.collector.Latest(); snap != nil {
+			writeSnapshotMetrics(p, snap)
+		}
+	}
+
+	if s.docker != nil {
+		available := s.docker.Available()
+		p.gauge("ultron_docker_available", "Whether the Docker daemon is reachable.", boolGauge(available))
+		if available {
+			writeContainerMetrics(p, s.docker.Containers())
+		}
+	}
+
+	if s.systemd != nil {
+		available := s.systemd.Available()
+		p.gauge("ultron_systemd_available", "Whether systemctl is reachable.", boolGauge(available))
+		if available {
+			writeServiceMetrics(p, s.systemd.Services())
+		}
+	}
+
+	if s.smart != nil {
+		available := s.smart.Available()
+		p.gauge("ultron_smart_available", "Whether smartctl is installed.", boolGauge(available))
+		if available {
+			writeDriveMetrics(p, s.smart.Drives())
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(p.buf.Bytes())
+}
+
+func writeSnapshotMetrics(p *promWriter, snap *metrics.Snapshot) {
+	p.gauge("ultron_cpu_usage_percent", "Total CPU usage across all cores.", snap.CPU.TotalPercent)
+
+	if len(snap.CPU.PerCore) > 0 {
+		p.family("ultron_cpu_core_usage_percent", "gauge", "CPU usage per core.")
+		for i, v := range snap.CPU.PerCore {
+			p.sample("ultron_cpu_core_usage_percent", v, "core", strconv.Itoa(i))
+		}
+	}
+
+	p.family("ultron_cpu_mode_percent", "gauge", "Share of CPU time spent in each mode since the previous reading.")
+	for _, m := range []struct {
+		mode  string
+		value float64
+	}{
+		{"user", snap.CPU.Times.User},
+		{"nice", snap.CPU.Times.Nice},
+		{"system", snap.CPU.Times.System},
+		{"idle", snap.CPU.Times.Idle},
+		{"iowait", snap.CPU.Times.IOWait},
+		{"irq", snap.CPU.Times.IRQ},
+		{"softirq", snap.CPU.Times.SoftIRQ},
+		{"steal", snap.CPU.Times.Steal},
+	} {
+		p.sample("ultron_cpu_mode_percent", m.value, "mode", m.mode)
+	}
+
+	p.gauge("ultron_load1", "1-minute load average.", snap.CPU.Load1)
+	p.gauge("ultron_load5", "5-minute load average.", snap.CPU.Load5)
+	p.gauge("ultron_load15", "15-minute load average.", snap.CPU.Load15)
+
+	p.gauge("ultron_context_switches_per_second", "Kernel context switch rate.", snap.System.ContextSwitchesPS)
+	p.gauge("ultron_interrupts_per_second", "Interrupt rate across all IRQs.", snap.System.InterruptsPS)
+	p.gauge("ultron_processes", "Number of processes.", float64(snap.System.Processes))
+	p.gauge("ultron_threads", "Number of threads.", float64(snap.System.Threads))
+	p.gauge("ultron_procs_running", "Processes currently runnable.", float64(snap.System.ProcsRunning))
+	p.gauge("ultron_procs_blocked", "Processes blocked waiting for I/O.", float64(snap.System.ProcsBlocked))
+	p.gauge("ultron_uptime_seconds", "Time since the host booted.", float64(snap.System.UptimeSeconds))
+
+	p.gauge("ultron_memory_total_bytes", "Total physical memory.", float64(snap.RAM.Total))
+	p.gauge("ultron_memory_used_bytes", "Used physical memory.", float64(snap.RAM.Used))
+	p.gauge("ultron_memory_available_bytes", "Memory available for new allocations.", float64(snap.RAM.Available))
+	p.gauge("ultron_memory_usage_percent", "Used memory as a percentage of total.", snap.RAM.Percent)
+	p.gauge("ultron_memory_buffers_bytes", "Memory used for block device buffers.", float64(snap.RAM.Buffers))
+	p.gauge("ultron_memory_cached_bytes", "Memory used for the page cache.", float64(snap.RAM.Cached))
+	p.gauge("ultron_memory_dirty_bytes", "Memory waiting to be written back to disk.", float64(snap.RAM.Dirty))
+	p.gauge("ultron_memory_writeback_bytes", "Memory being written back to disk.", float64(snap.RAM.Writeback))
+	p.gauge("ultron_swap_total_bytes", "Total swap space.", float64(snap.RAM.SwapTotal))
+	p.gauge("ultron_swap_used_bytes", "Swap space in use.", float64(snap.RAM.SwapUsed))
+	p.gauge("ultron_swap_usage_percent", "Used swap as a percentage of total.", snap.RAM.SwapPercent)
+
+	if z := snap.RAM.Zram; z != nil {
+		p.gauge("ultron_zram_original_bytes", "Uncompressed size of the data stored in zram.", float64(z.OrigDataSize))
+		p.gauge("ultron_zram_compressed_bytes", "Compressed size of the data stored in zram.", float64(z.ComprDataSize))
+		p.gauge("ultron_zram_memory_used_bytes", "RAM used by zram including allocator overhead.", float64(z.MemUsedTotal))
+		p.gauge("ultron_zram_compression_ratio", "Original to compressed size ratio across zram devices.", z.CompressionRatio)
+	}
+
+	if psi := snap.RAM.Pressure; psi != nil {
+		p.family("ultron_memory_pressure_percent", "gauge", "Share of time tasks stalled waiting for memory (PSI).")
+		for _, w := range []struct {
+			kind, window string
+			value        float64
+		}{
+			{"some", "10s", psi.SomeAvg10}, {"some", "60s", psi.SomeAvg60}, {"some", "300s", psi.SomeAvg300},
+			{"full", "10s", psi.FullAvg10}, {"full", "60s", psi.FullAvg60}, {"full", "300s", psi.FullAvg300},
+		} {
+			p.sample("ultron_memory_pressure_percent", w.value, "kind", w.kind, "window", w.window)
+		}
+	}
+
+	if len(snap.Disks) > 0 {
+		diskFamilies := []struct {
+			name, help string
+			value      func(d metrics.DiskPartition) float64
+		}{
+			{"ultron_disk_total_bytes", "Filesystem size.", func(d metrics.DiskPartition) float64 { return float64(d.Total) }},
+			{"ultron_disk_used_bytes", "Filesystem space used.", func(d metrics.DiskPartition) float64 { return float64(d.Used) }},
+			{"ultron_disk_free_bytes", "Filesystem space free.", func(d metrics.DiskPartition) float64 { return float64(d.Free) }},
+			{"ultron_disk_usage_percent", "Filesystem space used as a percentage.", func(d metrics.DiskPartition) float64 { return d.Percent }},
+			{"ultron_disk_inodes_total", "Filesystem inode count.", func(d metrics.DiskPartition) float64 { return float64(d.InodesTotal) }},
+			{"ultron_disk_inodes_used", "Filesystem inodes in use.", func(d metrics.DiskPartition) float64 { return float64(d.InodesUsed) }},
+			{"ultron_disk_inodes_free", "Filesystem inodes free.", func(d metrics.DiskPartition) float64 { return float64(d.InodesFree) }},
+			{"ultron_disk_inodes_usage_percent", "Filesystem inodes used as a percentage.", func(d metrics.DiskPartition) float64 { return d.InodesPercent }},
+		}
+		for _, f := range diskFamilies {
+			p.family(f.name, "gauge", f.help)
+			for _, d := range snap.Disks {
+				p.sample(f.name, f.value(d), "path", d.Path)
+			}
+		}
+
+		family := false
+		for _, d := range snap.Disks {
+			if d.FullInHours == nil {
+				continue
+			}
+			if !family {
+				p.family("ultron_disk_full_eta_hours", "gauge", "Estimated hours until the filesystem is full at its current growth trend.")
+				family = true
+			}
+			p.sample("ultron_disk_full_eta_hours", *d.FullInHours, "path", d.Path)
+		}
+	}
+
+	if len(snap.DiskIO) > 0 {
+		ioFamilies := []struct {
+			name, help string
+			value      func(d metrics.DiskIO) float64
+		}{
+			{"ultron_disk_read_bytes_per_second", "Block device read rate.", func(d metrics.DiskIO) float64 { return float64(d.ReadBytesPS) }},
+			{"ultron_disk_write_bytes_per_second", "Block device write rate.", func(d metrics.DiskIO) float64 { return float64(d.WriteBytesPS) }},
+			{"ultron_disk_reads_per_second", "Block device read operations per second.", func(d metrics.DiskIO) float64 { return d.ReadIOPS }},
+			{"ultron_disk_writes_per_second", "Block device write operations per second.", func(d metrics.DiskIO) float64 { return d.WriteIOPS }},
+			{"ultron_disk_busy_percent", "Share of time the block device had I/O in flight.", func(d metrics.DiskIO) float64 { return d.BusyPercent }},
+			{"ultron_disk_await_milliseconds", "Average time per completed block device request.", func(d metrics.DiskIO) float64 { return d.AwaitMs }},
+		}
+		for _, f := range ioFamilies {
+			p.family(f.name, "gauge", f.help)
+			for _, d := range snap.DiskIO {
+				p.sample(f.name, f.value(d), "device", d.Device)
+			}
+		}
+	}
+
+	if len(snap.Networks) > 0 {
+		netFamilies := []struct {
+			name, typ, help string
+			value           func(n metrics.NetworkIface) float64
+		}{
+			{"ultron_network_transmit_bytes_per_second", "gauge", "Network transmit rate per interface.", func(n metrics.NetworkIface) float64 { return float64(n.BytesSentPS) }},
+			{"ultron_network_receive_bytes_per_second", "gauge", "Network receive rate per interface.", func(n metrics.NetworkIface) float64 { return float64(n.BytesRecvPS) }},
+			{"ultron_network_transmit_packets_per_second", "gauge", "Packets sent per second.", func(n metrics.NetworkIface) float64 { return n.PacketsSentPS }},
+			{"ultron_network_receive_packets_per_second", "gauge", "Packets received per second.", func(n metrics.NetworkIface) float64 { return n.PacketsRecvPS }},
+			{"ultron_network_transmit_errors_per_second", "gauge", "Transmit errors per second.", func(n metrics.NetworkIface) float64 { return n.ErrorsOutPS }},
+			{"ultron_network_receive_errors_per_second", "gauge", "Receive errors per second.", func(n metrics.NetworkIface) float64 { return n.ErrorsInPS }},
+			{"ultron_network_transmit_drops_per_second", "gauge", "Outgoing packets dropped per second.", func(n metrics.NetworkIface) float64 { return n.DropsOutPS }},
+			{"ultron_network_receive_drops_per_second", "gauge", "Incoming packets dropped per second.", func(n metrics.NetworkIface) float64 { return n.DropsInPS }},
+			{"ultron_network_transmit_bytes_total", "counter", "Bytes sent since boot.", func(n metrics.NetworkIface) float64 { return float64(n.BytesSent) }},
+			{"ultron_network_receive_bytes_total", "counter", "Bytes received since boot.", func(n metrics.NetworkIface) float64 { return float64(n.BytesRecv) }},
+			{"ultron_network_transmit_packets_total", "counter", "Packets sent since boot.", func(n metrics.NetworkIface) float64 { return float64(n.PacketsSent) }},
+			{"ultron_network_receive_packets_total", "counter", "Packets received since boot.", func(n metrics.NetworkIface) float64 { return float64(n.PacketsRecv) }},
+			{"ultron_network_transmit_errors_total", "counter", "Transmit errors since boot.", func(n metrics.NetworkIface) float64 { return float64(n.ErrorsOut) }},
+			{"ultron_network_receive_errors_total", "counter", "Receive errors since boot.", func(n metrics.NetworkIface) float64 { return float64(n.ErrorsIn) }},
+			{"ultron_network_transmit_drops_total", "counter", "Outgoing packets dropped since boot.", func(n metrics.NetworkIface) float64 { return float64(n.DropsOut) }},
+			{"ultron_network_receive_drops_total", "counter", "Incoming packets dropped since boot.", func(n metrics.NetworkIface) float64 { return float64(n.DropsIn) }},
+			{"ultron_network_up", "gauge", "Whether the interface operstate is up.", func(n metrics.NetworkIface) float64 { return boolGauge(n.OperState == "up") }},
+			{"ultron_network_carrier", "gauge", "Whether a physical link is detected.", func(n metrics.NetworkIface) float64 { return boolGauge(n.Carrier) }},
+			{"ultron_network_mtu_bytes", "gauge", "Interface MTU.", func(n metrics.NetworkIface) float64 { return float64(n.MTU) }},
+			{"ultron_network_speed_mbps", "gauge", "Negotiated link speed; 0 if unknown.", func(n metrics.NetworkIface) float64 { return float64(n.SpeedMbps) }},
+		}
+		for _, f := range netFamilies {
+			p.family(f.name, f.typ, f.help)
+			for _, n := range snap.Networks {
+				p.sample(f.name, f.value(n), "interface", n.Name)
+			}
+		}
+
+		p.family("ultron_network_info", "gauge", "Interface details; always 1.")
+		for _, n := range snap.Networks {
+			p.sample("ultron_network_info", 1, "interface", n.Name, "mac", n.MAC, "operstate", n.OperState)
+		}
+		p.family("ultron_network_address_info", "gauge", "Addresses assigned to each interface; always 1.")
+		for _, n := range snap.Networks {
+			for _, addr := range n.Addrs {
+				p.sample("ultron_network_address_info", 1, "interface", n.Name, "address", addr)
+			}
+		}
+	}
+
+	if snap.Temperature != nil {
+		p.gauge("ultron_temperature_celsius", "Primary temperature sensor.", *snap.Temperature)
+	}
+
+	if len(snap.Temperatures) > 0 {
+		p.family("ultron_sensor_temperature_celsius", "gauge", "Temperature per sensor.")
+		for _, t := range snap.Temperatures {
+			p.sample("ultron_sensor_temperature_celsius", t.Celsius, "sensor", t.Label)
+		}
+	}
+
+	if snap.Pi != nil {
+		p.gauge("ultron_pi_throttled_mask", "Raw Raspberry Pi firmware throttled bitmask.", float64(snap.Pi.ThrottledMask))
+		p.gauge("ultron_pi_under_voltage", "Whether the Raspberry Pi is under-voltage now.", boolGauge(snap.Pi.UnderVoltage))
+		p.gauge("ultron_pi_throttled", "Whether the Raspberry Pi is throttled, frequency capped or at its soft temperature limit now.",
+			boolGauge(snap.Pi.Throttled || snap.Pi.FreqCapped || snap.Pi.SoftTempLimit))
+		if snap.Pi.ARMClockHz > 0 {
+			p.gauge("ultron_pi_arm_clock_hertz", "Current Raspberry Pi ARM clock.", float64(snap.Pi.ARMClockHz))
+		}
+		if snap.Pi.CoreVolts > 0 {
+			p.gauge("ultron_pi_core_volts", "Current Raspberry Pi core voltage.", snap.Pi.CoreVolts)
+		}
+	}
+
+	if len(snap.Custom) > 0 {
+		p.family("ultron_custom_metric", "gauge", "Latest value reported by a custom collector.")
+		for _, m := range snap.Custom {
+			p.sample("ultron_custom_metric", m.Value, "collector", m.Collector, "name", m.Name, "unit", m.Unit)
+		}
+	}
+}
+
+func writeContainerMetrics(p *promWriter, containers []docker.ContainerInfo) {
+	if len(containers) == 0 {
+		return
+	}
+
+	p.family("ultron_container_state", "gauge", "Container state; 1 for the current state, 0 otherwise.")
+	for _, c := range containers {
+		for _, state := range dockerStates {
+			p.sample("ultron_container_state", boolGauge(c.State == state), "container", c.Name, "state", state)
+		}
+	}
+
+	containerFamilies := []struct {
+		name, help string
+		value      func(c docker.ContainerInfo) float64
+	}{
+		{"ultron_container_cpu_usage_percent", "Container CPU usage.", func(c docker.ContainerInfo) float64 { return c.CPUPercent }},
+		{"ultron_container_memory_usage_bytes", "Container memory usage.", func(c docker.ContainerInfo) float64 { return float64(c.MemUsage) }},
+		{"ultron_container_memory_limit_bytes", "Container memory limit.", func(c docker.ContainerInfo) float64 { return float64(c.MemLimit) }},
+		{"ultron_container_memory_usage_percent", "Container memory usage as a percentage of its limit.", func(c docker.ContainerInfo) float64 { return c.MemPercent }},
+	}
+	for _, f := range containerFamilies {
+		p.family(f.name, "gauge", f.help)
+		for _, c := range containers {
+			p.sample(f.name, f.value(c), "container", c.Name, "image", c.Image)
+		}
+	}
+}
+
+func writeServiceMetrics(p *promWriter, services []systemd.ServiceInfo) {
+	if len(services) == 0 {
+		return
+	}
+
+	p.family("ultron_systemd_unit_active", "gauge", "Whether the systemd service is active.")
+	for _, svc := range services {
+		p.sample("ultron_systemd_unit_active", boolGauge(svc.Health == systemd.ServiceActive), "unit", svc.Name)
+	}
+
+	p.family("ultron_systemd_unit_failed", "gauge", "Whether the systemd service is in the failed state.")
+	for _, svc := range services {
+		p.sample("ultron_systemd_unit_failed", boolGauge(svc.Health == systemd.ServiceFailed), "unit", svc.Name)
+	}
+}
+
+func writeDriveMetrics(p *promWriter, drives []smart.Drive) {
+	if len(drives) == 0 {
+		return
+	}
+
+	p.family("ultron_drive_health", "gauge", "Drive SMART health; 1 for the current health, 0 otherwise.")
+	for _, d := range drives {
+		for _, h := range driveHealths {
+			p.sample("ultron_drive_health", boolGauge(d.Health == h), "device", d.Device, "model", d.Model, "health", string(h))
+		}
+	}
+
+	// Counters are only meaningful for drives whose SMART data could be read.
+	var read []smart.Drive
+	for _, d := range drives {
+		if d.Health != smart.HealthUnknown || d.Standby {
+			read = append(read, d)
+		}
+	}
+	driveFamilies := []struct {
+		name, help string
+		value      func(d smart.Drive) float64
+	}{
+		{"ultron_drive_reallocated_sectors", "Reallocated sectors (ATA) or grown defects (SCSI).", func(d smart.Drive) float64 { return float64(d.Reallocated) }},
+		{"ultron_drive_pending_sectors", "Sectors pending reallocation.", func(d smart.Drive) float64 { return float64(d.Pending) }},
+		{"ultron_drive_uncorrectable_errors", "Offline uncorrectable sectors (ATA) or media errors (NVMe).", func(d smart.Drive) float64 { return float64(d.Uncorrectable) }},
+		{"ultron_drive_power_on_hours", "Drive power-on time in hours.", func(d smart.Drive) float64 { return float64(d.PowerOnHours) }},
+	}
+	for _, f := range driveFamilies {
+		if len(read) == 0 {
+			break
+		}
+		p.family(f.name, "gauge", f.help)
+		for _, d := range read {
+			p.sample(f.name, f.value(d), "device", d.Device, "model", d.Model)
+		}
+	}
+
+	optionalFamilies := []struct {
+		name, help string
+		value      func(d smart.Drive) *float64
+	}{
+		{"ultron_drive_temperature_celsius", "Drive temperature.", func(d smart.Drive) *float64 { return d.Temperature }},
+		{"ultron_drive_wear_percent", "NVMe percentage of rated endurance used.", func(d smart.Drive) *float64 { return d.WearPercent }},
+	}
+	for _, f := range optionalFamilies {
+		header := false
+		for _, d := range drives {
+			v := f.value(d)
+			if v == nil {
+				continue
+			}
+			if !header {
+				p.family(f.name, "gauge", f.help)
+				header = true
+			}
+			p.sample(f.name, *v, "device", d.Device, "model", d.Model)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/docker"
+	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
+	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
+)
+
+func scrape(srv *Server, authHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPrometheus_OpenWithoutToken(t *testing.T) {
+	srv, _ := setupMetricsTestServer(t)
+
+	rec := scrape(srv, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE ultron_cpu_usage_percent gauge\nultron_cpu_usage_percent 42\n")
+	assert.Contains(t, body, "ultron_memory_usage_percent 60\n")
+	assert.Contains(t, body, `ultron_disk_usage_percent{path="/"} 70`)
+	assert.Contains(t, body, `ultron_disk_usage_percent{path="/boot"} 20`)
+}
+
+func TestPrometheus_TokenRequired(t *testing.T) {
+	srv, _ := setupMetricsTestServer(t)
+	srv.cfg.MetricsToken = "scrape-secret"
+
+	rec := scrape(srv, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+	rec = scrape(srv, "Bearer wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = scrape(srv, "Bearer scrape-secret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "ultron_cpu_usage_percent")
+}
+
+func TestPrometheus_SessionCookieNotAccepted(t *testing.T) {
+	srv, sid := setupMetricsTestServer(t)
+	srv.cfg.MetricsToken = "scrape-secret"
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: sid})
+	rec := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestPrometheus_NoCollectors(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+
+	rec := scrape(srv, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestWriteSnapshotMetrics_AllFields(t *testing.T) {
+	temp, eta := 55.5, 96.0
+	snap := &metrics.Snapshot{
+		CPU:          metrics.CPUMetrics{TotalPercent: 12, PerCore: []float64{10, 14}},
+		RAM:          metrics.RAMMetrics{Total: 1000, Used: 400, Available: 600, Percent: 40},
+		Disks:        []metrics.DiskPartition{{Path: "/", Total: 100, Used: 30, Free: 70, Percent: 30, InodesTotal: 1000, InodesFree: 750, InodesPercent: 25}, {Path: "/var/log", Percent: 60, FullInHours: &eta}},
+		DiskIO:       []metrics.DiskIO{{Device: "mmcblk0", ReadBytesPS: 4096, WriteIOPS: 12, BusyPercent: 80, AwaitMs: 9.5}},
+		Networks:     []metrics.NetworkIface{{Name: "eth0", BytesSentPS: 5, BytesRecvPS: 7}},
+		Temperature:  &temp,
+		Temperatures: []metrics.TempSensor{{Label: "cpu_thermal", Celsius: 55.5}, {Label: "nvme_composite", Celsius: 40}},
+	}
+
+	p := &promWriter{}
+	writeSnapshotMetrics(p, snap)
+	body := p.buf.String()
+
+	assert.Contains(t, body, `ultron_cpu_core_usage_percent{core="1"} 14`)
+	assert.Contains(t, body, "ultron_memory_total_bytes 1000\n")
+	assert.Contains(t, body, "ultron_memory_available_bytes 600\n")
+	assert.Contains(t, body, `ultron_disk_free_bytes{path="/"} 70`)
+	assert.Contains(t, body, `ultron_disk_inodes_free{path="/"} 750`)
+	assert.Contains(t, body, `ultron_disk_inodes_usage_percent{path="/"} 25`)
+	assert.Contains(t, body, `ultron_disk_full_eta_hours{path="/var/log"} 96`)
+	assert.NotContains(t, body, `ultron_disk_full_eta_hours{path="/"}`)
+	assert.Contains(t, body, `ultron_disk_read_bytes_per_second{device="mmcblk0"} 4096`)
+	assert.Contains(t, body, `ultron_disk_writes_per_second{device="mmcblk0"} 12`)
+	assert.Contains(t, body, `ultron_disk_busy_percent{device="mmcblk0"} 80`)
+	assert.Contains(t, body, `ultron_disk_await_milliseconds{device="mmcblk0"} 9.5`)
+	assert.Contains(t, body, `ultron_network_transmit_bytes_per_second{interface="eth0"} 5`)
+	assert.Contains(t, body, `ultron_network_receive_bytes_per_second{interface="eth0"} 7`)
+	assert.Contains(t, body, "ultron_temperature_celsius 55.5\n")
+	assert.Contains(t, body, `ultron_sensor_temperature_celsius{sensor="nvme_composite"} 40`)
+}
+
+func TestWriteSnapshotMetrics_System(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{
+		CPU:    metrics.CPUMetrics{Load1: 1.5, Load5: 0.75, Load15: 0.25, Times: metrics.CPUTimes{User: 30, IOWait: 12.5}},
+		System: metrics.SystemCounters{ContextSwitchesPS: 4200, InterruptsPS: 900, Processes: 180, Threads: 512, ProcsRunning: 2, ProcsBlocked: 1, UptimeSeconds: 86400},
+	})
+	body := p.buf.String()
+
+	assert.Contains(t, body, "ultron_load1 1.5\n")
+	assert.Contains(t, body, "ultron_load15 0.25\n")
+	assert.Contains(t, body, `ultron_cpu_mode_percent{mode="iowait"} 12.5`)
+	assert.Contains(t, body, `ultron_cpu_mode_percent{mode="user"} 30`)
+	assert.Contains(t, body, "ultron_context_switches_per_second 4200\n")
+	assert.Contains(t, body, "ultron_threads 512\n")
+	assert.Contains(t, body, "ultron_procs_blocked 1\n")
+	assert.Contains(t, body, "ultron_uptime_seconds 86400\n")
+}
+
+func TestWriteSnapshotMetrics_MemoryBreakdown(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{RAM: metrics.RAMMetrics{
+		Cached: 300, Dirty: 12, SwapTotal: 2048, SwapUsed: 512, SwapPercent: 25,
+		Zram:     &metrics.ZramStats{Devices: 1, OrigDataSize: 4000, ComprDataSize: 1000, MemUsedTotal: 1100, CompressionRatio: 4},
+		Pressure: &metrics.MemoryPressure{SomeAvg10: 2.5, FullAvg300: 0.5},
+	}})
+	body := p.buf.String()
+
+	assert.Contains(t, body, "ultron_memory_cached_bytes 300\n")
+	assert.Contains(t, body, "ultron_memory_dirty_bytes 12\n")
+	assert.Contains(t, body, "ultron_swap_used_bytes 512\n")
+	assert.Contains(t, body, "ultron_swap_usage_percent 25\n")
+	assert.Contains(t, body, "ultron_zram_compression_ratio 4\n")
+	assert.Contains(t, body, `ultron_memory_pressure_percent{kind="some",window="10s"} 2.5`)
+	assert.Contains(t, body, `ultron_memory_pressure_percent{kind="full",window="300s"} 0.5`)
+}
+
+func TestWriteSnapshotMetrics_NoZramOrPSI(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{})
+	assert.NotContains(t, p.buf.String(), "ultron_zram_")
+	assert.NotContains(t, p.buf.String(), "ultron_memory_pressure_percent")
+}
+
+func TestWriteSnapshotMetrics_NetworkDetails(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{Networks: []metrics.NetworkIface{{
+		Name: "eth0", ErrorsInPS: 2.5, BytesRecv: 123456, DropsOut: 3,
+		OperState: "up", Carrier: true, MTU: 1500, SpeedMbps: 1000,
+		MAC: "dc:a6:32:01:02:03", Addrs: []string{"192.168.1.10/24", "fe80::1/64"},
+	}}})
+	body := p.buf.String()
+
+	assert.Contains(t, body, `ultron_network_receive_errors_per_second{interface="eth0"} 2.5`)
+	assert.Contains(t, body, "# TYPE ultron_network_receive_bytes_total counter\n")
+	assert.Contains(t, body, `ultron_network_receive_bytes_total{interface="eth0"} 123456`)
+	assert.Contains(t, body, `ultron_network_transmit_drops_total{interface="eth0"} 3`)
+	assert.Contains(t, body, `ultron_network_up{interface="eth0"} 1`)
+	assert.Contains(t, body, `ultron_network_speed_mbps{interface="eth0"} 1000`)
+	assert.Contains(t, body, `ultron_network_info{interface="eth0",mac="dc:a6:32:01:02:03",operstate="up"} 1`)
+	assert.Contains(t, body, `ultron_network_address_info{interface="eth0",address="fe80::1/64"} 1`)
+}
+
+func TestWriteSnapshotMetrics_Pi(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{Pi: &metrics.PiStatus{ThrottledMask: 0x50005, UnderVoltage: true, ARMClockHz: 600000000, CoreVolts: 0.86}})
+	body := p.buf.String()
+
+	assert.Contains(t, body, "ultron_pi_throttled_mask 327685\n")
+	assert.Contains(t, body, "ultron_pi_under_voltage 1\n")
+	assert.Contains(t, body, "ultron_pi_throttled 0\n")
+	assert.Contains(t, body, "ultron_pi_arm_clock_hertz 6e+08\n")
+	assert.Contains(t, body, "ultron_pi_core_volts 0.86\n")
+}
+
+func TestWriteSnapshotMetrics_Custom(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{Custom: []metrics.CustomMetric{
+		{Name: "ups.battery", Collector: "ups", Value: 87, Unit: "%"},
+		{Name: "queue.depth", Collector: "queue", Value: 12},
+	}})
+	body := p.buf.String()
+
+	assert.Contains(t, body, "# TYPE ultron_custom_metric gauge\n")
+	assert.Contains(t, body, `ultron_custom_metric{collector="ups",name="ups.battery",unit="%"} 87`)
+	assert.Contains(t, body, `ultron_custom_metric{collector="queue",name="queue.depth",unit=""} 12`)
+}
+
+func TestWriteSnapshotMetrics_NotAPi(t *testing.T) {
+	p := &promWriter{}
+	writeSnapshotMetrics(p, &metrics.Snapshot{})
+	assert.NotContains(t, p.buf.String(), "ultron_pi_")
+}
+
+func TestWriteContainerMetrics(t *testing.T) {
+	p := &promWriter{}
+	writeContainerMetrics(p, []docker.ContainerInfo{
+		{Name: "web", Image: "nginx", State: "running", CPUPercent: 3.5, MemUsage: 2048, MemLimit: 4096, MemPercent: 50},
+		{Name: "db", Image: "postgres", State: "exited"},
+	})
+	body := p.buf.String()
+
+	assert.Contains(t, body, `ultron_container_state{container="web",state="running"} 1`)
+	assert.Contains(t, body, `ultron_container_state{container="web",state="exited"} 0`)
+	assert.Contains(t, body, `ultron_container_state{container="db",state="exited"} 1`)
+	assert.Contains(t, body, `ultron_container_cpu_usage_percent{container="web",image="nginx"} 3.5`)
+	assert.Contains(t, body, `ultron_container_memory_usage_bytes{container="web",image="nginx"} 2048`)
+	assert.Contains(t, body, `ultron_container_memory_limit_bytes{container="web",image="nginx"} 4096`)
+}
+
+func TestWriteServiceMetrics(t *testing.T) {
+	p := &promWriter{}
+	writeServiceMetrics(p, []systemd.ServiceInfo{
+		{Name: "sshd", ActiveState: "active", Health: systemd.ServiceActive},
+		{Name: "broken", ActiveState: "failed", Health: systemd.ServiceFailed},
+	})
+	body := p.buf.String()
+
+	assert.Contains(t, body, `ultron_systemd_unit_active{unit="sshd"} 1`)
+	assert.Contains(t, body, `ultron_systemd_unit_active{unit="broken"} 0`)
+	assert.Contains(t, body, `ultron_systemd_unit_failed{unit="broken"} 1`)
+	assert.Contains(t, body, `ultron_systemd_unit_failed{unit="sshd"} 0`)
+}
+
+func TestWriteDriveMetrics(t *testing.T) {
+	temp, wear := 38.0, 12.0
+	p := &promWriter{}
+	writeDriveMetrics(p, []smart.Drive{
+		{Device: "/dev/nvme0", Model: "SN570", Health: smart.HealthPassed, PowerOnHours: 900, Temperature: &temp, WearPercent: &wear},
+		{Device: "/dev/sda", Model: "WD20EFRX", Health: smart.HealthWarning, Reallocated: 24, Pending: 3},
+		{Device: "/dev/sdb", Health: smart.HealthUnknown, Error: "Unknown USB bridge"},
+	})
+	body := p.buf.String()
+
+	assert.Contains(t, body, `ultron_drive_health{device="/dev/nvme0",model="SN570",health="passed"} 1`)
+	assert.Contains(t, body, `ultron_drive_health{device="/dev/sda",model="WD20EFRX",health="warning"} 1`)
+	assert.Contains(t, body, `ultron_drive_health{device="/dev/sdb",model="",health="unknown"} 1`)
+	assert.Contains(t, body, `ultron_drive_reallocated_sectors{device="/dev/sda",model="WD20EFRX"} 24`)
+	assert.Contains(t, body, `ultron_drive_pending_sectors{device="/dev/sda",model="WD20EFRX"} 3`)
+	assert.Contains(t, body, `ultron_drive_power_on_hours{device="/dev/nvme0",model="SN570"} 900`)
+	assert.Contains(t, body, `ultron_drive_temperature_celsius{device="/dev/nvme0",model="SN570"} 38`)
+	assert.Contains(t, body, `ultron_drive_wear_percent{device="/dev/nvme0",model="SN570"} 12`)
+	assert.NotContains(t, body, `ultron_drive_reallocated_sectors{device="/dev/sdb"`, "drives without SMART data have no counters")
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\nd`, escapeLabelValue("a\"b\\c\nd"))
+}
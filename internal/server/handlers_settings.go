@@ -14,10 +14,15 @@ import (
 )
 
 type settingsData struct {
-	Rules    []database.AlertConfig
-	Telegram *notifDisplay
-	Email    *notifDisplay
-	Flash    string
+	Rules       []database.AlertConfig
+	Telegram    *notifDisplay
+	Email       *notifDisplay
+	Flash       string
+	TempSensors []string // labels offered as temp rule targets
+	Mountpoints []string // paths offered as disk, inodes and disk_full_eta_hours rule targets
+	Interfaces  []string // names offered as net_* rule targets
+	Custom      []string // names offered as custom rule targets
+	Probes      []database.Probe
 }
 
 type notifDisplay struct {
@@ -31,7 +36,29 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 		log.Printf("settings: failed to list rules: %v", err)
 	}
 
-	data := settingsData{Rules: rules}
+	probes, err := s.db.ListProbes()
+	if err != nil {
+		log.Printf("settings: failed to list probes: %v", err)
+	}
+
+	data := settingsData{Rules: rules, Probes: probes}
+
+	if s.collector != nil {
+		if snap := s.collector.Latest(); snap != nil {
+			for _, t := range snap.Temperatures {
+				data.TempSensors = append(data.TempSensors, t.Label)
+			}
+			for _, d := range snap.Disks {
+				data.Mountpoints = append(data.Mountpoints, d.Path)
+			}
+			for _, n := range snap.Networks {
+				data.Interfaces = append(data.Interfaces, n.Name)
+			}
+			for _, m := range snap.Custom {
+				data.Custom = append(data.Custom, m.Name)
+			}
+		}
+	}
 
 	// Load notification configs
 	if tg, err := s.db.GetNotificationConfig("telegram"); err == nil && tg != nil {
@@ -107,11 +134,42 @@ func (s *Server) handleAlertRuleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	target := strings.TrimSpace(r.FormValue("target"))
+	if target != "" && !supportsTarget(metric) {
+		http.Error(w, "Target not supported for metric", http.StatusBadRequest)
+		return
+	}
+	if target == "" && metric == "custom" {
+		http.Error(w, "Custom metric rules need a target", http.StatusBadRequest)
+		return
+	}
+
+	mode := r.FormValue("mode")
+	switch mode {
+	case "", database.AlertModeThreshold:
+		mode = database.AlertModeThreshold
+	case database.AlertModeAnomaly:
+		if operator == "==" {
+			http.Error(w, "Anomaly rules need an operator that points above or below the baseline", http.StatusBadRequest)
+			return
+		}
+		if threshold <= 0 {
+			http.Error(w, "Anomaly rules need a deviation greater than 0", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Invalid mode", http.StatusBadRequest)
+		return
+	}
+
 	ac := &database.AlertConfig{
 		Name:            r.FormValue("name"),
 		Metric:          metric,
+		Target:          target,
+		Mode:            mode,
 		Operator:        operator,
 		Threshold:       threshold,
+		Hourly:          mode == database.AlertModeAnomaly && r.FormValue("hourly") != "",
 		Severity:        severity,
 		Enabled:         true,
 		CooldownMinutes: cooldown,
@@ -119,6 +177,9 @@ func (s *Server) handleAlertRuleCreate(w http.ResponseWriter, r *http.Request) {
 
 	if ac.Name == "" {
 		ac.Name = fmt.Sprintf("%s %s %.0f", strings.ToUpper(metric), operator, threshold)
+		if mode == database.AlertModeAnomaly {
+			ac.Name = fmt.Sprintf("%s anomaly %s %gσ", strings.ToUpper(metric), operator, threshold)
+		}
 	}
 
 	if err := s.db.CreateAlertConfig(ac); err != nil {
@@ -264,7 +325,23 @@ func (s *Server) validateCSRF(w http.ResponseWriter, r *http.Request) bool {
 
 func isValidMetric(m string) bool {
 	switch m {
-	case "cpu", "ram", "disk", "temp":
+	case "cpu", "ram", "disk", "inodes", "disk_full_eta_hours", "temp", "swap", "mem_pressure",
+		"disk_read", "disk_write", "disk_iops", "disk_busy", "disk_await",
+		"undervoltage", "throttled", "arm_clock",
+		"load1", "load5", "load15", "load5_per_core",
+		"cpu_user", "cpu_system", "iowait", "steal", "softirq",
+		"ctx_switches", "interrupts", "processes", "threads", "procs_blocked",
+		"net_down", "net_errors", "net_drops", "custom":
+		return true
+	}
+	return false
+}
+
+// supportsTarget reports whether rules on metric may name a specific sensor,
+// mountpoint, network interface or custom metric.
+func supportsTarget(metric string) bool {
+	switch metric {
+	case "temp", "disk", "inodes", "disk_full_eta_hours", "net_down", "net_errors", "net_drops", "custom":
 		return true
 	}
 	return false
@@ -70,6 +70,149 @@ func TestAlertRuleCreate(t *testing.T) {
 	assert.Equal(t, 85.0, rules[0].Threshold)
 }
 
+func TestAlertRuleCreate_TempTarget(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	form := url.Values{
+		"csrf_token": {session.CSRFToken},
+		"metric":     {"temp"},
+		"target":     {" nvme_composite "},
+		"operator":   {">"},
+		"threshold":  {"70"},
+		"severity":   {"warning"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alerts/rules", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "nvme_composite")
+
+	rules, _ := srv.db.ListAlertConfigs()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "nvme_composite", rules[0].Target)
+}
+
+func TestAlertRuleCreate_DiskTarget(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	form := url.Values{
+		"csrf_token": {session.CSRFToken},
+		"metric":     {"disk"},
+		"target":     {"/data"},
+		"operator":   {">"},
+		"threshold":  {"90"},
+		"severity":   {"critical"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alerts/rules", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rules, _ := srv.db.ListAlertConfigs()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "/data", rules[0].Target)
+}
+
+func TestAlertRuleCreate_TargetUnsupported(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	form := url.Values{
+		"csrf_token": {session.CSRFToken},
+		"metric":     {"cpu"},
+		"target":     {"core0"},
+		"operator":   {">"},
+		"threshold":  {"90"},
+		"severity":   {"warning"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/alerts/rules", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+	rec := httptest.NewRecorder()
+
+	srv.httpServer.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAlertRuleCreate_CustomNeedsTarget(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	post := func(target string) int {
+		form := url.Values{
+			"csrf_token": {session.CSRFToken},
+			"metric":     {"custom"},
+			"target":     {target},
+			"operator":   {"<"},
+			"threshold":  {"20"},
+			"severity":   {"critical"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/alerts/rules", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+		rec := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(""))
+	assert.Equal(t, http.StatusOK, post("ups.battery"))
+
+	rules, _ := srv.db.ListAlertConfigs()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "ups.battery", rules[0].Target)
+}
+
+func TestAlertRuleCreate_Anomaly(t *testing.T) {
+	srv, session := setupSSETestServer(t)
+
+	post := func(mode, operator, threshold string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"csrf_token": {session.CSRFToken},
+			"metric":     {"load5"},
+			"mode":       {mode},
+			"operator":   {operator},
+			"threshold":  {threshold},
+			"hourly":     {"1"},
+			"severity":   {"warning"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/alerts/rules", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: session.ID})
+		rec := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post("anomaly", "==", "3").Code)
+	assert.Equal(t, http.StatusBadRequest, post("anomaly", ">", "0").Code)
+	assert.Equal(t, http.StatusBadRequest, post("anomaly", ">", "-2").Code)
+	assert.Equal(t, http.StatusBadRequest, post("magic", ">", "3").Code)
+
+	rec := post("anomaly", ">", "3")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "3.0&sigma; baseline")
+	assert.Contains(t, rec.Body.String(), "hourly")
+
+	rec = post("threshold", ">", "4")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rules, _ := srv.db.ListAlertConfigs()
+	require.Len(t, rules, 2)
+	assert.Equal(t, database.AlertModeAnomaly, rules[0].Mode)
+	assert.True(t, rules[0].Hourly)
+	assert.Equal(t, "LOAD5 anomaly > 3σ", rules[0].Name)
+	assert.Equal(t, database.AlertModeThreshold, rules[1].Mode)
+	assert.False(t, rules[1].Hourly, "hourly only applies to anomaly rules")
+}
+
 func TestAlertRuleCreate_InvalidThreshold(t *testing.T) {
 	srv, session := setupSSETestServer(t)
 
@@ -221,6 +364,15 @@ func TestValidation_Helpers(t *testing.T) {
 	assert.True(t, isValidMetric("ram"))
 	assert.True(t, isValidMetric("disk"))
 	assert.True(t, isValidMetric("temp"))
+	assert.True(t, isValidMetric("disk_busy"))
+	assert.True(t, isValidMetric("disk_await"))
+	assert.True(t, isValidMetric("load5_per_core"))
+	assert.True(t, isValidMetric("iowait"))
+	assert.True(t, isValidMetric("inodes"))
+	assert.True(t, isValidMetric("net_down"))
+	assert.True(t, isValidMetric("net_errors"))
+	assert.True(t, isValidMetric("swap"))
+	assert.True(t, isValidMetric("mem_pressure"))
 	assert.False(t, isValidMetric("network"))
 
 	assert.True(t, isValidOperator(">"))
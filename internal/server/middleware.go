@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/auth"
 )
 
 type contextKey string
@@ -48,3 +50,23 @@ func (s *Server) redirectOrUnauthorized(w http.ResponseWriter, r *http.Request)
 	}
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
+
+// requireMetricsToken protects scrape endpoints with the static bearer token
+// from ULTRON_METRICS_TOKEN, independent of session cookies. When no token is
+// configured the endpoint is left open.
+func (s *Server) requireMetricsToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.MetricsToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !auth.ValidateToken(s.cfg.MetricsToken, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
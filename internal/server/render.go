@@ -27,7 +27,7 @@ func (s *Server) render(w http.ResponseWriter, r *http.Request, page string, tit
 	}
 	// Include extra partials needed by specific pages
 	if page == "settings.html" {
-		patterns = append(patterns, "templates/partials/alert-rules-table.html")
+		patterns = append(patterns, "templates/partials/alert-rules-table.html", "templates/partials/probes-table.html")
 	}
 
 	tmpl, err := template.ParseFS(s.templates, patterns...)
@@ -14,6 +14,8 @@ import (
 	"github.com/cesareyeserrano/ultron-ap/internal/database"
 	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
 	"github.com/cesareyeserrano/ultron-ap/web"
 )
@@ -26,6 +28,8 @@ type Server struct {
 	collector  *metrics.Collector
 	docker     *docker.Monitor
 	systemd    *systemd.Monitor
+	probes     *probes.Monitor
+	smart      *smart.Monitor
 	alertEng   *alerts.Engine
 	sseBroker  *sseBroker
 	templates  fs.FS
@@ -61,12 +65,25 @@ func New(cfg *config.Config, db *database.DB, collector *metrics.Collector, dock
 	return s
 }
 
+// SetProbes shows the state of synthetic probes on the dashboard.
+func (s *Server) SetProbes(m *probes.Monitor) {
+	s.probes = m
+}
+
+// SetSmart shows drive health on the dashboard and in the Prometheus metrics.
+func (s *Server) SetSmart(m *smart.Monitor) {
+	s.smart = m
+}
+
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Public routes (no auth)
 	mux.HandleFunc("GET /health", s.handleHealth)
 	mux.HandleFunc("GET /login", s.handleLoginPage)
 	mux.HandleFunc("POST /login", s.handleLogin)
 
+	// Prometheus scrape endpoint (optional bearer token, no session)
+	mux.Handle("GET /metrics", s.requireMetricsToken(http.HandlerFunc(s.handlePrometheus)))
+
 	// Static files
 	staticFS, _ := fs.Sub(web.Static, "static")
 	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
@@ -81,10 +98,17 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 	// API routes (require auth)
 	mux.Handle("GET /api/sse/dashboard", s.requireAuth(http.HandlerFunc(s.handleSSE)))
+	mux.Handle("GET /api/metrics/history", s.requireAuth(http.HandlerFunc(s.handleMetricsHistory)))
 	mux.Handle("GET /api/docker/{id}", s.requireAuth(http.HandlerFunc(s.handleDockerDetail)))
+	mux.Handle("POST /api/docker/{id}/{action}", s.requireAuth(http.HandlerFunc(s.handleDockerAction)))
+	mux.Handle("GET /api/docker/{id}/logs", s.requireAuth(http.HandlerFunc(s.handleDockerLogs)))
+	mux.Handle("GET /api/docker/{id}/logs/panel", s.requireAuth(http.HandlerFunc(s.handleDockerLogPanel)))
 	mux.Handle("POST /api/alerts/rules", s.requireAuth(http.HandlerFunc(s.handleAlertRuleCreate)))
 	mux.Handle("POST /api/alerts/rules/{id}/toggle", s.requireAuth(http.HandlerFunc(s.handleAlertRuleToggle)))
 	mux.Handle("DELETE /api/alerts/rules/{id}", s.requireAuth(http.HandlerFunc(s.handleAlertRuleDelete)))
+	mux.Handle("POST /api/probes", s.requireAuth(http.HandlerFunc(s.handleProbeCreate)))
+	mux.Handle("POST /api/probes/{id}/toggle", s.requireAuth(http.HandlerFunc(s.handleProbeToggle)))
+	mux.Handle("DELETE /api/probes/{id}", s.requireAuth(http.HandlerFunc(s.handleProbeDelete)))
 	mux.Handle("POST /api/notifications/{channel}", s.requireAuth(http.HandlerFunc(s.handleNotificationSave)))
 }
 
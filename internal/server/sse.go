@@ -7,23 +7,28 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/probes"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 	"github.com/cesareyeserrano/ultron-ap/internal/systemd"
 )
 
 // DashboardData holds all data for dashboard rendering.
 type DashboardData struct {
 	Metrics      *metrics.Snapshot
-	CPUHistory   []metrics.Snapshot
-	RAMHistory   []metrics.Snapshot
+	History      *metrics.History
 	Containers   []docker.ContainerInfo
 	DockerAvail  bool
 	Services     []systemd.ServiceInfo
 	SystemdAvail bool
+	Probes       []probes.Status
+	Drives       []smart.Drive
+	SmartAvail   bool
 	Uptime       string
 }
 
@@ -137,6 +142,10 @@ func (s *Server) buildSSEPayload() []byte {
 	metricsHTML := s.renderPartial("partials/sse-metrics.html", dd)
 	writeSSEEvent(&buf, "metrics", metricsHTML)
 
+	// Processes event
+	processesHTML := s.renderPartial("partials/sse-processes.html", dd)
+	writeSSEEvent(&buf, "processes", processesHTML)
+
 	// Docker event
 	dockerHTML := s.renderPartial("partials/sse-docker.html", dd)
 	writeSSEEvent(&buf, "docker", dockerHTML)
@@ -145,6 +154,14 @@ func (s *Server) buildSSEPayload() []byte {
 	systemdHTML := s.renderPartial("partials/sse-systemd.html", dd)
 	writeSSEEvent(&buf, "systemd", systemdHTML)
 
+	// Probes event
+	probesHTML := s.renderPartial("partials/sse-probes.html", dd)
+	writeSSEEvent(&buf, "probes", probesHTML)
+
+	// Disk health event
+	smartHTML := s.renderPartial("partials/sse-smart.html", dd)
+	writeSSEEvent(&buf, "smart", smartHTML)
+
 	// Charts event
 	chartsHTML := s.renderPartial("partials/sse-charts.html", dd)
 	writeSSEEvent(&buf, "charts", chartsHTML)
@@ -152,9 +169,16 @@ func (s *Server) buildSSEPayload() []byte {
 	return buf.Bytes()
 }
 
+// writeSSEEvent writes one SSE event. CR, LF and CRLF all end a line in an
+// event stream, so each line of data gets its own data field; otherwise text
+// after a line break would be read as fields of its own.
 func writeSSEEvent(buf *bytes.Buffer, event string, data string) {
 	buf.WriteString(fmt.Sprintf("event: %s\n", event))
-	buf.WriteString(fmt.Sprintf("data: %s\n\n", data))
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r", "\n"), "\n") {
+		buf.WriteString(fmt.Sprintf("data: %s\n", line))
+	}
+	buf.WriteString("\n")
 }
 
 func (s *Server) gatherDashboardData() DashboardData {
@@ -165,8 +189,7 @@ func (s *Server) gatherDashboardData() DashboardData {
 	if s.collector != nil {
 		dd.Metrics = s.collector.Latest()
 		// Last 60 min at 5s interval = 720 points
-		dd.CPUHistory = s.collector.History(720)
-		dd.RAMHistory = dd.CPUHistory // Same data, different field rendered
+		dd.History = s.collector.History(720)
 	}
 
 	if s.docker != nil {
@@ -179,20 +202,38 @@ func (s *Server) gatherDashboardData() DashboardData {
 		dd.Services = s.systemd.Services()
 	}
 
+	if s.probes != nil {
+		dd.Probes = s.probes.Statuses()
+	}
+
+	if s.smart != nil {
+		dd.SmartAvail = s.smart.Available()
+		dd.Drives = s.smart.Drives()
+	}
+
 	return dd
 }
 
 func (s *Server) renderPartial(name string, data interface{}) string {
 	funcMap := template.FuncMap{
-		"formatBytes":    formatBytes,
-		"formatPercent":  formatPercent,
-		"tempColor":      tempColor,
-		"healthColor":    healthColor,
-		"svcHealthColor": svcHealthColor,
-		"shortID":        shortID,
-		"sparklineSVG":   sparklineSVG,
-		"formatTemp":     formatTemp,
-		"deref":          derefFloat,
+		"formatBytes":        formatBytes,
+		"formatPercent":      formatPercent,
+		"tempColor":          tempColor,
+		"healthColor":        healthColor,
+		"svcHealthColor":     svcHealthColor,
+		"driveHealthColor":   driveHealthColor,
+		"eventColor":         eventColor,
+		"shortID":            shortID,
+		"sparklineSVG":       sparklineSVG,
+		"sparklineScaledSVG": sparklineScaledSVG,
+		"formatTemp":         formatTemp,
+		"deref":              derefFloat,
+		"formatMHz":          formatMHz,
+		"formatSeconds":      formatSeconds,
+		"latencySparkline":   latencySparkline,
+		"since":              formatSince,
+		"certDays":           certDays,
+		"formatETA":          formatETA,
 	}
 
 	tmpl, err := template.New("").Funcs(funcMap).ParseFS(s.templates, "templates/"+name)
@@ -228,6 +269,15 @@ func formatPercent(f float64) string {
 	return fmt.Sprintf("%.1f%%", f)
 }
 
+func formatMHz(hz uint64) string {
+	return fmt.Sprintf("%d MHz", hz/1000000)
+}
+
+// formatSeconds formats a duration in whole seconds, such as host uptime, like formatUptime.
+func formatSeconds(secs uint64) string {
+	return formatUptime(time.Duration(secs) * time.Second)
+}
+
 func tempColor(temp *float64) string {
 	if temp == nil {
 		return "text-text-muted"
@@ -246,9 +296,9 @@ func healthColor(h docker.HealthStatus) string {
 	switch h {
 	case docker.HealthRunning:
 		return "bg-green-500"
-	case docker.HealthError:
+	case docker.HealthError, docker.HealthUnhealthy, docker.HealthOOM:
 		return "bg-red-500"
-	case docker.HealthPaused:
+	case docker.HealthPaused, docker.HealthRestarting:
 		return "bg-yellow-500"
 	default:
 		return "bg-gray-500"
@@ -266,6 +316,33 @@ func svcHealthColor(h systemd.ServiceHealth) string {
 	}
 }
 
+// eventColor highlights container events that indicate a problem.
+func eventColor(ev docker.Event) string {
+	switch {
+	case ev.Action == "oom", ev.Action == "health_status: unhealthy":
+		return "text-danger"
+	case ev.Action == "die" && ev.ExitCode != nil && *ev.ExitCode != 0:
+		return "text-danger"
+	case ev.Action == "die", ev.Action == "kill", ev.Action == "stop":
+		return "text-yellow-400"
+	default:
+		return "text-text"
+	}
+}
+
+func driveHealthColor(h smart.Health) string {
+	switch h {
+	case smart.HealthPassed:
+		return "bg-green-500"
+	case smart.HealthWarning:
+		return "bg-yellow-500"
+	case smart.HealthFailing:
+		return "bg-red-500"
+	default:
+		return "bg-gray-500"
+	}
+}
+
 func shortID(id string) string {
 	if len(id) > 12 {
 		return id[:12]
@@ -274,33 +351,64 @@ func shortID(id string) string {
 }
 
 // sparklineSVG generates a simple SVG polyline sparkline from metric history.
-func sparklineSVG(snapshots []metrics.Snapshot, field string) template.HTML {
-	if len(snapshots) == 0 {
+// field is a series name such as "cpu" or "mem_pressure"; gaps are drawn at 0.
+func sparklineSVG(history *metrics.History, field string) template.HTML {
+	values := sparklineValues(history, field)
+	if len(values) == 0 {
 		return ""
 	}
+	return sparklinePolyline(values, 0, 100) // Percent always 0-100
+}
+
+// sparklineScaledSVG is sparklineSVG for series without a fixed range, such
+// as custom metrics: the line spans the minimum to the maximum shown.
+func sparklineScaledSVG(history *metrics.History, field string) template.HTML {
+	values := sparklineValues(history, field)
+	if len(values) == 0 {
+		return ""
+	}
+	minV, maxV := values[0], values[0]
+	for _, v := range values {
+		minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+	}
+	if minV == maxV {
+		// Flat line in the middle
+		minV, maxV = minV-1, maxV+1
+	}
+	return sparklinePolyline(values, minV, maxV)
+}
+
+// sparklineValues returns the last points of a series to draw.
+func sparklineValues(history *metrics.History, field string) []float64 {
+	n := history.Len()
+	if n == 0 {
+		return nil
+	}
 
-	w, h := 300, 60
 	maxPoints := 120 // Show up to 10 min of data at 5s intervals
 
 	// Use the last maxPoints
-	data := snapshots
-	if len(data) > maxPoints {
-		data = data[len(data)-maxPoints:]
+	start := 0
+	if n > maxPoints {
+		start = n - maxPoints
 	}
 
 	// Extract values
-	values := make([]float64, len(data))
-	for i, s := range data {
-		switch field {
-		case "cpu":
-			values[i] = s.CPU.TotalPercent
-		case "ram":
-			values[i] = s.RAM.Percent
+	values := make([]float64, n-start)
+	if column := history.Column(field); column != nil {
+		for i := range values {
+			if v := float64(column[start+i]); !math.IsNaN(v) {
+				values[i] = v
+			}
 		}
 	}
+	return values
+}
+
+func sparklinePolyline(values []float64, minV, maxV float64) template.HTML {
+	w, h := 300, 60
 
 	// Scale to SVG coords
-	minV, maxV := 0.0, 100.0 // Percent always 0-100
 	points := make([]string, len(values))
 	for i, v := range values {
 		x := float64(i) / float64(len(values)-1) * float64(w)
@@ -317,6 +425,39 @@ func sparklineSVG(snapshots []metrics.Snapshot, field string) template.HTML {
 	return template.HTML(svg)
 }
 
+// latencySparkline draws probe latencies scaled from 0 to their maximum.
+func latencySparkline(latencies []float64) template.HTML {
+	if len(latencies) < 2 {
+		return ""
+	}
+	maxV := 1.0
+	for _, v := range latencies {
+		maxV = math.Max(maxV, v)
+	}
+	return sparklinePolyline(latencies, 0, maxV)
+}
+
+// certDays returns the whole days until a certificate expires, negative once it has.
+func certDays(c probes.CertInfo) int {
+	return int(math.Floor(c.DaysLeft(time.Now())))
+}
+
+// formatSince formats the time elapsed since t, e.g. "3h 12m", or "" for the zero time.
+func formatSince(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return formatUptime(time.Since(t))
+}
+
+// formatETA formats a forecast in hours, e.g. "9 hours" or "12 days".
+func formatETA(hours float64) string {
+	if hours < 48 {
+		return fmt.Sprintf("%.0f hours", hours)
+	}
+	return fmt.Sprintf("%.0f days", hours/24)
+}
+
 func formatTemp(temp *float64) string {
 	if temp == nil {
 		return "--"
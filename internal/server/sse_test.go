@@ -14,7 +14,9 @@ import (
 
 	"github.com/cesareyeserrano/ultron-ap/internal/config"
 	"github.com/cesareyeserrano/ultron-ap/internal/database"
+	"github.com/cesareyeserrano/ultron-ap/internal/docker"
 	"github.com/cesareyeserrano/ultron-ap/internal/metrics"
+	"github.com/cesareyeserrano/ultron-ap/internal/smart"
 )
 
 func setupSSETestServer(t *testing.T) (*Server, *database.Session) {
@@ -91,6 +93,82 @@ func TestFormatTemp(t *testing.T) {
 	assert.Equal(t, "--", formatTemp(nil))
 }
 
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "9 hours", formatETA(9.4))
+	assert.Equal(t, "47 hours", formatETA(47))
+	assert.Equal(t, "12 days", formatETA(12*24+5))
+}
+
+func TestRenderDiskForecast(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	eta := 30.0
+	data := DashboardData{Metrics: &metrics.Snapshot{Disks: []metrics.DiskPartition{
+		{Path: "/", Percent: 40},
+		{Path: "/var/log", Percent: 80, FullInHours: &eta},
+	}}}
+
+	html := srv.renderPartial("partials/sse-metrics.html", data)
+	assert.Contains(t, html, "/var/log full in 30 hours")
+	assert.NotContains(t, html, "/ full in")
+}
+
+func TestRenderSmart(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	wear := 93.0
+	data := DashboardData{SmartAvail: true, Drives: []smart.Drive{
+		{Device: "/dev/nvme0", Model: "WD Blue SN570", Protocol: "NVMe", Health: smart.HealthWarning, WearPercent: &wear, Problems: []string{"93% of rated endurance used"}},
+		{Device: "/dev/sda", Model: "WDC WD20EFRX", Health: smart.HealthPassed, PowerOnHours: 41000, Standby: true},
+	}}
+
+	html := srv.renderPartial("partials/sse-smart.html", data)
+	assert.Contains(t, html, "WD Blue SN570")
+	assert.Contains(t, html, "93% of rated endurance used")
+	assert.Contains(t, html, "bg-yellow-500")
+	assert.Contains(t, html, "41000 h")
+	assert.Contains(t, html, "(standby)")
+
+	html = srv.renderPartial("partials/sse-smart.html", DashboardData{})
+	assert.Contains(t, html, "smartctl not available")
+}
+
+func TestRenderDockerDetailEvents(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	exit := 137
+	detail := dockerDetailData{ContainerDetail: &docker.ContainerDetail{ID: "abc", Events: []docker.Event{
+		{Time: time.Date(2026, 3, 4, 10, 15, 0, 0, time.Local), Action: "die", ExitCode: &exit},
+		{Time: time.Date(2026, 3, 4, 10, 14, 59, 0, time.Local), Action: "oom"},
+	}}}
+
+	html := srv.renderPartial("partials/docker-detail.html", detail)
+	assert.Contains(t, html, "Mar 4 10:15:00")
+	assert.Contains(t, html, "die (exit 137)")
+	assert.Contains(t, html, "text-danger")
+
+	html = srv.renderPartial("partials/docker-detail.html", dockerDetailData{ContainerDetail: &docker.ContainerDetail{ID: "abc"}})
+	assert.Contains(t, html, "none recorded")
+}
+
+func TestRenderDockerHealth(t *testing.T) {
+	srv, _ := setupSSETestServer(t)
+	data := DashboardData{DockerAvail: true, Containers: []docker.ContainerInfo{
+		{ID: "a1", Name: "api", State: "running", Status: "Up 3 minutes (unhealthy)", HealthCheck: "unhealthy", Health: docker.HealthUnhealthy, RestartCount: 5},
+		{ID: "b2", Name: "worker", State: "exited", Status: "Exited (137) 1 minute ago", OOMKilled: true, ExitCode: 137, Health: docker.HealthOOM},
+	}}
+
+	html := srv.renderPartial("partials/sse-docker.html", data)
+	assert.Contains(t, html, `bg-red-500" title="unhealthy"`)
+	assert.Contains(t, html, `title="oom"`)
+	assert.Contains(t, html, "5 restarts")
+	assert.Contains(t, html, "OOM killed")
+	assert.Equal(t, "bg-yellow-500", healthColor(docker.HealthRestarting))
+}
+
+func TestFormatMHz(t *testing.T) {
+	assert.Equal(t, "1500 MHz", formatMHz(1500398464))
+	assert.Equal(t, "0 MHz", formatMHz(0))
+	assert.Equal(t, "1d 2h 3m", formatSeconds(26*3600+3*60))
+}
+
 func TestShortID(t *testing.T) {
 	assert.Equal(t, "abc123def456", shortID("abc123def456789"))
 	assert.Equal(t, "short", shortID("short"))
@@ -101,16 +179,34 @@ func TestSparklineSVG_Empty(t *testing.T) {
 	assert.Empty(t, string(result))
 }
 
+// historyOf stores snapshots in a ring buffer and returns them as history.
+func historyOf(snapshots []metrics.Snapshot) *metrics.History {
+	rb := metrics.NewRingBuffer(len(snapshots))
+	for _, s := range snapshots {
+		rb.Add(s)
+	}
+	return rb.All()
+}
+
 func TestSparklineSVG_WithData(t *testing.T) {
 	snapshots := make([]metrics.Snapshot, 10)
 	for i := range snapshots {
 		snapshots[i].CPU.TotalPercent = float64(i * 10)
 	}
-	result := sparklineSVG(snapshots, "cpu")
+	result := sparklineSVG(historyOf(snapshots), "cpu")
 	assert.Contains(t, string(result), "<svg")
 	assert.Contains(t, string(result), "polyline")
 }
 
+func TestSparklineSVG_MemoryPressure(t *testing.T) {
+	snapshots := make([]metrics.Snapshot, 3)
+	snapshots[1].RAM.Pressure = &metrics.MemoryPressure{SomeAvg10: 50}
+	snapshots[2].RAM.Pressure = &metrics.MemoryPressure{SomeAvg10: 100}
+	result := string(sparklineSVG(historyOf(snapshots), "mem_pressure"))
+	// The first snapshot has no PSI data and is drawn at 0
+	assert.Contains(t, result, `points="0.0,59.0 150.0,30.0 300.0,1.0"`)
+}
+
 // --- SSE Broker Tests ---
 
 func TestSSEBroker_AddRemoveClient(t *testing.T) {
@@ -196,8 +292,11 @@ func TestSSEEndpoint_SendsInitialData(t *testing.T) {
 
 	body := rec.Body.String()
 	assert.Contains(t, body, "event: metrics")
+	assert.Contains(t, body, "event: processes")
 	assert.Contains(t, body, "event: docker")
 	assert.Contains(t, body, "event: systemd")
+	assert.Contains(t, body, "event: probes")
+	assert.Contains(t, body, "event: smart")
 	assert.Contains(t, body, "event: charts")
 }
 
@@ -261,3 +360,21 @@ func TestWriteSSEEvent(t *testing.T) {
 	writeSSEEvent(b, "metrics", "<div>test</div>")
 	assert.Equal(t, "event: metrics\ndata: <div>test</div>\n\n", b.String())
 }
+
+func TestWriteSSEEvent_MultiLine(t *testing.T) {
+	b := &bytes.Buffer{}
+	writeSSEEvent(b, "metrics", "<div>\r\n  a\r  b\n</div>")
+	assert.Equal(t, "event: metrics\ndata: <div>\ndata:   a\ndata:   b\ndata: </div>\n\n", b.String())
+}
+
+func TestSparklineScaledSVG(t *testing.T) {
+	snapshots := make([]metrics.Snapshot, 3)
+	for i := range snapshots {
+		snapshots[i].Custom = []metrics.CustomMetric{{Name: "queue.depth", Value: float64(1000 + i*500)}}
+	}
+	result := string(sparklineScaledSVG(historyOf(snapshots), "custom:queue.depth"))
+	// Spans the full height from the minimum (1000) to the maximum (2000)
+	assert.Contains(t, result, `points="0.0,59.0 150.0,30.0 300.0,1.0"`)
+
+	assert.Empty(t, string(sparklineScaledSVG(nil, "custom:queue.depth")))
+}
@@ -0,0 +1,50 @@
+package smart
+
+import "time"
+
+// Health is the overall state of a drive for UI display and alerts.
+type Health string
+
+const (
+	HealthPassed  Health = "passed"  // green
+	HealthWarning Health = "warning" // yellow: bad sectors, media errors or heavy wear
+	HealthFailing Health = "failing" // red: the drive itself predicts failure
+	HealthUnknown Health = "unknown" // grey: no SMART data could be read
+)
+
+// Drive holds the SMART data of one drive.
+type Drive struct {
+	Device        string    `json:"device"`   // e.g. /dev/sda
+	Type          string    `json:"type"`     // smartctl device type, e.g. "sat" or "nvme"
+	Protocol      string    `json:"protocol"` // "ATA", "NVMe" or "SCSI"
+	Model         string    `json:"model"`
+	Serial        string    `json:"serial"`
+	Health        Health    `json:"health"`
+	Problems      []string  `json:"problems,omitempty"` // why Health is warning or failing
+	Reallocated   int64     `json:"reallocated_sectors"`
+	Pending       int64     `json:"pending_sectors"`
+	Uncorrectable int64     `json:"uncorrectable_sectors"` // ATA offline uncorrectable, NVMe media errors
+	PowerOnHours  int64     `json:"power_on_hours"`
+	Temperature   *float64  `json:"temperature,omitempty"`
+	WearPercent   *float64  `json:"wear_percent,omitempty"` // NVMe percentage used, may exceed 100
+	Standby       bool      `json:"standby"`                // asleep at the last check, values are from before
+	Error         string    `json:"error,omitempty"`
+	CheckedAt     time.Time `json:"checked_at"`
+}
+
+// Name is a short label for the drive: its model, or its device.
+func (d Drive) Name() string {
+	if d.Model != "" {
+		return d.Model
+	}
+	return d.Device
+}
+
+// Key identifies the drive across device renames, e.g. a USB SSD that comes
+// back as /dev/sdb: its serial number, or its device.
+func (d Drive) Key() string {
+	if d.Serial != "" {
+		return d.Serial
+	}
+	return d.Device
+}
@@ -0,0 +1,200 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/execrunner"
+)
+
+const (
+	// refreshInterval is how often drives are checked. SMART values change
+	// slowly, and querying a drive is not free on USB bridges.
+	refreshInterval = 10 * time.Minute
+	// commandTimeout bounds each smartctl run, which can hang on a bad bridge.
+	commandTimeout = 30 * time.Second
+)
+
+// Monitor periodically reads SMART data of the attached drives with smartctl.
+type Monitor struct {
+	runner    execrunner.Runner
+	mu        sync.RWMutex
+	drives    []Drive
+	available bool
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewMonitor creates a SMART monitor. If smartctl is not available, the
+// monitor logs a warning and returns Available() == false.
+func NewMonitor() *Monitor {
+	// smartctl reports its findings in the exit status too; its JSON output is
+	// read whatever the status.
+	m := &Monitor{runner: execrunner.Stdout{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err := m.runner.Run(ctx, "smartctl", "--version"); err != nil {
+		log.Printf("smart: smartctl not available: %v", err)
+		return m
+	}
+
+	m.available = true
+	return m
+}
+
+// newMonitorWithRunner creates a monitor that runs smartctl through runner,
+// which tests replace with recorded smartctl output. A nil runner makes the
+// monitor unavailable.
+func newMonitorWithRunner(runner execrunner.Runner) *Monitor {
+	return &Monitor{
+		runner:    runner,
+		available: runner != nil,
+	}
+}
+
+// Start begins periodic drive checks in a background goroutine.
+func (m *Monitor) Start(ctx context.Context) {
+	if !m.Available() {
+		return
+	}
+	ctx, m.cancel = context.WithCancel(ctx)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run(ctx)
+	}()
+
+	log.Printf("SMART monitor started (interval=%v)", refreshInterval)
+}
+
+// Stop cancels the refresh loop and waits for it to exit.
+func (m *Monitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+	log.Println("SMART monitor stopped")
+}
+
+// Available reports whether smartctl is installed.
+func (m *Monitor) Available() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.available
+}
+
+// Drives returns the drives of the latest check, sorted by device (thread-safe copy).
+func (m *Monitor) Drives() []Drive {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]Drive, len(m.drives))
+	copy(result, m.drives)
+	return result
+}
+
+func (m *Monitor) run(ctx context.Context) {
+	m.refresh(ctx)
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh scans for drives and reads each of them. A drive that is asleep is
+// not woken up; it keeps the values of its previous check.
+func (m *Monitor) refresh(ctx context.Context) {
+	devices, err := m.scan(ctx)
+	if err != nil {
+		log.Printf("smart: %v", err)
+		return
+	}
+
+	previous := make(map[string]Drive)
+	for _, d := range m.Drives() {
+		previous[d.Device] = d
+	}
+
+	now := time.Now()
+	drives := make([]Drive, 0, len(devices))
+	for _, dev := range devices {
+		d := m.read(ctx, dev)
+		if ctx.Err() != nil {
+			return
+		}
+		if prev, ok := previous[dev.Name]; ok && d.Standby {
+			prev.Standby = true
+			drives = append(drives, prev)
+			continue
+		}
+		d.CheckedAt = now
+		drives = append(drives, d)
+	}
+	sort.Slice(drives, func(i, j int) bool { return drives[i].Device < drives[j].Device })
+
+	m.mu.Lock()
+	m.drives = drives
+	m.mu.Unlock()
+}
+
+// scan lists the drives smartctl can open.
+func (m *Monitor) scan(ctx context.Context) ([]scanDevice, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	out, err := m.runner.Run(ctx, "smartctl", "--scan-open", "--json")
+	devices, perr := parseScan(out)
+	if perr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("smartctl --scan-open: %w", err)
+		}
+		return nil, perr
+	}
+	return devices, nil
+}
+
+// read runs smartctl for one drive. smartctl sets exit status bits for drive
+// problems as well, so its output is parsed whatever the status.
+func (m *Monitor) read(ctx context.Context, dev scanDevice) Drive {
+	if dev.OpenError != "" {
+		return Drive{Device: dev.Name, Type: dev.Type, Protocol: dev.Protocol, Health: HealthUnknown, Error: dev.OpenError}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	args := []string{"--json", "-a", "-n", "standby"}
+	if dev.Type != "" {
+		args = append(args, "-d", dev.Type)
+	}
+	out, err := m.runner.Run(ctx, "smartctl", append(args, dev.Name)...)
+
+	d, perr := parseDrive(out)
+	if perr != nil {
+		d = Drive{Health: HealthUnknown, Error: perr.Error()}
+		if err != nil {
+			d.Error = err.Error()
+		}
+	}
+	// Keep the names from the scan, which -d options and bridges don't change.
+	d.Device, d.Type = dev.Name, dev.Type
+	if d.Protocol == "" {
+		d.Protocol = dev.Protocol
+	}
+	return d
+}
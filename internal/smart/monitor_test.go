@@ -0,0 +1,106 @@
+package smart
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock Command Runner ---
+
+// mockRunner returns the output registered for the last argument (the device,
+// or "--json" for the scan). Outputs of drive reads come with an exit error,
+// as smartctl exits non-zero whenever it sets a status bit.
+type mockRunner struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	calls   [][]string
+}
+
+func (m *mockRunner) Run(_ context.Context, name string, args ...string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, append([]string{name}, args...))
+
+	out, ok := m.outputs[args[len(args)-1]]
+	if !ok {
+		return nil, errors.New("exit status 2")
+	}
+	if strings.Contains(out, `"exit_status": 0`) {
+		return []byte(out), nil
+	}
+	return []byte(out), errors.New("exit status 64")
+}
+
+func TestMonitor_Refresh(t *testing.T) {
+	runner := &mockRunner{outputs: map[string]string{
+		"--json":     sampleScan,
+		"/dev/sda":   sampleSATABadSectors,
+		"/dev/nvme0": sampleNVMeWorn,
+	}}
+	m := newMonitorWithRunner(runner)
+	m.refresh(context.Background())
+
+	drives := m.Drives()
+	require.Len(t, drives, 3)
+
+	assert.Equal(t, "/dev/nvme0", drives[0].Device)
+	assert.Equal(t, HealthWarning, drives[0].Health)
+	assert.False(t, drives[0].CheckedAt.IsZero())
+
+	assert.Equal(t, "/dev/sda", drives[1].Device)
+	assert.Equal(t, int64(24), drives[1].Reallocated, "output of a non-zero exit is parsed")
+
+	assert.Equal(t, "/dev/sdb", drives[2].Device)
+	assert.Equal(t, HealthUnknown, drives[2].Health)
+	assert.Contains(t, drives[2].Error, "Unknown USB bridge")
+
+	assert.Contains(t, runner.calls, []string{"smartctl", "--json", "-a", "-n", "standby", "-d", "sat", "/dev/sda"})
+	assert.Len(t, runner.calls, 3, "drives that failed to open are not queried")
+}
+
+func TestMonitor_StandbyKeepsPreviousValues(t *testing.T) {
+	runner := &mockRunner{outputs: map[string]string{
+		"--json":   `{"devices": [{"name": "/dev/sda", "type": "sat", "protocol": "ATA"}]}`,
+		"/dev/sda": sampleSATAHealthy,
+	}}
+	m := newMonitorWithRunner(runner)
+	m.refresh(context.Background())
+	checked := m.Drives()[0].CheckedAt
+
+	runner.outputs["/dev/sda"] = sampleStandby
+	m.refresh(context.Background())
+
+	d := m.Drives()[0]
+	assert.True(t, d.Standby)
+	assert.Equal(t, HealthPassed, d.Health)
+	assert.Equal(t, int64(13021), d.PowerOnHours)
+	assert.Equal(t, checked, d.CheckedAt)
+}
+
+func TestMonitor_ScanFailureKeepsDrives(t *testing.T) {
+	runner := &mockRunner{outputs: map[string]string{
+		"--json":   `{"devices": [{"name": "/dev/sda", "type": "sat", "protocol": "ATA"}]}`,
+		"/dev/sda": sampleSATAHealthy,
+	}}
+	m := newMonitorWithRunner(runner)
+	m.refresh(context.Background())
+
+	delete(runner.outputs, "--json")
+	m.refresh(context.Background())
+	assert.Len(t, m.Drives(), 1)
+}
+
+func TestMonitor_Unavailable(t *testing.T) {
+	m := newMonitorWithRunner(nil)
+	assert.False(t, m.Available())
+	assert.Empty(t, m.Drives())
+
+	m.Start(context.Background())
+	m.Stop()
+}
@@ -0,0 +1,195 @@
+package smart
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Bits of the smartctl exit status that describe the drive rather than the
+// command (see smartctl(8), "RETURN VALUES").
+const (
+	exitOpenFailed      = 1 << 1 // device open failed, or asleep with -n standby
+	exitCommandFailed   = 1 << 2
+	exitDiskFailing     = 1 << 3
+	exitPrefailBelow    = 1 << 4
+	exitAttrBelowInPast = 1 << 5
+)
+
+// ATA attribute IDs read from the attribute table.
+const (
+	attrReallocated   = 5
+	attrPending       = 197
+	attrUncorrectable = 198
+)
+
+// wearWarnPercent is the NVMe "percentage used" from which a drive is
+// reported as worn.
+const wearWarnPercent = 90
+
+// scanOutput is the part of `smartctl --scan-open --json` that is used.
+type scanOutput struct {
+	Devices []scanDevice `json:"devices"`
+}
+
+type scanDevice struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Protocol  string `json:"protocol"`
+	OpenError string `json:"open_error"`
+}
+
+// parseScan parses the drive list of `smartctl --scan-open --json`.
+func parseScan(out []byte) ([]scanDevice, error) {
+	var scan scanOutput
+	if err := json.Unmarshal(out, &scan); err != nil {
+		return nil, fmt.Errorf("cannot parse smartctl scan: %w", err)
+	}
+	return scan.Devices, nil
+}
+
+// smartctlOutput is the part of `smartctl --json -a` that is used.
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATAAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeLog *struct {
+		CriticalWarning int     `json:"critical_warning"`
+		PercentageUsed  float64 `json:"percentage_used"`
+		MediaErrors     int64   `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+	SCSIGrownDefects *int64 `json:"scsi_grown_defect_list"`
+}
+
+// parseDrive parses the output of `smartctl --json -a` for one drive and
+// classifies its health.
+func parseDrive(out []byte) (Drive, error) {
+	var o smartctlOutput
+	if err := json.Unmarshal(out, &o); err != nil {
+		return Drive{}, fmt.Errorf("cannot parse smartctl output: %w", err)
+	}
+
+	d := Drive{
+		Device:       o.Device.Name,
+		Type:         o.Device.Type,
+		Protocol:     o.Device.Protocol,
+		Model:        o.ModelName,
+		Serial:       o.SerialNumber,
+		PowerOnHours: o.PowerOnTime.Hours,
+	}
+	if o.Temperature != nil {
+		temp := o.Temperature.Current
+		d.Temperature = &temp
+	}
+	for _, a := range o.ATAAttributes.Table {
+		switch a.ID {
+		case attrReallocated:
+			d.Reallocated = a.Raw.Value
+		case attrPending:
+			d.Pending = a.Raw.Value
+		case attrUncorrectable:
+			d.Uncorrectable = a.Raw.Value
+		}
+	}
+	if o.SCSIGrownDefects != nil {
+		d.Reallocated = *o.SCSIGrownDefects
+	}
+	if o.NVMeLog != nil {
+		wear := o.NVMeLog.PercentageUsed
+		d.WearPercent = &wear
+		d.Uncorrectable = o.NVMeLog.MediaErrors
+	}
+
+	status := o.Smartctl.ExitStatus
+	if o.SmartStatus == nil && status&(exitOpenFailed|exitCommandFailed) != 0 {
+		for _, m := range o.Smartctl.Messages {
+			if strings.Contains(strings.ToUpper(m.String), "STANDBY") {
+				d.Standby = true
+			}
+		}
+		d.Health = HealthUnknown
+		d.Error = smartctlError(o)
+		return d, nil
+	}
+
+	var failing, warning []string
+	if (o.SmartStatus != nil && !o.SmartStatus.Passed) || status&exitDiskFailing != 0 {
+		failing = append(failing, "SMART overall-health self-assessment failed")
+	}
+	if status&exitPrefailBelow != 0 {
+		failing = append(failing, "pre-failure attribute at or below its threshold")
+	}
+	if o.NVMeLog != nil && o.NVMeLog.CriticalWarning != 0 {
+		failing = append(failing, fmt.Sprintf("NVMe critical warning 0x%02x", o.NVMeLog.CriticalWarning))
+	}
+	if d.Reallocated > 0 {
+		warning = append(warning, fmt.Sprintf("%d reallocated sectors", d.Reallocated))
+	}
+	if d.Pending > 0 {
+		warning = append(warning, fmt.Sprintf("%d pending sectors", d.Pending))
+	}
+	if d.Uncorrectable > 0 {
+		warning = append(warning, fmt.Sprintf("%d uncorrectable errors", d.Uncorrectable))
+	}
+	if d.WearPercent != nil && *d.WearPercent >= wearWarnPercent {
+		warning = append(warning, fmt.Sprintf("%.0f%% of rated endurance used", *d.WearPercent))
+	}
+	if status&exitAttrBelowInPast != 0 {
+		warning = append(warning, "an attribute was at or below its threshold in the past")
+	}
+
+	d.Problems = append(failing, warning...)
+	switch {
+	case len(failing) > 0:
+		d.Health = HealthFailing
+	case len(warning) > 0:
+		d.Health = HealthWarning
+	case o.SmartStatus == nil:
+		d.Health = HealthUnknown
+		d.Error = smartctlError(o)
+	default:
+		d.Health = HealthPassed
+	}
+	return d, nil
+}
+
+// smartctlError returns the first error message smartctl reported.
+func smartctlError(o smartctlOutput) string {
+	for _, m := range o.Smartctl.Messages {
+		if m.Severity == "error" {
+			return m.String
+		}
+	}
+	if len(o.Smartctl.Messages) > 0 {
+		return o.Smartctl.Messages[0].String
+	}
+	return "no SMART data available"
+}
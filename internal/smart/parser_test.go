@@ -0,0 +1,181 @@
+package smart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Sample smartctl --json output ---
+
+const sampleScan = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 3], "exit_status": 0},
+  "devices": [
+    {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+    {"name": "/dev/sdb", "info_name": "/dev/sdb", "type": "scsi", "protocol": "SCSI", "open_error": "/dev/sdb: Unknown USB bridge [0x152d:0x0578 (0x209)]"},
+    {"name": "/dev/nvme0", "info_name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"}
+  ]
+}`
+
+const sampleSATAHealthy = `{
+  "smartctl": {"version": [7, 3], "exit_status": 0},
+  "device": {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+  "model_name": "Samsung SSD 870 EVO 500GB",
+  "serial_number": "S6PXNX0T123456",
+  "smart_status": {"passed": true},
+  "ata_smart_attributes": {"revision": 1, "table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "worst": 100, "thresh": 10, "raw": {"value": 0, "string": "0"}},
+    {"id": 9, "name": "Power_On_Hours", "value": 97, "worst": 97, "thresh": 0, "raw": {"value": 13021, "string": "13021"}},
+    {"id": 197, "name": "Current_Pending_Sector", "value": 100, "worst": 100, "thresh": 0, "raw": {"value": 0, "string": "0"}}
+  ]},
+  "power_on_time": {"hours": 13021},
+  "temperature": {"current": 34}
+}`
+
+const sampleSATABadSectors = `{
+  "smartctl": {"version": [7, 3], "exit_status": 64},
+  "device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+  "model_name": "WDC WD20EFRX-68EUZN0",
+  "serial_number": "WD-WCC4M1234567",
+  "smart_status": {"passed": true},
+  "ata_smart_attributes": {"revision": 16, "table": [
+    {"id": 5, "name": "Reallocated_Sector_Ct", "value": 198, "worst": 198, "thresh": 140, "raw": {"value": 24, "string": "24"}},
+    {"id": 197, "name": "Current_Pending_Sector", "value": 200, "worst": 200, "thresh": 0, "raw": {"value": 3, "string": "3"}},
+    {"id": 198, "name": "Offline_Uncorrectable", "value": 200, "worst": 200, "thresh": 0, "raw": {"value": 1, "string": "1"}}
+  ]},
+  "power_on_time": {"hours": 41000},
+  "temperature": {"current": 41}
+}`
+
+const sampleSATAFailing = `{
+  "smartctl": {"version": [7, 3], "exit_status": 24},
+  "device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+  "model_name": "KINGSTON SA400S37240G",
+  "serial_number": "50026B7782AAAAAA",
+  "smart_status": {"passed": false},
+  "ata_smart_attributes": {"table": []},
+  "power_on_time": {"hours": 20000}
+}`
+
+const sampleNVMeWorn = `{
+  "smartctl": {"version": [7, 3], "exit_status": 0},
+  "device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "model_name": "WD Blue SN570 1TB",
+  "serial_number": "22123A456789",
+  "smart_status": {"passed": true, "nvme": {"value": 0}},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0, "temperature": 45, "available_spare": 100, "available_spare_threshold": 10,
+    "percentage_used": 93, "power_on_hours": 30100, "media_errors": 0, "num_err_log_entries": 12
+  },
+  "temperature": {"current": 45},
+  "power_on_time": {"hours": 30100}
+}`
+
+const sampleNVMeCritical = `{
+  "smartctl": {"version": [7, 3], "exit_status": 8},
+  "device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "model_name": "Generic NVMe",
+  "serial_number": "X1",
+  "smart_status": {"passed": false, "nvme": {"value": 1}},
+  "nvme_smart_health_information_log": {"critical_warning": 1, "percentage_used": 40, "media_errors": 2}
+}`
+
+const sampleStandby = `{
+  "smartctl": {"version": [7, 3], "exit_status": 2, "messages": [
+    {"string": "Device is in STANDBY mode, exit(2)", "severity": "information"}
+  ]},
+  "device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"}
+}`
+
+const sampleUnsupported = `{
+  "smartctl": {"version": [7, 3], "exit_status": 4, "messages": [
+    {"string": "Read Device Identity failed: scsi error unsupported field in scsi command", "severity": "error"}
+  ]},
+  "device": {"name": "/dev/sdc", "type": "sat", "protocol": "ATA"}
+}`
+
+// --- Tests ---
+
+func TestParseScan(t *testing.T) {
+	devices, err := parseScan([]byte(sampleScan))
+	require.NoError(t, err)
+	require.Len(t, devices, 3)
+	assert.Equal(t, scanDevice{Name: "/dev/sda", Type: "sat", Protocol: "ATA"}, devices[0])
+	assert.Contains(t, devices[1].OpenError, "Unknown USB bridge")
+	assert.Equal(t, "nvme", devices[2].Type)
+
+	_, err = parseScan([]byte("smartctl: command not found"))
+	assert.Error(t, err)
+}
+
+func TestParseDrive_Healthy(t *testing.T) {
+	d, err := parseDrive([]byte(sampleSATAHealthy))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/dev/sda", d.Device)
+	assert.Equal(t, "ATA", d.Protocol)
+	assert.Equal(t, "Samsung SSD 870 EVO 500GB", d.Name())
+	assert.Equal(t, "S6PXNX0T123456", d.Key())
+	assert.Equal(t, HealthPassed, d.Health)
+	assert.Empty(t, d.Problems)
+	assert.Equal(t, int64(13021), d.PowerOnHours)
+	require.NotNil(t, d.Temperature)
+	assert.Equal(t, 34.0, *d.Temperature)
+	assert.Nil(t, d.WearPercent)
+}
+
+func TestParseDrive_BadSectors(t *testing.T) {
+	d, err := parseDrive([]byte(sampleSATABadSectors))
+	require.NoError(t, err)
+
+	assert.Equal(t, HealthWarning, d.Health)
+	assert.Equal(t, int64(24), d.Reallocated)
+	assert.Equal(t, int64(3), d.Pending)
+	assert.Equal(t, int64(1), d.Uncorrectable)
+	assert.Equal(t, []string{"24 reallocated sectors", "3 pending sectors", "1 uncorrectable errors"}, d.Problems,
+		"exit bit 6 (error log) alone is not a problem")
+}
+
+func TestParseDrive_Failing(t *testing.T) {
+	d, err := parseDrive([]byte(sampleSATAFailing))
+	require.NoError(t, err)
+
+	assert.Equal(t, HealthFailing, d.Health)
+	assert.Equal(t, []string{"SMART overall-health self-assessment failed", "pre-failure attribute at or below its threshold"}, d.Problems)
+	assert.Nil(t, d.Temperature)
+}
+
+func TestParseDrive_NVMe(t *testing.T) {
+	d, err := parseDrive([]byte(sampleNVMeWorn))
+	require.NoError(t, err)
+
+	assert.Equal(t, HealthWarning, d.Health)
+	require.NotNil(t, d.WearPercent)
+	assert.Equal(t, 93.0, *d.WearPercent)
+	assert.Equal(t, []string{"93% of rated endurance used"}, d.Problems)
+
+	d, err = parseDrive([]byte(sampleNVMeCritical))
+	require.NoError(t, err)
+	assert.Equal(t, HealthFailing, d.Health)
+	assert.Equal(t, int64(2), d.Uncorrectable)
+	assert.Contains(t, d.Problems, "NVMe critical warning 0x01")
+	assert.Contains(t, d.Problems, "2 uncorrectable errors")
+}
+
+func TestParseDrive_NoData(t *testing.T) {
+	d, err := parseDrive([]byte(sampleStandby))
+	require.NoError(t, err)
+	assert.True(t, d.Standby)
+	assert.Equal(t, HealthUnknown, d.Health)
+
+	d, err = parseDrive([]byte(sampleUnsupported))
+	require.NoError(t, err)
+	assert.False(t, d.Standby)
+	assert.Equal(t, HealthUnknown, d.Health)
+	assert.Contains(t, d.Error, "Read Device Identity failed")
+
+	_, err = parseDrive([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -5,13 +5,15 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/cesareyeserrano/ultron-ap/internal/execrunner"
 )
 
 const refreshInterval = 30 * time.Second
 
 // Monitor periodically refreshes systemd service data.
 type Monitor struct {
-	runner    CommandRunner
+	runner    execrunner.Runner
 	mu        sync.RWMutex
 	services  []ServiceInfo
 	available bool
@@ -22,7 +24,7 @@ type Monitor struct {
 // NewMonitor creates a systemd monitor. If systemctl is not available,
 // the monitor logs a warning and returns Available() == false.
 func NewMonitor() *Monitor {
-	m := &Monitor{runner: &ExecRunner{}}
+	m := &Monitor{runner: execrunner.Combined{}}
 
 	// Check if systemctl exists
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -40,7 +42,7 @@ func NewMonitor() *Monitor {
 }
 
 // newMonitorWithRunner creates a monitor with an injected runner (for testing).
-func newMonitorWithRunner(runner CommandRunner) *Monitor {
+func newMonitorWithRunner(runner execrunner.Runner) *Monitor {
 	return &Monitor{
 		runner:    runner,
 		available: runner != nil,
@@ -1,19 +0,0 @@
-package systemd
-
-import (
-	"context"
-	"os/exec"
-)
-
-// CommandRunner abstracts shell command execution for testability.
-type CommandRunner interface {
-	Run(ctx context.Context, name string, args ...string) ([]byte, error)
-}
-
-// ExecRunner runs commands using os/exec.
-type ExecRunner struct{}
-
-// Run executes a command and returns its combined output.
-func (r *ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
-	return exec.CommandContext(ctx, name, args...).CombinedOutput()
-}